@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/prices"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+// runBacktestCommand ejecuta el subcomando "backtest": corre un backtest con los
+// eventos almacenados, imprime el resumen y guarda el resultado
+func runBacktestCommand(ctx context.Context, args []string, pricesPath string, repo ports.StockRepository, results ports.BacktestRepository, recommendationService *services.RecommendationService) error {
+	flags := flag.NewFlagSet("backtest", flag.ContinueOnError)
+
+	pricesFlag := flags.String("prices", pricesPath, "archivo CSV o Parquet con precios de cierre diarios (por defecto BACKTEST_PRICES_PATH)")
+	strategy := flags.String("strategy", "", "estrategia de recomendación (por defecto la de la configuración de scoring)")
+	from := flags.String("from", "", "primer día de señales, YYYY-MM-DD (obligatorio)")
+	to := flags.String("to", "", "último día de señales, YYYY-MM-DD (por defecto hoy)")
+	limit := flags.Int("limit", 0, "recomendaciones tomadas cada día (por defecto 10)")
+	lookback := flags.Int("lookback", 0, "días de eventos que ve la estrategia cada día (por defecto 30)")
+	minScore := flags.Float64("min-score", 0, "score mínimo de las recomendaciones")
+	rebalance := flags.Int("rebalance", 0, "cada cuántos días se generan señales (por defecto 1)")
+	horizons := flags.String("horizons", "", "plazos en días de mercado separados por comas (por defecto 5,20,60)")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	params := backtest.Params{
+		Strategy:      *strategy,
+		LookbackDays:  *lookback,
+		Limit:         *limit,
+		MinScore:      *minScore,
+		RebalanceDays: *rebalance,
+	}
+
+	var err error
+	if params.From, err = time.Parse("2006-01-02", *from); err != nil {
+		return fmt.Errorf("invalid -from %q, use YYYY-MM-DD", *from)
+	}
+	params.To = time.Now().UTC()
+	if *to != "" {
+		if params.To, err = time.Parse("2006-01-02", *to); err != nil {
+			return fmt.Errorf("invalid -to %q, use YYYY-MM-DD", *to)
+		}
+	}
+	if *horizons != "" {
+		for _, part := range strings.Split(*horizons, ",") {
+			days, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return fmt.Errorf("invalid -horizons %q", *horizons)
+			}
+			params.Horizons = append(params.Horizons, days)
+		}
+	}
+
+	var source ports.PriceSource
+	if *pricesFlag != "" {
+		source = prices.NewFileSource(*pricesFlag)
+	}
+
+	service := services.NewBacktestService(repo, results, recommendationService, source)
+
+	report, err := service.Run(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	printBacktestReport(os.Stdout, report)
+	return nil
+}
+
+// printBacktestReport imprime el resumen de un backtest como tabla
+func printBacktestReport(out io.Writer, report backtest.Report) {
+	params, result := report.Params, report.Result
+
+	fmt.Fprintf(out, "Backtest %s: estrategia %s, %s a %s, precios %s\n",
+		report.ID, params.Strategy, params.From.Format("2006-01-02"), params.To.Format("2006-01-02"), report.PricesSource)
+	fmt.Fprintf(out, "%d días de señales, %d recomendaciones de %d tickers (%d sin precios), %d ms\n\n",
+		result.SignalDays, result.Picks, result.Tickers, result.PicksWithoutPrices, report.DurationMs)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "plazo\toperaciones\taciertos\tretorno prom.\treferencia\tret. referencia\texceso\t")
+	for _, h := range result.Horizons {
+		fmt.Fprintf(tw, "%dd\t%d\t%s\t%s\t%s\t%s\t%s\t\n",
+			h.Days, h.Trades, formatRate(h.HitRate), formatPct(h.AvgReturnPct),
+			formatRate(h.BaselineHitRate), formatPct(h.BaselineAvgReturnPct), formatPct(h.ExcessReturnPct))
+	}
+	tw.Flush()
+}
+
+func formatRate(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *v*100)
+}
+
+func formatPct(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", *v)
+}
@@ -2,17 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 
 	httpAdapter "github.com/RobertCastro/stock-insights-api/internal/adapters/primary/http"
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cache"
 	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cockroachdb"
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/prices"
 	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/stockapi"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
 	"github.com/RobertCastro/stock-insights-api/internal/infrastructure/config"
 	"github.com/RobertCastro/stock-insights-api/internal/infrastructure/database"
 )
@@ -39,22 +48,81 @@ func main() {
 		os.Setenv("STOCK_API_AUTH_TOKEN", cfg.StockAPIToken)
 	}
 
-	// Conectar a la base de datos
-	db, err := database.Connect(cfg.GetDBConnectionString())
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
-	}
-	defer db.Close()
+	// Crear repositorio según el almacenamiento configurado
+	var repo ports.StockRepository
+	var syncJobs ports.SyncJobRepository
+	var backtests ports.BacktestRepository
+	var snapshots ports.SnapshotRepository
+	var apiKeys ports.APIKeyRepository
+
+	switch cfg.StorageDriver {
+	case "memory":
+		log.Println("Usando almacenamiento en memoria (modo demo), los datos no se persisten")
+		repo = memory.NewStockRepository()
+		syncJobs = memory.NewSyncJobRepository()
+		backtests = memory.NewBacktestRepository()
+		snapshots = memory.NewSnapshotRepository()
+		apiKeys = memory.NewAPIKeyRepository()
+	case "cockroachdb":
+		// Conectar a la base de datos
+		db, err := database.Connect(cfg.GetDBConnectionString())
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer db.Close()
+
+		crdbRepo := cockroachdb.NewStockRepository(db)
+
+		if err := crdbRepo.InitDB(ctx); err != nil {
+			log.Fatalf("Error initializing database: %v", err)
+		}
 
-	// Crear repositorio
-	repo := cockroachdb.NewStockRepository(db)
+		crdbSyncJobs := cockroachdb.NewSyncJobRepository(db)
 
-	if err := repo.InitDB(ctx); err != nil {
-		log.Fatalf("Error initializing database: %v", err)
+		if err := crdbSyncJobs.InitDB(ctx); err != nil {
+			log.Fatalf("Error initializing sync jobs table: %v", err)
+		}
+
+		crdbBacktests := cockroachdb.NewBacktestRepository(db)
+
+		if err := crdbBacktests.InitDB(ctx); err != nil {
+			log.Fatalf("Error initializing backtests table: %v", err)
+		}
+
+		crdbSnapshots := cockroachdb.NewSnapshotRepository(db)
+
+		if err := crdbSnapshots.InitDB(ctx); err != nil {
+			log.Fatalf("Error initializing recommendation snapshots table: %v", err)
+		}
+
+		crdbAPIKeys := cockroachdb.NewAPIKeyRepository(db)
+
+		if err := crdbAPIKeys.InitDB(ctx); err != nil {
+			log.Fatalf("Error initializing api keys table: %v", err)
+		}
+
+		repo = crdbRepo
+		syncJobs = crdbSyncJobs
+		backtests = crdbBacktests
+		snapshots = crdbSnapshots
+		apiKeys = crdbAPIKeys
+	default:
+		log.Fatalf("Error: STORAGE_DRIVER no soportado: %s", cfg.StorageDriver)
 	}
 
 	// Crear cliente de la API
-	client := stockapi.NewClient()
+	retryPolicy := stockapi.DefaultRetryPolicy()
+	retryPolicy.MaxRetries = cfg.StockAPIMaxRetries
+	retryPolicy.BaseDelay = cfg.StockAPIRetryBaseDelay
+	retryPolicy.MaxDelay = cfg.StockAPIRetryMaxDelay
+	retryPolicy.Deadline = cfg.StockAPIRetryDeadline
+
+	client := stockapi.NewClient(
+		stockapi.WithRetryPolicy(retryPolicy),
+		stockapi.WithRateLimit(cfg.StockAPIRateLimit, cfg.StockAPIRateBurst),
+	)
+
+	syncService := services.NewSyncService(repo, syncJobs, client)
 
 	// Verificar si se debe sincronizar con la API externa
 	syncFlag := os.Getenv("SYNC_DATA")
@@ -63,25 +131,123 @@ func main() {
 			log.Fatalf("Error: STOCK_API_AUTH_TOKEN environment variable is required for sync operation")
 		}
 
-		// Obtener todos los stocks de la API
-		fmt.Println("Obteniendo todos los stocks de la API...")
-		stocks, err := client.FetchAllStocks()
+		// Obtener y guardar los stocks de la API, SYNC_MODE=full recorre todo el dataset
+		mode := models.SyncModeIncremental
+		if os.Getenv("SYNC_MODE") == string(models.SyncModeFull) {
+			mode = models.SyncModeFull
+		}
+
+		fmt.Printf("Sincronizando stocks desde la API (modo %s)...\n", mode)
+		job, err := syncService.RunSync(ctx, mode)
+		switch {
+		case errors.Is(err, services.ErrSyncInProgress):
+			// Otra réplica ya está sincronizando, no hace falta repetirlo
+			fmt.Printf("Sincronización omitida: el trabajo %s ya está en curso\n", job.ID)
+		case err != nil:
+			log.Fatalf("Error syncing stocks: %v", err)
+		default:
+			fmt.Printf("Sincronización %s completada: %d stocks obtenidos, %d insertados, %d actualizados\n",
+				job.ID, job.ItemsFetched, job.ItemsInserted, job.ItemsUpdated)
+		}
+	}
+
+	// Configuración de scoring: se valida al iniciar y se recarga cuando cambia el archivo
+	recommendationService := services.NewRecommendationService(repo)
+
+	// Cache de respuestas, invalidado al terminar cada sincronización exitosa. Las
+	// demás réplicas detectan la sincronización por el checkpoint compartido
+	var responseCache *cache.LRU
+	var cacheInvalidator *services.CacheInvalidator
+	if cfg.CacheSize > 0 {
+		responseCache = cache.NewLRU(cfg.CacheSize, cfg.CacheTTL)
+		recommendationService.SetCache(responseCache)
+		cacheInvalidator = services.NewCacheInvalidator(syncJobs, responseCache)
+		syncService.OnSuccess(func(ctx context.Context, job models.SyncJob) {
+			responseCache.Purge()
+			if err := cacheInvalidator.Check(ctx); err != nil {
+				log.Printf("Error al leer el checkpoint tras la sincronización %s: %v", job.ID, err)
+			}
+		})
+	}
+
+	if cfg.ScoringConfigPath != "" {
+		scoringConfig, err := config.LoadScoringConfig(cfg.ScoringConfigPath)
 		if err != nil {
-			log.Fatalf("Error fetching all stocks: %v", err)
+			log.Fatalf("Error loading scoring config: %v", err)
 		}
+		if err := recommendationService.SetScoringConfig(scoringConfig); err != nil {
+			log.Fatalf("Error applying scoring config: %v", err)
+		}
+		log.Printf("Configuración de scoring cargada desde %s", cfg.ScoringConfigPath)
+	}
+
+	// Subcomando "backtest": ejecuta un backtest con los datos almacenados y termina
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := runBacktestCommand(ctx, os.Args[2:], cfg.BacktestPricesPath, repo, backtests, recommendationService); err != nil {
+			log.Fatalf("Error running backtest: %v", err)
+		}
+		return
+	}
+
+	// El servidor no inicia con una configuración que dejaría la API inaccesible
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Error: configuración inválida: %v", err)
+	}
 
-		fmt.Printf("Obtenidos %d stocks en total\n", len(stocks))
+	var priceSource ports.PriceSource
+	if cfg.BacktestPricesPath != "" {
+		priceSource = prices.NewFileSource(cfg.BacktestPricesPath)
+	}
+	backtestService := services.NewBacktestService(repo, backtests, recommendationService, priceSource)
 
-		// Guardar todos los stocks en la base de datos
-		fmt.Println("Guardando stocks en la base de datos...")
-		if err := repo.SaveStocks(ctx, stocks); err != nil {
-			log.Fatalf("Error saving stocks: %v", err)
+	// Cada sincronización exitosa reemplaza los snapshots del día con el ranking actualizado
+	snapshotService := services.NewSnapshotService(recommendationService, snapshots)
+	syncService.OnSuccess(func(ctx context.Context, job models.SyncJob) {
+		if err := snapshotService.TakeSnapshots(ctx); err != nil {
+			log.Printf("Error al tomar los snapshots de recomendaciones tras la sincronización %s: %v", job.ID, err)
 		}
+	})
 
-		fmt.Println("Stocks guardados correctamente")
+	// Autenticación con API keys: API_ADMIN_KEY registra la clave admin inicial
+	var apiKeyService *services.APIKeyService
+	if cfg.AuthEnabled {
+		apiKeyService = services.NewAPIKeyService(apiKeys)
+		apiKeyService.SetDefaultDailyQuota(cfg.APIDailyQuota)
+		if err := apiKeyService.EnsureKey(ctx, "bootstrap admin", cfg.APIAdminKey, models.RoleAdmin); err != nil {
+			log.Fatalf("Error registering API_ADMIN_KEY: %v", err)
+		}
+		log.Printf("API key de administración configurada: %s", maskToken(cfg.APIAdminKey))
+	} else {
+		log.Println("Advertencia: AUTH_ENABLED=false, las rutas de /api/v1 no requieren API key")
 	}
 
-	router := httpAdapter.NewRouter(repo, client)
+	routerOptions := httpAdapter.Options{AllowedOrigins: cfg.CORSAllowedOrigins}
+	if cfg.RateLimitEnabled {
+		routerOptions.RateLimits = &httpAdapter.RateLimits{
+			Default: httpAdapter.RateLimit{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst},
+			Routes: map[string]httpAdapter.RateLimit{
+				"/api/v1/stocks":          {Rate: cfg.RateLimitStocksRPS, Burst: cfg.RateLimitStocksBurst},
+				"/api/v1/recommendations": {Rate: cfg.RateLimitRecommendationsRPS, Burst: cfg.RateLimitRecommendationsBurst},
+			},
+			PerIP: httpAdapter.RateLimit{Rate: cfg.RateLimitIPRPS, Burst: cfg.RateLimitIPBurst},
+		}
+	}
+
+	// Los límites por IP toman la IP del cliente de X-Forwarded-For solo si la
+	// agregaron los proxies de confianza
+	trustedProxies, err := httpAdapter.ParseTrustedProxies(cfg.TrustedProxyHops, cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Error: configuración inválida de proxies de confianza: %v", err)
+	}
+	routerOptions.TrustedProxies = trustedProxies
+
+	// Las consultas HTTP de un stock por ticker pasan por el cache
+	httpRepo := repo
+	if responseCache != nil {
+		httpRepo = cache.NewStockRepository(repo, responseCache)
+	}
+
+	router := httpAdapter.NewRouter(httpRepo, client, syncService, recommendationService, snapshotService, backtestService, apiKeyService, routerOptions)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -97,10 +263,43 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Apagado ordenado ante SIGINT o SIGTERM, cancelando las sincronizaciones en curso
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.ScoringConfigPath != "" && cfg.ScoringConfigReloadInterval > 0 {
+		go config.WatchScoringConfig(shutdownCtx, cfg.ScoringConfigPath, cfg.ScoringConfigReloadInterval, recommendationService.SetScoringConfig)
+	}
+
+	if cfg.RecommendationSnapshotInterval > 0 {
+		go snapshotService.Run(shutdownCtx, cfg.RecommendationSnapshotInterval)
+	}
+
+	if cacheInvalidator != nil && cfg.CacheInvalidationInterval > 0 {
+		go cacheInvalidator.Run(shutdownCtx, cfg.CacheInvalidationInterval)
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-shutdownCtx.Done()
+		log.Println("Apagando el servidor HTTP...")
+
+		syncService.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Error al apagar el servidor HTTP: %v", err)
+		}
+	}()
+
 	fmt.Printf("Servidor HTTP iniciado en el puerto %s\n", port)
-	if err := server.ListenAndServe(); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Error al iniciar el servidor HTTP: %v", err)
 	}
+
+	<-shutdownDone
 }
 
 // Ocultar parte del token cuando se imprime en los logs
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/primary/http/handlers"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// adminPathPrefix agrupa las rutas de administración, que requieren el rol admin
+const adminPathPrefix = "/api/v1/admin/"
+
+// Exige una API key válida, enviada en el header X-API-Key o como
+// "Authorization: Bearer <key>", con el rol que requiere la solicitud
+func authMiddleware(keys *services.APIKeyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := apiKeyFromRequest(r)
+			if secret == "" {
+				handlers.Unauthorized(w, r, "Se requiere una API key (header X-API-Key o Authorization: Bearer)")
+				return
+			}
+
+			key, err := keys.Authenticate(r.Context(), secret)
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				handlers.Unauthorized(w, r, "API key inválida o revocada")
+				return
+			}
+			if err != nil {
+				handlers.RespondError(w, r, err, "Error al validar la API key")
+				return
+			}
+
+			required := requiredRole(r)
+			if !key.Role.Allows(required) {
+				handlers.Forbidden(w, r, "La operación requiere el rol "+string(required))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(handlers.WithAPIKey(r.Context(), key)))
+		})
+	}
+}
+
+// Las consultas requieren el rol reader; cualquier otro método y las rutas de
+// administración requieren admin, de modo que los endpoints de escritura nuevos
+// quedan protegidos sin configurarlos uno por uno
+func requiredRole(r *http.Request) models.Role {
+	if strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+		return models.RoleAdmin
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.RoleReader
+	default:
+		return models.RoleAdmin
+	}
+}
+
+// Obtiene la clave de la solicitud, vacía si no se envió
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   models.Role
+	}{
+		{http.MethodGet, "/api/v1/stocks", models.RoleReader},
+		{http.MethodHead, "/api/v1/stocks/AAPL", models.RoleReader},
+		{http.MethodOptions, "/api/v1/recommendations", models.RoleReader},
+		{http.MethodPost, "/api/v1/sync", models.RoleAdmin},
+		{http.MethodPost, "/api/v1/backtests", models.RoleAdmin},
+		{http.MethodPut, "/api/v1/stocks/AAPL", models.RoleAdmin},
+		{http.MethodPatch, "/api/v1/stocks/AAPL", models.RoleAdmin},
+		{http.MethodDelete, "/api/v1/admin/keys/abc", models.RoleAdmin},
+		// Las rutas de administración requieren admin también para consultar
+		{http.MethodGet, "/api/v1/admin/keys", models.RoleAdmin},
+		{http.MethodGet, "/api/v1/adminish", models.RoleReader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := requiredRole(req); got != tt.want {
+				t.Errorf("requiredRole() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	const (
+		readerKey  = "reader-test-key-0123456789abcdefghijk"
+		adminKey   = "admin-test-key-0123456789abcdefghijkl"
+		revokedKey = "revoked-test-key-0123456789abcdefghij"
+	)
+
+	ctx := context.Background()
+	keys := services.NewAPIKeyService(memory.NewAPIKeyRepository())
+	for secret, role := range map[string]models.Role{readerKey: models.RoleReader, adminKey: models.RoleAdmin, revokedKey: models.RoleAdmin} {
+		if err := keys.EnsureKey(ctx, string(role), secret, role); err != nil {
+			t.Fatalf("EnsureKey() error: %v", err)
+		}
+	}
+	revoked, err := keys.Authenticate(ctx, revokedKey)
+	if err != nil {
+		t.Fatalf("Authenticate() error: %v", err)
+	}
+	if _, err := keys.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatalf("Revoke() error: %v", err)
+	}
+
+	router := NewRouter(memory.NewStockRepository(), nil, nil, nil, nil, nil, keys, Options{}).SetupRoutes()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		status int
+	}{
+		{"missing key", http.MethodGet, "/api/v1/stocks", "", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/api/v1/stocks", "X-API-Key", "unknown", http.StatusUnauthorized},
+		{"revoked key", http.MethodGet, "/api/v1/stocks", "X-API-Key", revokedKey, http.StatusUnauthorized},
+		{"reader key", http.MethodGet, "/api/v1/stocks", "X-API-Key", readerKey, http.StatusOK},
+		{"bearer token", http.MethodGet, "/api/v1/stocks", "Authorization", "Bearer " + readerKey, http.StatusOK},
+		{"lowercase bearer scheme", http.MethodGet, "/api/v1/stocks", "Authorization", "bearer " + readerKey, http.StatusOK},
+		{"other scheme", http.MethodGet, "/api/v1/stocks", "Authorization", "Basic " + readerKey, http.StatusUnauthorized},
+		{"reader writes", http.MethodPost, "/api/v1/sync", "X-API-Key", readerKey, http.StatusForbidden},
+		{"reader lists keys", http.MethodGet, "/api/v1/admin/keys", "X-API-Key", readerKey, http.StatusForbidden},
+		{"admin lists keys", http.MethodGet, "/api/v1/admin/keys", "X-API-Key", adminKey, http.StatusOK},
+		{"public health check", http.MethodGet, "/health", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
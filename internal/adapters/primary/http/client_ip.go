@@ -0,0 +1,124 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies indica en qué proxies delante del servidor se confía para tomar la
+// IP del cliente de X-Forwarded-For. Sin proxies de confianza se usa la IP de la
+// conexión, porque cualquier cliente puede enviar el header
+type TrustedProxies struct {
+	// Hops es la cantidad de proxies delante del servidor, como un balanceador de carga
+	Hops int
+	// Networks son las redes de los proxies de confianza, en cualquier posición de la cadena
+	Networks []netip.Prefix
+}
+
+// ParseTrustedProxies arma la configuración de proxies a partir de la cantidad de
+// saltos y una lista de redes CIDR o IPs individuales
+func ParseTrustedProxies(hops int, networks []string) (TrustedProxies, error) {
+	if hops < 0 {
+		return TrustedProxies{}, fmt.Errorf("trusted proxy hops must not be negative: %d", hops)
+	}
+
+	proxies := TrustedProxies{Hops: hops}
+	for _, value := range networks {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return TrustedProxies{}, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+			proxies.Networks = append(proxies.Networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return TrustedProxies{}, fmt.Errorf("invalid trusted proxy network %q: %w", value, err)
+		}
+		proxies.Networks = append(proxies.Networks, prefix.Masked())
+	}
+
+	return proxies, nil
+}
+
+// IsZero indica si no se confía en ningún proxy
+func (p TrustedProxies) IsZero() bool {
+	return p.Hops == 0 && len(p.Networks) == 0
+}
+
+// clientIP obtiene la IP del cliente. Recorre X-Forwarded-For desde la conexión
+// hacia el origen: cada dirección agregada por un proxy de confianza reemplaza a la
+// anterior, y la primera que no es de confianza es el cliente. Las entradas que
+// ningún proxy de confianza agregó se ignoran, de modo que no se pueden falsificar
+func (p TrustedProxies) clientIP(r *http.Request) string {
+	remote := remoteAddr(r)
+	if p.IsZero() {
+		return remote
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+
+	client := remote
+	for hop, i := 0, len(forwarded)-1; i >= 0; hop, i = hop+1, i-1 {
+		if !p.trusts(client, hop) {
+			break
+		}
+
+		addr, ok := parseForwardedAddr(forwarded[i])
+		if !ok {
+			break
+		}
+		client = addr.String()
+	}
+
+	return client
+}
+
+// trusts indica si la dirección, a hop proxies del servidor, es un proxy de confianza
+func (p TrustedProxies) trusts(address string, hop int) bool {
+	if hop < p.Hops {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range p.Networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwardedAddr interpreta una entrada de X-Forwarded-For, con o sin puerto
+func parseForwardedAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// Obtiene la IP de la conexión
+func remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
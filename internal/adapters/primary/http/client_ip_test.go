@@ -0,0 +1,135 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	mustParse := func(hops int, networks ...string) TrustedProxies {
+		proxies, err := ParseTrustedProxies(hops, networks)
+		if err != nil {
+			t.Fatalf("ParseTrustedProxies() error: %v", err)
+		}
+		return proxies
+	}
+
+	tests := []struct {
+		name      string
+		proxies   TrustedProxies
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"no trusted proxies ignores the header", TrustedProxies{}, "203.0.113.7:4000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"remote address without port", TrustedProxies{}, "203.0.113.7", nil, "203.0.113.7"},
+
+		{"one hop", mustParse(1), "10.0.0.2:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		// El cliente puede anteponer direcciones falsas; solo cuenta la que agregó el proxy
+		{"one hop with a spoofed entry", mustParse(1), "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"two hops", mustParse(2), "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.1, 10.0.0.9"}, "198.51.100.1"},
+		{"two hops in separate headers", mustParse(2), "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.1", "10.0.0.9"}, "198.51.100.1"},
+		{"fewer entries than hops", mustParse(3), "10.0.0.2:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"hops without the header", mustParse(1), "10.0.0.2:4000", nil, "10.0.0.2"},
+
+		{"trusted network", mustParse(0, "10.0.0.0/8"), "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.1, 10.1.2.3"}, "198.51.100.1"},
+		{"untrusted connection ignores the header", mustParse(0, "10.0.0.0/8"), "203.0.113.7:4000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted single address", mustParse(0, "192.0.2.10"), "192.0.2.10:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"only trusted addresses", mustParse(0, "10.0.0.0/8"), "10.0.0.2:4000", []string{"10.0.0.3, 10.0.0.4"}, "10.0.0.3"},
+		{"IPv6 network", mustParse(0, "2001:db8::/32"), "[2001:db8::1]:4000", []string{"2001:db8:ffff::5"}, "2001:db8:ffff::5"},
+		{"IPv4-mapped connection", mustParse(0, "10.0.0.0/8"), "[::ffff:10.0.0.2]:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"entry with port", mustParse(1), "10.0.0.2:4000", []string{"198.51.100.1:5000"}, "198.51.100.1"},
+		{"invalid entry keeps the last valid address", mustParse(2), "10.0.0.2:4000", []string{"unknown, 198.51.100.1"}, "198.51.100.1"},
+		{"hops and networks", mustParse(1, "172.16.0.0/12"), "10.0.0.2:4000", []string{"198.51.100.1, 172.16.0.5"}, "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stocks", nil)
+			req.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := tt.proxies.clientIP(req); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name     string
+		hops     int
+		networks []string
+		want     []string
+		wantErr  bool
+	}{
+		{"none", 0, nil, nil, false},
+		{"networks and addresses", 1, []string{"10.0.0.0/8", " 192.0.2.10 ", "10.1.2.3/16", "::ffff:192.0.2.11"},
+			[]string{"10.0.0.0/8", "192.0.2.10/32", "10.1.0.0/16", "192.0.2.11/32"}, false},
+		{"negative hops", -1, nil, nil, true},
+		{"invalid address", 0, []string{"proxy.internal"}, nil, true},
+		{"invalid network", 0, []string{"10.0.0.0/33"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, err := ParseTrustedProxies(tt.hops, tt.networks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrustedProxies() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var got []string
+			for _, network := range proxies.Networks {
+				got = append(got, network.String())
+			}
+			if len(got) != len(tt.want) || proxies.Hops != tt.hops {
+				t.Fatalf("networks = %v, hops = %d; want %v, %d", got, proxies.Hops, tt.want, tt.hops)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("networks[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIPRateLimitUsesTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(1, nil)
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error: %v", err)
+	}
+	limiter, _ := newTestLimiter(RateLimits{Default: RateLimit{Rate: 0.001, Burst: 1}})
+	handler := ipRateLimitMiddleware(limiter, proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		forwarded string
+		status    int
+	}{
+		{"198.51.100.1", http.StatusOK},
+		{"198.51.100.1", http.StatusTooManyRequests},
+		// Otro cliente detrás del mismo proxy tiene su propio bucket
+		{"198.51.100.2", http.StatusOK},
+		// Cambiar las entradas anteriores del header no evade el límite
+		{"1.2.3.4, 198.51.100.2", http.StatusTooManyRequests},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stocks", nil)
+		req.RemoteAddr = "10.0.0.2:4000"
+		req.Header.Set("X-Forwarded-For", tt.forwarded)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("request %d: status = %d, want %d", i, rec.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// maxAPIKeyRequestBytes limita el tamaño del cuerpo de POST /admin/keys
+const maxAPIKeyRequestBytes = 1 << 12
+
+// APIKeyHandler maneja la administración de API keys
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+// NewAPIKeyHandler crea una nueva instancia del handler de API keys
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+	}
+}
+
+// apiKeyRequest es el cuerpo de POST /admin/keys
+type apiKeyRequest struct {
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	DailyQuota int    `json:"daily_quota"`
+}
+
+// createAPIKeyResponse incluye la clave en claro, que no se vuelve a mostrar
+type createAPIKeyResponse struct {
+	APIKey models.APIKey `json:"api_key"`
+	Key    string        `json:"key"`
+}
+
+// CreateAPIKey crea una API key con el nombre y el rol indicados (reader por defecto)
+// y, opcionalmente, una cuota diaria propia
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIKeyRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Cuerpo de la solicitud inválido: "+err.Error())
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = string(models.RoleReader)
+	}
+
+	key, secret, err := h.service.Create(r.Context(), req.Name, req.Role, req.DailyQuota)
+	if err != nil {
+		RespondError(w, r, err, "Error al crear la API key")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/admin/keys/"+key.ID)
+	sendJSONResponse(w, createAPIKeyResponse{APIKey: key, Key: secret}, http.StatusCreated)
+}
+
+// ListAPIKeys lista las API keys registradas, incluidas las revocadas
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.List(r.Context())
+	if err != nil {
+		RespondError(w, r, err, "Error al listar las API keys")
+		return
+	}
+
+	response := map[string]interface{}{
+		"api_keys": keys,
+		"count":    len(keys),
+	}
+	sendJSONResponse(w, response, http.StatusOK)
+}
+
+// RevokeAPIKey revoca una API key
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.service.Revoke(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		RespondError(w, r, err, "Error al revocar la API key")
+		return
+	}
+
+	sendJSONResponse(w, key, http.StatusOK)
+}
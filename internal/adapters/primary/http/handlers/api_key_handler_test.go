@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	keys := services.NewAPIKeyService(memory.NewAPIKeyRepository())
+	keys.SetDefaultDailyQuota(1000)
+	handler := NewAPIKeyHandler(keys)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		role   models.Role
+		quota  int
+	}{
+		// La cuota 0 aplica la cuota por defecto vigente al consumirla
+		{"default role and quota", `{"name":"dashboard"}`, http.StatusCreated, "", models.RoleReader, 0},
+		{"admin with its own quota", `{"name":"ops","role":"admin","daily_quota":50}`, http.StatusCreated, "", models.RoleAdmin, 50},
+		{"missing name", `{"role":"reader"}`, http.StatusBadRequest, CodeInvalidParameter, "", 0},
+		{"unknown role", `{"name":"x","role":"owner"}`, http.StatusBadRequest, CodeInvalidParameter, "", 0},
+		{"negative quota", `{"name":"x","daily_quota":-1}`, http.StatusBadRequest, CodeInvalidParameter, "", 0},
+		{"unknown field", `{"name":"x","scope":"all"}`, http.StatusBadRequest, CodeInvalidBody, "", 0},
+		{"invalid JSON", `{"name":`, http.StatusBadRequest, CodeInvalidBody, "", 0},
+		{"body too large", `{"name":"` + strings.Repeat("x", maxAPIKeyRequestBytes) + `"}`, http.StatusBadRequest, CodeInvalidBody, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/keys", strings.NewReader(tt.body))
+			rec := serve(handler.CreateAPIKey, req, nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			if tt.status != http.StatusCreated {
+				var response ErrorResponse
+				decodeBody(t, rec, &response)
+				if response.Error.Code != tt.code {
+					t.Errorf("code = %q, want %q", response.Error.Code, tt.code)
+				}
+				return
+			}
+
+			var response createAPIKeyResponse
+			decodeBody(t, rec, &response)
+			if response.APIKey.Role != tt.role || response.APIKey.DailyQuota != tt.quota {
+				t.Errorf("api key = %+v, want role %s and quota %d", response.APIKey, tt.role, tt.quota)
+			}
+			if got := rec.Header().Get("Location"); got != "/api/v1/admin/keys/"+response.APIKey.ID {
+				t.Errorf("Location = %q, want the key path", got)
+			}
+
+			// La clave en claro retornada autentica con el rol creado
+			key, err := keys.Authenticate(context.Background(), response.Key)
+			if err != nil || key.ID != response.APIKey.ID || key.Role != tt.role {
+				t.Errorf("Authenticate() = %+v, %v; want the created key", key, err)
+			}
+			if !strings.HasPrefix(response.Key, response.APIKey.Prefix) {
+				t.Errorf("key does not start with its prefix %q", response.APIKey.Prefix)
+			}
+		})
+	}
+}
+
+func TestListAndRevokeAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := services.NewAPIKeyService(memory.NewAPIKeyRepository())
+	handler := NewAPIKeyHandler(keys)
+
+	created, secret, err := keys.Create(ctx, "dashboard", "reader", 0)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, _, err := keys.Create(ctx, "ops", "admin", 0); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	rec := serve(handler.RevokeAPIKey, httptest.NewRequest(http.MethodDelete, "/api/v1/admin/keys/"+created.ID, nil), map[string]string{"id": created.ID})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var revoked models.APIKey
+	decodeBody(t, rec, &revoked)
+	if revoked.ID != created.ID || revoked.RevokedAt == nil {
+		t.Errorf("revoked key = %+v, want %s with revoked_at", revoked, created.ID)
+	}
+	if _, err := keys.Authenticate(ctx, secret); err == nil {
+		t.Error("a revoked key still authenticates")
+	}
+
+	rec = serve(handler.RevokeAPIKey, httptest.NewRequest(http.MethodDelete, "/api/v1/admin/keys/missing", nil), map[string]string{"id": "missing"})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("revoke of an unknown key status = %d, want 404", rec.Code)
+	}
+
+	// El listado incluye las keys revocadas y nunca sus hashes ni claves en claro
+	rec = serve(handler.ListAPIKeys, httptest.NewRequest(http.MethodGet, "/api/v1/admin/keys", nil), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, secret) || strings.Contains(body, created.Hash) {
+		t.Error("list response includes a key secret or hash")
+	}
+	var response struct {
+		APIKeys []models.APIKey `json:"api_keys"`
+		Count   int             `json:"count"`
+	}
+	decodeBody(t, rec, &response)
+	if response.Count != 2 || len(response.APIKeys) != 2 {
+		t.Errorf("list = %+v, want 2 keys", response)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+// maxBacktestRequestBytes limita el tamaño del cuerpo de POST /backtests
+const maxBacktestRequestBytes = 1 << 16
+
+// BacktestHandler maneja las solicitudes HTTP para backtests de estrategias
+type BacktestHandler struct {
+	service *services.BacktestService
+}
+
+// NewBacktestHandler crea una nueva instancia del handler de backtests
+func NewBacktestHandler(service *services.BacktestService) *BacktestHandler {
+	return &BacktestHandler{
+		service: service,
+	}
+}
+
+// backtestRequest es el cuerpo de POST /backtests, con fechas YYYY-MM-DD
+type backtestRequest struct {
+	Strategy      string  `json:"strategy"`
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	LookbackDays  int     `json:"lookback_days"`
+	Limit         int     `json:"limit"`
+	MinScore      float64 `json:"min_score"`
+	Horizons      []int   `json:"horizons"`
+	RebalanceDays int     `json:"rebalance_days"`
+}
+
+// params convierte la solicitud en parámetros del backtest
+func (req backtestRequest) params() (backtest.Params, error) {
+	params := backtest.Params{
+		Strategy:      req.Strategy,
+		LookbackDays:  req.LookbackDays,
+		Limit:         req.Limit,
+		MinScore:      req.MinScore,
+		Horizons:      req.Horizons,
+		RebalanceDays: req.RebalanceDays,
+	}
+
+	var err error
+	if params.From, err = time.Parse("2006-01-02", req.From); err != nil {
+		return params, fmt.Errorf("parámetro from inválido: %q (use YYYY-MM-DD)", req.From)
+	}
+	if params.To, err = time.Parse("2006-01-02", req.To); err != nil {
+		return params, fmt.Errorf("parámetro to inválido: %q (use YYYY-MM-DD)", req.To)
+	}
+
+	return params, nil
+}
+
+// RunBacktest ejecuta un backtest de forma síncrona y retorna el resultado guardado
+func (h *BacktestHandler) RunBacktest(w http.ResponseWriter, r *http.Request) {
+	var req backtestRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBacktestRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Cuerpo de la solicitud inválido: "+err.Error())
+		return
+	}
+
+	params, err := req.params()
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	report, err := h.service.Run(r.Context(), params)
+	if err != nil {
+		RespondError(w, r, err, "Error al ejecutar el backtest")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/backtests/"+report.ID)
+	sendJSONResponse(w, report, http.StatusCreated)
+}
+
+// GetBacktest retorna un backtest guardado
+func (h *BacktestHandler) GetBacktest(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	report, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener el backtest")
+		return
+	}
+
+	sendJSONResponse(w, report, http.StatusOK)
+}
+
+// ListBacktests lista los backtests más recientes
+func (h *BacktestHandler) ListBacktests(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			if l > 100 {
+				l = 100
+			}
+			limit = l
+		}
+	}
+
+	reports, err := h.service.List(r.Context(), limit)
+	if err != nil {
+		RespondError(w, r, err, "Error al listar los backtests")
+		return
+	}
+
+	if reports == nil {
+		reports = []backtest.Report{}
+	}
+
+	response := map[string]interface{}{
+		"backtests": reports,
+		"count":     len(reports),
+	}
+	sendJSONResponse(w, response, http.StatusOK)
+}
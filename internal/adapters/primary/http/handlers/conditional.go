@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sendCacheableJSON responde data con ETag y, si lastModified no es cero, con
+// Last-Modified. Si la solicitud
+// condicional (If-None-Match o If-Modified-Since) indica que el cliente ya tiene
+// esta versión, responde 304 sin cuerpo
+func sendCacheableJSON(w http.ResponseWriter, r *http.Request, data interface{}, lastModified time.Time) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error al codificar respuesta JSON: %v", err)
+		sendJSONResponse(w, data, http.StatusOK)
+		return
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// El cliente debe revalidar siempre; la respuesta depende de su API key
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// notModified evalúa los encabezados condicionales. If-None-Match tiene prioridad
+// sobre If-Modified-Since, como indica RFC 9110
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return false
+		}
+		// Last-Modified tiene resolución de segundos
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// requestIDKey es la clave del ID de la solicitud en el contexto
+type requestIDKey struct{}
+
+// apiKeyKey es la clave de la API key autenticada en el contexto
+type apiKeyKey struct{}
+
+// WithRequestID guarda el ID de la solicitud en el contexto
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext retorna el ID de la solicitud, vacío si no tiene
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithAPIKey guarda en el contexto la API key con la que se autenticó la solicitud
+func WithAPIKey(ctx context.Context, key models.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext retorna la API key de la solicitud. El booleano es false si
+// la solicitud no se autenticó, por ejemplo con la autenticación desactivada
+func APIKeyFromContext(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(models.APIKey)
+	return key, ok
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// errInvalidCursor se retorna cuando el cursor no puede decodificarse
+var errInvalidCursor = errors.New("parámetro cursor inválido")
+
+// cursorPayload es el contenido serializado de un cursor opaco
+type cursorPayload struct {
+	OrderBy   string `json:"o"`
+	SortOrder string `json:"s"`
+	Value     string `json:"v,omitempty"`
+	Null      bool   `json:"n,omitempty"`
+	Ticker    string `json:"t"`
+	Backward  bool   `json:"b,omitempty"`
+}
+
+// encodeCursor serializa un cursor como base64 URL-safe
+func encodeCursor(cursor models.StockCursor) string {
+	data, _ := json.Marshal(cursorPayload(cursor))
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor interpreta un cursor generado por encodeCursor
+func decodeCursor(raw string) (models.StockCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return models.StockCursor{}, errInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.Ticker == "" || !validOrderFields[payload.OrderBy] {
+		return models.StockCursor{}, errInvalidCursor
+	}
+	if payload.SortOrder != "ASC" && payload.SortOrder != "DESC" {
+		return models.StockCursor{}, errInvalidCursor
+	}
+
+	// El valor debe poder interpretarse con el tipo de la columna de ordenamiento
+	if !payload.Null {
+		switch payload.OrderBy {
+		case "time":
+			if _, err := time.Parse(time.RFC3339Nano, payload.Value); err != nil {
+				return models.StockCursor{}, errInvalidCursor
+			}
+		case "target_from", "target_to", "target_change":
+			if _, err := strconv.ParseFloat(payload.Value, 64); err != nil {
+				return models.StockCursor{}, errInvalidCursor
+			}
+		}
+	}
+
+	return models.StockCursor(payload), nil
+}
+
+// cursorAt construye el cursor que apunta a la posición de un stock
+func cursorAt(stock models.Stock, orderBy, sortOrder string, backward bool) models.StockCursor {
+	cursor := models.StockCursor{
+		OrderBy:   orderBy,
+		SortOrder: sortOrder,
+		Ticker:    stock.Ticker,
+		Backward:  backward,
+	}
+
+	var number *float64
+	switch orderBy {
+	case "ticker":
+		cursor.Value = stock.Ticker
+	case "company":
+		cursor.Value = stock.Company
+	case "brokerage":
+		cursor.Value = stock.Brokerage
+	case "rating_from":
+		cursor.Value = stock.RatingFrom
+	case "rating_to":
+		cursor.Value = stock.RatingTo
+	case "time":
+		cursor.Value = stock.Time.UTC().Format(time.RFC3339Nano)
+		return cursor
+	case "target_from":
+		if stock.TargetFromPrice != nil {
+			number = &stock.TargetFromPrice.Amount
+		}
+	case "target_to":
+		if stock.TargetToPrice != nil {
+			number = &stock.TargetToPrice.Amount
+		}
+	case "target_change":
+		number = stock.TargetChangePct
+	default:
+		return cursor
+	}
+
+	if strings.HasPrefix(orderBy, "target_") {
+		if number == nil {
+			cursor.Null = true
+		} else {
+			cursor.Value = strconv.FormatFloat(*number, 'f', -1, 64)
+		}
+	}
+
+	return cursor
+}
+
+// setLinkHeader agrega los enlaces rel="next" y rel="prev" conservando los
+// filtros de la solicitud
+func setLinkHeader(w http.ResponseWriter, r *http.Request, next, prev string) {
+	var links []string
+
+	for _, link := range []struct{ cursor, rel string }{{next, "next"}, {prev, "prev"}} {
+		if link.cursor == "" {
+			continue
+		}
+
+		query := r.URL.Query()
+		query.Del("page")
+		query.Set("cursor", link.cursor)
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), link.rel))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
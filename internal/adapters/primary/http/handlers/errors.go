@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// Códigos de error estables de la API, pensados para que los clientes los comparen
+// en lugar del mensaje
+const (
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeInvalidBody         = "invalid_body"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeConflict            = "conflict"
+	CodeRateLimited         = "rate_limited"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeMisconfigured       = "misconfigured"
+	CodeInternal            = "internal_error"
+)
+
+// ErrorBody describe un error de la API
+type ErrorBody struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// ErrorResponse es el formato de todas las respuestas de error
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// writeError responde un error con el formato común
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeErrorDetails(w, r, status, code, message, nil)
+}
+
+// writeErrorDetails responde un error con datos adicionales para el cliente
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]string) {
+	response := ErrorResponse{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: RequestIDFromContext(r.Context()),
+		Details:   details,
+	}}
+	sendJSONResponse(w, response, status)
+}
+
+// badRequest responde un parámetro inválido. Los mensajes de los errores de
+// validación de los handlers están pensados para el cliente
+func badRequest(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+}
+
+// RespondError elige el estado HTTP según la categoría del error. Los errores sin
+// categoría, como los de la base de datos, se registran y se responden con el
+// mensaje dado, sin detalles internos
+func RespondError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidArgument):
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+	case errors.Is(err, models.ErrUnauthenticated):
+		Unauthorized(w, r, err.Error())
+	case errors.Is(err, models.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrConflict):
+		writeError(w, r, http.StatusConflict, CodeConflict, err.Error())
+	case errors.Is(err, models.ErrRateLimited):
+		TooManyRequests(w, r, CodeRateLimited, err.Error())
+	case errors.Is(err, models.ErrUnavailable):
+		log.Printf("[%s] %s: %v", RequestIDFromContext(r.Context()), message, err)
+		writeError(w, r, http.StatusServiceUnavailable, CodeUpstreamUnavailable, message+": servicio no disponible")
+	case errors.Is(err, models.ErrMisconfigured):
+		log.Printf("[%s] %s: %v", RequestIDFromContext(r.Context()), message, err)
+		writeError(w, r, http.StatusInternalServerError, CodeMisconfigured, message+": error de configuración del servicio")
+	default:
+		log.Printf("[%s] %s: %v", RequestIDFromContext(r.Context()), message, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, message)
+	}
+}
+
+// NotFound responde las rutas inexistentes con el formato común
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "Ruta no encontrada: "+r.URL.Path)
+}
+
+// MethodNotAllowed responde los métodos no soportados por una ruta existente
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Método no permitido: "+r.Method+" "+r.URL.Path)
+}
+
+// Unauthorized responde una solicitud sin credenciales válidas
+func Unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="stock-insights-api"`)
+	writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden responde una solicitud autenticada cuyo rol no alcanza para la operación
+func Forbidden(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusForbidden, CodeForbidden, message)
+}
+
+// TooManyRequests responde una solicitud rechazada por el límite de tasa (CodeRateLimited)
+// o por la cuota diaria (CodeQuotaExceeded). El llamador define Retry-After
+func TooManyRequests(w http.ResponseWriter, r *http.Request, code, message string) {
+	writeError(w, r, http.StatusTooManyRequests, code, message)
+}
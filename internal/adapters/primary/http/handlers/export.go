@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// Formatos de exportación
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+)
+
+const (
+	// exportFlushRows es cada cuántas filas se envía lo escrito al cliente
+	exportFlushRows = 100
+
+	// exportWriteTimeout es el plazo de escritura que se renueva en cada envío, para
+	// que una exportación larga no quede cortada por el WriteTimeout del servidor
+	exportWriteTimeout = time.Minute
+)
+
+// parseExportFormat lee el parámetro format, csv por defecto
+func parseExportFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", exportCSV:
+		return exportCSV, nil
+	case exportNDJSON:
+		return exportNDJSON, nil
+	default:
+		return "", fmt.Errorf("parámetro format inválido: %q (use csv o ndjson)", format)
+	}
+}
+
+// exportWriter escribe una exportación fila por fila con transferencia por partes.
+// Los encabezados se envían con la primera fila, de modo que un error previo
+// todavía puede responderse con el formato común de errores
+type exportWriter struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	format     string
+	filename   string
+	header     []string
+
+	csv     *csv.Writer
+	encoder *json.Encoder
+	started bool
+	rows    int
+}
+
+// newExportWriter prepara una exportación en el formato dado. header son las
+// columnas del CSV y filename el nombre sugerido al cliente, sin extensión
+func newExportWriter(w http.ResponseWriter, format, filename string, header []string) *exportWriter {
+	return &exportWriter{
+		w:          w,
+		controller: http.NewResponseController(w),
+		format:     format,
+		filename:   filename,
+		header:     header,
+	}
+}
+
+// start envía los encabezados de la respuesta y, en CSV, la fila de columnas
+func (e *exportWriter) start() error {
+	e.started = true
+
+	contentType := "text/csv; charset=utf-8"
+	if e.format == exportNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	e.w.Header().Set("Content-Type", contentType)
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, e.filename, e.format))
+	e.w.Header().Set("X-Content-Type-Options", "nosniff")
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.format == exportNDJSON {
+		e.encoder = json.NewEncoder(e.w)
+		return nil
+	}
+
+	e.csv = csv.NewWriter(e.w)
+	return e.csv.Write(e.header)
+}
+
+// write escribe una fila: value en NDJSON o record en CSV
+func (e *exportWriter) write(value interface{}, record []string) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if e.format == exportNDJSON {
+		err = e.encoder.Encode(value)
+	} else {
+		err = e.csv.Write(escapeCSVRecord(record))
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// escapeCSVRecord neutraliza las celdas que una planilla interpretaría como fórmula,
+// anteponiendo un apóstrofo. Los textos vienen del proveedor externo, de modo que
+// una empresa llamada "=HYPERLINK(...)" no debe ejecutarse al abrir el CSV. Los
+// números negativos se dejan intactos porque no son fórmulas
+func escapeCSVRecord(record []string) []string {
+	escaped := make([]string, len(record))
+	for i, value := range record {
+		escaped[i] = escapeCSVCell(value)
+	}
+	return escaped
+}
+
+func escapeCSVCell(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && value[0] == '-' {
+		return value
+	}
+	return "'" + value
+}
+
+// flush envía al cliente lo escrito y renueva el plazo de escritura
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	// SetWriteDeadline no está disponible en todos los ResponseWriter; sin él rige el plazo del servidor
+	_ = e.controller.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	return e.controller.Flush()
+}
+
+// finish termina la exportación. Si err ocurrió antes de enviar datos responde un
+// error normal; si ya se enviaron filas corta la conexión, para que el cliente no
+// confunda una exportación incompleta con una completa
+func (e *exportWriter) finish(r *http.Request, err error, message string) {
+	if err == nil {
+		if !e.started {
+			err = e.start()
+		}
+		if err == nil {
+			err = e.flush()
+		}
+		if err == nil {
+			return
+		}
+	}
+
+	if !e.started {
+		RespondError(e.w, r, err, message)
+		return
+	}
+
+	// Un cliente que canceló la descarga no es un error del servicio
+	if r.Context().Err() == nil {
+		log.Printf("[%s] %s después de %d filas: %v", RequestIDFromContext(r.Context()), message, e.rows, err)
+	}
+	e.abort()
+}
+
+// abort corta la respuesta sin terminarla. En HTTP/1 se cierra la conexión, antes
+// del fragmento final de la transferencia por partes; en HTTP/2, donde no se puede
+// tomar la conexión, un plazo de escritura vencido cancela el stream
+func (e *exportWriter) abort() {
+	if conn, _, err := e.controller.Hijack(); err == nil {
+		conn.Close()
+		return
+	}
+
+	_ = e.controller.SetWriteDeadline(time.Now())
+	_ = e.controller.Flush()
+}
+
+// stockExportHeader son las columnas de la exportación CSV de stocks
+var stockExportHeader = []string{
+	"ticker", "company", "brokerage", "action", "rating_from", "rating_to",
+	"rating_from_bucket", "rating_to_bucket", "target_from", "target_to",
+	"target_from_price", "target_to_price", "currency", "target_change_pct", "time",
+}
+
+// stockRecord convierte un stock en una fila CSV
+func stockRecord(stock models.Stock) []string {
+	var fromPrice, toPrice, currency, changePct string
+	if stock.TargetFromPrice != nil {
+		fromPrice = formatFloat(stock.TargetFromPrice.Amount)
+		currency = stock.TargetFromPrice.Currency
+	}
+	if stock.TargetToPrice != nil {
+		toPrice = formatFloat(stock.TargetToPrice.Amount)
+		currency = stock.TargetToPrice.Currency
+	}
+	if stock.TargetChangePct != nil {
+		changePct = formatFloat(*stock.TargetChangePct)
+	}
+
+	return []string{
+		stock.Ticker, stock.Company, stock.Brokerage, stock.Action, stock.RatingFrom, stock.RatingTo,
+		string(stock.RatingFromBucket), string(stock.RatingToBucket), stock.TargetFrom, stock.TargetTo,
+		fromPrice, toPrice, currency, changePct, stock.Time.UTC().Format(time.RFC3339),
+	}
+}
+
+// recommendationExportHeader son las columnas de la exportación CSV de recomendaciones
+var recommendationExportHeader = []string{
+	"rank", "ticker", "company", "score", "potential_return", "brokerage", "action",
+	"rating_from", "rating_to", "target_from", "target_to", "time", "reasons", "rationale",
+}
+
+// recommendationRecord convierte una recomendación en una fila CSV. Los códigos de
+// las razones se separan con ";"
+func recommendationRecord(rank int, result recommendation.RecommendationResult) []string {
+	codes := make([]string, len(result.Reasons))
+	for i, reason := range result.Reasons {
+		codes[i] = string(reason.Code)
+	}
+
+	stock := result.Stock
+	return []string{
+		strconv.Itoa(rank), stock.Ticker, stock.Company, formatFloat(result.Score), result.PotentialReturn,
+		stock.Brokerage, stock.Action, stock.RatingFrom, stock.RatingTo, stock.TargetFrom, stock.TargetTo,
+		stock.Time.UTC().Format(time.RFC3339), strings.Join(codes, ";"), result.Rationale,
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type exportRow struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+func TestExportWriter(t *testing.T) {
+	rows := []exportRow{{"a", 1}, {"b, c", 2}}
+
+	tests := []struct {
+		format      string
+		rows        []exportRow
+		contentType string
+		body        string
+	}{
+		{exportCSV, rows, "text/csv; charset=utf-8", "name,value\na,1\n\"b, c\",2\n"},
+		{exportNDJSON, rows, "application/x-ndjson", "{\"name\":\"a\",\"value\":1}\n{\"name\":\"b, c\",\"value\":2}\n"},
+		{exportCSV, nil, "text/csv; charset=utf-8", "name,value\n"},
+		{exportNDJSON, nil, "application/x-ndjson", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s with %d rows", tt.format, len(tt.rows)), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/export", nil)
+
+			export := newExportWriter(rec, tt.format, "rows", []string{"name", "value"})
+			var err error
+			for _, row := range tt.rows {
+				if err = export.write(row, []string{row.Name, fmt.Sprint(row.Value)}); err != nil {
+					break
+				}
+			}
+			export.finish(req, err, "Error al exportar")
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			wantDisposition := fmt.Sprintf(`attachment; filename="rows.%s"`, tt.format)
+			if got := rec.Header().Get("Content-Disposition"); got != wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, wantDisposition)
+			}
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestExportWriterErrorBeforeFirstRow(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+
+	export := newExportWriter(rec, exportCSV, "rows", []string{"name"})
+	export.finish(req, errors.New("connection reset"), "Error al exportar")
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("error body is not JSON: %v", err)
+	}
+	if response.Error.Code != CodeInternal || response.Error.Message != "Error al exportar" {
+		t.Errorf("error = %+v, want internal error without details", response.Error)
+	}
+}
+
+func TestExportWriterAbortsAfterRows(t *testing.T) {
+	const sent = exportFlushRows + 10
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		export := newExportWriter(w, exportCSV, "rows", []string{"n"})
+		var err error
+		for i := 0; i < sent && err == nil; i++ {
+			err = export.write(nil, []string{fmt.Sprint(i)})
+		}
+		export.finish(r, errors.New("cursor closed"), "Error al exportar")
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	// El cliente debe ver una respuesta incompleta y no un CSV válido truncado
+	body, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("read error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if lines := strings.Count(string(body), "\n"); lines < exportFlushRows {
+		t.Errorf("received %d lines before the cut, want at least %d", lines, exportFlushRows)
+	}
+}
+
+func TestEscapeCSVCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Apple Inc.", "Apple Inc."},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1+cmd|' /C calc'!A0", "'+1+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"a=1", "a=1"},
+		// Los números negativos, como target_change_pct, no son fórmulas
+		{"-12.5", "-12.5"},
+		{"-3e2", "-3e2"},
+		{"+12.5", "'+12.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := escapeCSVCell(tt.value); got != tt.want {
+				t.Errorf("escapeCSVCell(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportWriterEscapesFormulas(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+
+	export := newExportWriter(rec, exportCSV, "rows", []string{"name", "value"})
+	err := export.write(exportRow{"=1+1", -1}, []string{"=1+1", "-1"})
+	if err == nil {
+		err = export.write(exportRow{"@x", 2}, []string{"@x", "2"})
+	}
+	export.finish(req, err, "Error al exportar")
+
+	if want := "name,value\n'=1+1,-1\n'@x,2\n"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		query   string
+		format  string
+		wantErr bool
+	}{
+		{"", exportCSV, false},
+		{"format=csv", exportCSV, false},
+		{"format=ndjson", exportNDJSON, false},
+		{"format=xlsx", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			format, err := parseExportFormat(httptest.NewRequest(http.MethodGet, "/export?"+tt.query, nil))
+			if format != tt.format || (err != nil) != tt.wantErr {
+				t.Errorf("parseExportFormat(%q) = %q, %v; want %q, error %t", tt.query, format, err, tt.format, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,23 +2,23 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"log"
 	"net/http"
 	"os"
 	"time"
 
-	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cockroachdb"
 	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/stockapi"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
 )
 
 // Maneja las solicitudes de verificación de salud del servicio
 type HealthHandler struct {
-	repo   *cockroachdb.StockRepository
+	repo   ports.StockRepository
 	client *stockapi.Client
 }
 
 // Crea una nueva instancia de HealthHandler
-func NewHealthHandler(repo *cockroachdb.StockRepository, client *stockapi.Client) *HealthHandler {
+func NewHealthHandler(repo ports.StockRepository, client *stockapi.Client) *HealthHandler {
 	return &HealthHandler{
 		repo:   repo,
 		client: client,
@@ -56,7 +56,9 @@ func (h *HealthHandler) DetailedHealth(w http.ResponseWriter, r *http.Request) {
 	// Verifica conexión a la base de datos
 	if err := h.repo.Ping(ctx); err != nil {
 		status.Status = "degraded"
-		status.Components["database"] = "error: " + err.Error()
+		// El detalle del error se registra pero no se expone en la respuesta
+		log.Printf("Health check: error de la base de datos: %v", err)
+		status.Components["database"] = "error"
 	} else {
 		status.Components["database"] = "ok"
 	}
@@ -81,10 +83,5 @@ func (h *HealthHandler) DetailedHealth(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(status); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"status":"error","message":"Error encoding response"}`))
-		return
-	}
+	sendJSONResponse(w, status, http.StatusOK)
 }
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// validOrderFields son los campos aceptados por order_by
+var validOrderFields = map[string]bool{
+	"ticker": true, "company": true, "brokerage": true,
+	"rating_from": true, "rating_to": true, "time": true,
+	"target_from": true, "target_to": true, "target_change": true,
+}
+
+// parseStockFilter construye un StockFilter con los parámetros de la solicitud.
+// Los campos de lista aceptan el parámetro repetido (?rating=Buy&rating=Hold) y,
+// salvo brokerage y company, también valores separados por comas (?rating=Buy,Hold).
+// Los valores de rating que pertenecen a la taxonomía filtran por su categoría
+func parseStockFilter(r *http.Request, taxonomy *models.RatingTaxonomy) (models.StockFilter, error) {
+	filter := models.StockFilter{
+		Tickers:     queryList(r, "ticker", true),
+		Brokerages:  queryList(r, "brokerage", false),
+		RatingsFrom: queryList(r, "rating_from", true),
+		RatingsTo:   queryList(r, "rating_to", true),
+		Actions:     queryList(r, "action", true),
+		Company:     strings.TrimSpace(r.URL.Query().Get("company")),
+	}
+
+	for _, rating := range queryList(r, "rating", true) {
+		if bucket, ok := taxonomy.Classify("", rating); ok {
+			filter.RatingBuckets = append(filter.RatingBuckets, bucket)
+		} else {
+			filter.Ratings = append(filter.Ratings, rating)
+		}
+	}
+
+	var err error
+	if filter.From, err = parseOptionalTime(r, "from", false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseOptionalTime(r, "to", true); err != nil {
+		return filter, err
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return filter, fmt.Errorf("el parámetro from debe ser anterior a to")
+	}
+
+	// Filtros numéricos de precio objetivo
+	priceParams := []struct {
+		name   string
+		target **float64
+	}{
+		{"min_target", &filter.MinTarget},
+		{"max_target", &filter.MaxTarget},
+		{"min_target_change", &filter.MinTargetChange},
+		{"max_target_change", &filter.MaxTargetChange},
+	}
+	for _, param := range priceParams {
+		if *param.target, err = parseOptionalFloat(r, param.name); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// parseStockSort valida order_by y sort, con time DESC por defecto
+func parseStockSort(r *http.Request) (string, string, error) {
+	orderBy := r.URL.Query().Get("order_by")
+	sortOrder := r.URL.Query().Get("sort")
+
+	if orderBy == "" {
+		orderBy = "time"
+	} else if !validOrderFields[orderBy] {
+		return "", "", fmt.Errorf("parámetro order_by inválido: %q", orderBy)
+	}
+
+	if sortOrder == "" {
+		sortOrder = "DESC"
+	} else {
+		sortOrder = strings.ToUpper(sortOrder)
+		if sortOrder != "ASC" && sortOrder != "DESC" {
+			return "", "", fmt.Errorf("parámetro sort inválido: %q (use asc o desc)", r.URL.Query().Get("sort"))
+		}
+	}
+
+	return orderBy, sortOrder, nil
+}
+
+// queryList lee un parámetro que admite varios valores
+func queryList(r *http.Request, name string, splitCommas bool) []string {
+	var values []string
+	for _, raw := range r.URL.Query()[name] {
+		parts := []string{raw}
+		if splitCommas {
+			parts = strings.Split(raw, ",")
+		}
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// parseOptionalFloat lee un parámetro numérico opcional, nil si no se envió
+func parseOptionalFloat(r *http.Request, name string) (*float64, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("parámetro %s inválido: %q", name, raw)
+	}
+
+	return &value, nil
+}
+
+// parseOptionalInt lee un parámetro entero opcional, nil si no se envió
+func parseOptionalInt(r *http.Request, name string) (*int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parámetro %s inválido: %q", name, raw)
+	}
+
+	return &value, nil
+}
+
+// parseOptionalTime lee una fecha RFC 3339 o YYYY-MM-DD. Con endOfDay, una fecha
+// sin hora incluye el día completo
+func parseOptionalTime(r *http.Request, name string, endOfDay bool) (*time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, fmt.Errorf("parámetro %s inválido: %q (use YYYY-MM-DD o RFC 3339)", name, raw)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Microsecond)
+	}
+
+	return &t, nil
+}
@@ -1,35 +1,218 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
 )
 
 // RecommendationHandler maneja las solicitudes HTTP para recomendaciones
 type RecommendationHandler struct {
-	service *services.RecommendationService
+	service   *services.RecommendationService
+	snapshots *services.SnapshotService
 }
 
 // NewRecommendationHandler crea una nueva instancia del handler de recomendaciones
-func NewRecommendationHandler(service *services.RecommendationService) *RecommendationHandler {
+func NewRecommendationHandler(service *services.RecommendationService, snapshots *services.SnapshotService) *RecommendationHandler {
 	return &RecommendationHandler{
-		service: service,
+		service:   service,
+		snapshots: snapshots,
 	}
 }
 
-// GetRecommendations maneja la solicitud para obtener recomendaciones de stocks
+// GetRecommendations maneja la solicitud para obtener recomendaciones de stocks.
+// Acepta strategy, limit, lookback_days y min_score para ajustar la configuración por
+// defecto, as_of para evaluarlas en una fecha pasada y rationale=false para omitir
+// la explicación en prosa. Responde con ETag y Last-Modified para solicitudes condicionales
 func (h *RecommendationHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
-	recommendations, err := h.service.GetRecommendations(r.Context())
+	opts, err := parseRecommendationOptions(r)
 	if err != nil {
-		http.Error(w, "Error al generar recomendaciones: "+err.Error(), http.StatusInternalServerError)
+		badRequest(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(recommendations); err != nil {
-		http.Error(w, "Error al codificar respuesta: "+err.Error(), http.StatusInternalServerError)
+	recommendations, err := h.service.GetRecommendations(r.Context(), opts)
+	if err != nil {
+		RespondError(w, r, err, "Error al generar recomendaciones")
+		return
+	}
+
+	sendCacheableJSON(w, r, recommendations, recommendations.GeneratedAt)
+}
+
+// GetAvoidList maneja la solicitud de acciones a evitar: rebajas de rating y recortes
+// del precio objetivo, con los mismos parámetros que GetRecommendations
+func (h *RecommendationHandler) GetAvoidList(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseRecommendationOptions(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	avoid, err := h.service.GetAvoidList(r.Context(), opts)
+	if err != nil {
+		RespondError(w, r, err, "Error al generar la lista de acciones a evitar")
 		return
 	}
+
+	sendCacheableJSON(w, r, avoid, avoid.GeneratedAt)
+}
+
+// ExportRecommendations exporta en CSV o NDJSON (parámetro format) el ranking de
+// recomendaciones con los mismos parámetros que GetRecommendations. Sin limit incluye
+// el ranking completo; el ranking se calcula antes de enviar la primera fila
+func (h *RecommendationHandler) ExportRecommendations(w http.ResponseWriter, r *http.Request) {
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	opts, err := parseRecommendationOptions(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	recommendations, err := h.service.ExportRecommendations(r.Context(), opts)
+	if err != nil {
+		RespondError(w, r, err, "Error al generar recomendaciones")
+		return
+	}
+
+	filename := fmt.Sprintf("recommendations-%s-%s", recommendations.Strategy, recommendations.AsOf.UTC().Format("20060102"))
+	export := newExportWriter(w, format, filename, recommendationExportHeader)
+	for i, result := range recommendations.Recommendations {
+		if err = r.Context().Err(); err != nil {
+			break
+		}
+		if err = export.write(result, recommendationRecord(i+1, result)); err != nil {
+			break
+		}
+	}
+	export.finish(r, err, "Error al exportar recomendaciones")
+}
+
+// ListStrategies lista las estrategias de recomendación disponibles y sus parámetros
+func (h *RecommendationHandler) ListStrategies(w http.ResponseWriter, r *http.Request) {
+	strategies := h.service.Strategies()
+
+	response := map[string]interface{}{
+		"strategies": strategies,
+		"count":      len(strategies),
+	}
+
+	sendJSONResponse(w, response, http.StatusOK)
+}
+
+// GetRecommendationHistory retorna el snapshot de recomendaciones guardado para el
+// día date (YYYY-MM-DD), o el más reciente si no se indica, de la estrategia strategy
+func (h *RecommendationHandler) GetRecommendationHistory(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		if err := validateSnapshotDate("date", date); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+	}
+
+	snapshot, err := h.snapshots.GetSnapshot(r.Context(), date, r.URL.Query().Get("strategy"))
+	if !h.handleSnapshotError(w, r, err) {
+		return
+	}
+
+	sendJSONResponse(w, snapshot, http.StatusOK)
+}
+
+// GetRecommendationDiff compara los snapshots de los días from y to: tickers que
+// entraron y salieron del ranking y los que cambiaron de posición
+func (h *RecommendationHandler) GetRecommendationDiff(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if err := validateSnapshotDate("from", from); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+	if err := validateSnapshotDate("to", to); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	diff, err := h.snapshots.Diff(r.Context(), from, to, r.URL.Query().Get("strategy"))
+	if !h.handleSnapshotError(w, r, err) {
+		return
+	}
+
+	sendJSONResponse(w, diff, http.StatusOK)
+}
+
+// handleSnapshotError responde los errores de consulta de snapshots. Retorna false
+// si hubo un error
+func (h *RecommendationHandler) handleSnapshotError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err == nil {
+		return true
+	}
+	RespondError(w, r, err, "Error al obtener el snapshot de recomendaciones")
+	return false
+}
+
+// validateSnapshotDate verifica que un parámetro obligatorio sea una fecha YYYY-MM-DD
+func validateSnapshotDate(name, value string) error {
+	if _, err := time.Parse(recommendation.SnapshotDateLayout, value); err != nil {
+		return fmt.Errorf("parámetro %s inválido: %q (use YYYY-MM-DD)", name, value)
+	}
+	return nil
+}
+
+// parseRecommendationOptions lee los parámetros opcionales de la solicitud
+func parseRecommendationOptions(r *http.Request) (services.RecommendationOptions, error) {
+	var opts services.RecommendationOptions
+	var err error
+
+	opts.Strategy = r.URL.Query().Get("strategy")
+	if opts.Limit, err = parseOptionalInt(r, "limit"); err != nil {
+		return opts, err
+	}
+	if opts.LookbackDays, err = parseOptionalInt(r, "lookback_days"); err != nil {
+		return opts, err
+	}
+	if opts.MinScore, err = parseOptionalFloat(r, "min_score"); err != nil {
+		return opts, err
+	}
+	if opts.AsOf, err = parseOptionalTime(r, "as_of", true); err != nil {
+		return opts, err
+	}
+
+	// Restricciones de diversificación
+	if maxPerBrokerage, err := parseOptionalInt(r, "max_per_brokerage"); err != nil {
+		return opts, err
+	} else if maxPerBrokerage != nil {
+		opts.Constraints.MaxPerBrokerage = *maxPerBrokerage
+	}
+	if maxPerSector, err := parseOptionalInt(r, "max_per_sector"); err != nil {
+		return opts, err
+	} else if maxPerSector != nil {
+		opts.Constraints.MaxPerSector = *maxPerSector
+	}
+	if raw := r.URL.Query().Get("dedupe_companies"); raw != "" {
+		dedupe, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("parámetro dedupe_companies inválido: %q", raw)
+		}
+		opts.Constraints.DedupeCompanies = dedupe
+	}
+
+	if raw := r.URL.Query().Get("rationale"); raw != "" {
+		include, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("parámetro rationale inválido: %q", raw)
+		}
+		opts.OmitRationale = !include
+	}
+
+	return opts, nil
 }
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// newTestRecommendationHandler crea un handler cuyo repositorio en memoria tiene
+// count alzas de rating recientes, una por ticker
+func newTestRecommendationHandler(t *testing.T, count int) *RecommendationHandler {
+	t.Helper()
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	taxonomy := models.DefaultRatingTaxonomy()
+
+	stocks := make([]models.Stock, count)
+	for i := range stocks {
+		stocks[i] = models.Stock{
+			Ticker:     fmt.Sprintf("R%02d", i),
+			Company:    fmt.Sprintf("Company %02d", i),
+			Brokerage:  "Goldman Sachs",
+			Action:     "upgraded by",
+			RatingFrom: "Neutral",
+			RatingTo:   "Buy",
+			TargetFrom: "$100.00",
+			TargetTo:   fmt.Sprintf("$%d.00", 110+i),
+			Time:       now.Add(-time.Duration(i+1) * time.Hour),
+		}
+		stocks[i].ParseTargets()
+		stocks[i].ClassifyRatings(taxonomy)
+	}
+
+	repo := memory.NewStockRepository()
+	if _, err := repo.SaveStocks(context.Background(), stocks); err != nil {
+		t.Fatalf("SaveStocks() error: %v", err)
+	}
+
+	service := services.NewRecommendationService(repo)
+	service.SetClock(recommendation.FixedClock(now))
+	return NewRecommendationHandler(service, nil)
+}
+
+func TestExportRecommendations(t *testing.T) {
+	const candidates = 25
+	handler := newTestRecommendationHandler(t, candidates)
+
+	rec := serve(handler.GetRecommendations, httptest.NewRequest(http.MethodGet, "/api/v1/recommendations", nil), nil)
+	var listed services.RecommendationResponse
+	decodeBody(t, rec, &listed)
+	if listed.Count >= candidates {
+		t.Fatalf("GetRecommendations returned %d results, want fewer than the %d candidates", listed.Count, candidates)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		rows   int
+	}{
+		{"", http.StatusOK, candidates},
+		{"limit=3", http.StatusOK, 3},
+		{"format=ndjson&limit=5", http.StatusOK, 5},
+		{"limit=0", http.StatusBadRequest, 0},
+		{"format=pdf", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/recommendations/export?"+tt.query, nil)
+			rec := serve(handler.ExportRecommendations, req, nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if rec.Header().Get("Content-Type") == "application/x-ndjson" {
+				if lines := strings.Count(rec.Body.String(), "\n"); lines != tt.rows {
+					t.Errorf("rows = %d, want %d", lines, tt.rows)
+				}
+				return
+			}
+
+			records, err := csv.NewReader(rec.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid CSV: %v", err)
+			}
+			if len(records) != tt.rows+1 {
+				t.Fatalf("rows = %d, want %d", len(records)-1, tt.rows)
+			}
+			for i, record := range records[1:] {
+				if record[0] != strconv.Itoa(i+1) {
+					t.Errorf("row %d has rank %s", i+1, record[0])
+				}
+			}
+		})
+	}
+}
@@ -1,15 +1,18 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
-	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cockroachdb"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/consensus"
 	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
 )
 
 type Pagination struct {
@@ -19,171 +22,358 @@ type Pagination struct {
 }
 
 type StockHandler struct {
-	repo *cockroachdb.StockRepository
+	repo     ports.StockRepository
+	taxonomy *models.RatingTaxonomy
+
+	// clock da la fecha de referencia cuando la solicitud no indica as_of
+	clock recommendation.Clock
 }
 
-func NewStockHandler(repo *cockroachdb.StockRepository) *StockHandler {
+func NewStockHandler(repo ports.StockRepository) *StockHandler {
 	return &StockHandler{
-		repo: repo,
+		repo:     repo,
+		taxonomy: models.DefaultRatingTaxonomy(),
+		clock:    recommendation.SystemClock,
 	}
 }
 
-// ListStocks maneja la solicitud para listar stocks
+// SetClock reemplaza el reloj del handler. Debe llamarse antes de atender solicitudes
+func (h *StockHandler) SetClock(clock recommendation.Clock) {
+	h.clock = clock
+}
+
+// ListStocks maneja la solicitud para listar stocks. Todos los filtros se combinan
+// y el ordenamiento se respeta para cualquier combinación. Con el parámetro cursor
+// la paginación es por keyset; sin él se conserva la paginación por número de página
 func (h *StockHandler) ListStocks(w http.ResponseWriter, r *http.Request) {
-	// Extraer parámetros de filtrado
-	brokerage := r.URL.Query().Get("brokerage")
-	ticker := r.URL.Query().Get("ticker")
-	rating := r.URL.Query().Get("rating")
+	filter, err := parseStockFilter(r, h.taxonomy)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	orderBy, sortOrder, err := parseStockSort(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
 
-	// Parsear parámetros de paginación
-	pagination := parsePagination(r)
+	pagination, err := parsePagination(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("cursor") != "" {
+		h.listStocksByCursor(w, r, filter, orderBy, sortOrder, pagination.Limit)
+		return
+	}
 
-	// Extraer parámetros de ordenamiento
-	orderBy := r.URL.Query().Get("order_by")
-	sortOrder := r.URL.Query().Get("sort")
+	stocks, err := h.repo.GetStocks(r.Context(), filter, orderBy, sortOrder, pagination.Offset, pagination.Limit)
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener stocks")
+		return
+	}
 
-	// Validar y establecer valores predeterminados para ordenamiento
-	if orderBy == "" {
-		orderBy = "time"
-	} else {
-		// Validar que el campo de ordenamiento sea válido
-		validFields := map[string]bool{
-			"ticker": true, "company": true, "brokerage": true,
-			"rating_from": true, "rating_to": true, "time": true,
+	totalStocks, err := h.repo.CountStocks(r.Context(), filter)
+	if err != nil {
+		RespondError(w, r, err, "Error al contar stocks")
+		return
+	}
+
+	totalPages := (totalStocks + pagination.Limit - 1) / pagination.Limit
+
+	// Los cursores permiten a los clientes pasar a paginación por keyset desde cualquier página
+	var nextCursor, prevCursor string
+	if len(stocks) > 0 {
+		if pagination.Offset+len(stocks) < totalStocks {
+			nextCursor = encodeCursor(cursorAt(stocks[len(stocks)-1], orderBy, sortOrder, false))
 		}
-		if !validFields[orderBy] {
-			orderBy = "time"
+		if pagination.Page > 1 {
+			prevCursor = encodeCursor(cursorAt(stocks[0], orderBy, sortOrder, true))
 		}
 	}
 
-	if sortOrder == "" {
-		sortOrder = "DESC"
-	} else {
-		sortOrder = strings.ToUpper(sortOrder)
-		if sortOrder != "ASC" && sortOrder != "DESC" {
-			sortOrder = "DESC"
-		}
+	response := map[string]interface{}{
+		"stocks":         stocks,
+		"total_stocks":   totalStocks,
+		"total_pages":    totalPages,
+		"current_page":   pagination.Page,
+		"items_per_page": pagination.Limit,
+		"next_cursor":    nextCursor,
+		"prev_cursor":    prevCursor,
 	}
 
-	var stocks []models.Stock
-	var totalStocks int
-	var err error
+	setLinkHeader(w, r, nextCursor, prevCursor)
+	sendJSONResponse(w, response, http.StatusOK)
+}
 
-	// Obtener stocks según los filtros
-	if ticker != "" {
+// ExportStocks exporta en CSV o NDJSON (parámetro format) todos los stocks que cumplen
+// los mismos filtros y ordenamiento que ListStocks. Las filas se leen del cursor de la
+// base de datos y se envían a medida que llegan; si el cliente cancela la descarga la
+// consulta se interrumpe
+func (h *StockHandler) ExportStocks(w http.ResponseWriter, r *http.Request) {
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
 
-		stocks, err = h.repo.GetStocksByTickerPattern(r.Context(), ticker, pagination.Offset, pagination.Limit)
-		if err != nil {
-			http.Error(w, "Error al obtener stocks por ticker: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	filter, err := parseStockFilter(r, h.taxonomy)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
 
-		totalStocks, err = h.repo.CountStocksByTickerPattern(r.Context(), ticker)
-		if err != nil {
-			http.Error(w, "Error al contar stocks por ticker: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else if brokerage != "" {
+	orderBy, sortOrder, err := parseStockSort(r)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
 
-		stocks, err = h.repo.GetStocksByBrokerage(r.Context(), brokerage, pagination.Offset, pagination.Limit)
-		if err != nil {
-			http.Error(w, "Error al obtener stocks por brokerage: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	export := newExportWriter(w, format, "stocks-"+time.Now().UTC().Format("20060102"), stockExportHeader)
+	err = h.repo.StreamStocks(r.Context(), filter, orderBy, sortOrder, func(stock models.Stock) error {
+		return export.write(stock, stockRecord(stock))
+	})
+	export.finish(r, err, "Error al exportar stocks")
+}
 
-		totalStocks, err = h.repo.CountStocksByBrokerage(r.Context(), brokerage)
-		if err != nil {
-			http.Error(w, "Error al contar stocks por brokerage: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else if rating != "" {
+// listStocksByCursor responde una página obtenida por keyset a partir del cursor
+func (h *StockHandler) listStocksByCursor(w http.ResponseWriter, r *http.Request, filter models.StockFilter, orderBy, sortOrder string, limit int) {
+	if r.URL.Query().Get("page") != "" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Los parámetros cursor y page no pueden combinarse")
+		return
+	}
 
-		stocks, err = h.repo.GetStocksByRating(r.Context(), rating, pagination.Offset, pagination.Limit)
-		if err != nil {
-			http.Error(w, "Error al obtener stocks por rating: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	cursor, err := decodeCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
 
-		totalStocks, err = h.repo.CountStocksByRating(r.Context(), rating)
-		if err != nil {
-			http.Error(w, "Error al contar stocks por rating: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else {
-		// Sin filtros, obtener todos los stocks
-		stocks, err = h.repo.GetStocks(r.Context(), orderBy, sortOrder, pagination.Offset, pagination.Limit)
-		if err != nil {
-			http.Error(w, "Error al obtener stocks: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	// El cursor solo es válido para el ordenamiento con el que se generó
+	query := r.URL.Query()
+	if (query.Get("order_by") != "" && orderBy != cursor.OrderBy) || (query.Get("sort") != "" && sortOrder != cursor.SortOrder) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "El cursor no corresponde al ordenamiento solicitado")
+		return
+	}
+	orderBy, sortOrder = cursor.OrderBy, cursor.SortOrder
 
-		totalStocks, err = h.repo.CountStocks(r.Context())
-		if err != nil {
-			http.Error(w, "Error al contar stocks: "+err.Error(), http.StatusInternalServerError)
-			return
+	// Se pide un elemento extra para saber si hay más resultados en esa dirección
+	stocks, err := h.repo.GetStocksByCursor(r.Context(), filter, cursor, limit+1)
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener stocks")
+		return
+	}
+
+	hasMore := len(stocks) > limit
+	if hasMore {
+		if cursor.Backward {
+			stocks = stocks[1:]
+		} else {
+			stocks = stocks[:limit]
 		}
 	}
 
-	totalPages := (totalStocks + pagination.Limit - 1) / pagination.Limit
+	totalStocks, err := h.repo.CountStocks(r.Context(), filter)
+	if err != nil {
+		RespondError(w, r, err, "Error al contar stocks")
+		return
+	}
+
+	// Al avanzar siempre hay página anterior y al retroceder siempre hay siguiente
+	var nextCursor, prevCursor string
+	if len(stocks) > 0 {
+		if !cursor.Backward || hasMore {
+			prevCursor = encodeCursor(cursorAt(stocks[0], orderBy, sortOrder, true))
+		}
+		if cursor.Backward || hasMore {
+			nextCursor = encodeCursor(cursorAt(stocks[len(stocks)-1], orderBy, sortOrder, false))
+		}
+	}
 
 	response := map[string]interface{}{
 		"stocks":         stocks,
 		"total_stocks":   totalStocks,
-		"total_pages":    totalPages,
-		"current_page":   pagination.Page,
-		"items_per_page": pagination.Limit,
+		"items_per_page": limit,
+		"next_cursor":    nextCursor,
+		"prev_cursor":    prevCursor,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Error al codificar respuesta: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
+	setLinkHeader(w, r, nextCursor, prevCursor)
+	sendJSONResponse(w, response, http.StatusOK)
 }
 
-// Maneja la solicitud para obtener los detalles de un stock específico por ticker
+// Maneja la solicitud para obtener los detalles de un stock específico por ticker.
+// Con as_of retorna el estado del stock en esa fecha, según los eventos publicados e
+// ingeridos hasta entonces
 func (h *StockHandler) GetStockDetails(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 	ticker := vars["ticker"]
 
 	if ticker == "" {
-		http.Error(w, "Se requiere especificar un ticker", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Se requiere especificar un ticker")
+		return
+	}
+
+	asOf, err := parseOptionalTime(r, "as_of", true)
+	if err != nil {
+		badRequest(w, r, err)
 		return
 	}
 
 	// Obtener stock por ticker exacto
-	stock, err := h.repo.GetStockByTicker(r.Context(), ticker)
+	var stock models.Stock
+	if asOf != nil {
+		stock, err = h.repo.GetStockByTickerAsOf(r.Context(), ticker, *asOf)
+	} else {
+		stock, err = h.repo.GetStockByTicker(r.Context(), ticker)
+	}
 	if err != nil {
-		http.Error(w, "Stock no encontrado: "+err.Error(), http.StatusNotFound)
+		RespondError(w, r, err, "Error al obtener el stock")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(stock); err != nil {
-		http.Error(w, "Error al codificar respuesta: "+err.Error(), http.StatusInternalServerError)
+	// Solo ETag: la interpretación de precios y la reclasificación de ratings pueden
+	// cambiar el stock sin cambiar la fecha de su último evento
+	sendCacheableJSON(w, r, stock, time.Time{})
+}
+
+// Maneja la solicitud para obtener el historial de cambios de rating de un ticker
+func (h *StockHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	ticker := vars["ticker"]
+
+	if ticker == "" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Se requiere especificar un ticker")
+		return
+	}
+
+	events, err := h.repo.GetStockHistory(r.Context(), ticker)
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener historial")
 		return
 	}
+
+	if len(events) == 0 {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Stock no encontrado: "+ticker)
+		return
+	}
+
+	response := map[string]interface{}{
+		"ticker": ticker,
+		"events": events,
+		"count":  len(events),
+	}
+
+	sendJSONResponse(w, response, http.StatusOK)
+}
+
+// GetStockConsensus maneja la solicitud del consenso de las casas de bolsa sobre
+// un ticker en los days días (30 por defecto) previos a as_of o a la hora actual
+func (h *StockHandler) GetStockConsensus(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+
+	if ticker == "" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Se requiere especificar un ticker")
+		return
+	}
+
+	days := 30
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		d, err := strconv.Atoi(raw)
+		if err != nil || d < 1 || d > 365 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("parámetro days inválido: %q (debe estar entre 1 y 365)", raw))
+			return
+		}
+		days = d
+	}
+
+	asOf, err := parseOptionalTime(r, "as_of", true)
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	// Una fecha futura se limita a la hora actual. Una fecha pasada solo considera los
+	// eventos ya ingeridos en esa fecha
+	to := h.clock.Now().UTC()
+	past := asOf != nil && asOf.Before(to)
+	if past {
+		to = asOf.UTC()
+	}
+	from := to.AddDate(0, 0, -days)
+
+	var events []models.Stock
+	if past {
+		events, err = h.repo.GetStockHistoryAsOf(r.Context(), ticker, from, to)
+	} else {
+		events, err = h.repo.GetStockHistoryByDateRange(r.Context(), ticker, from, to)
+	}
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener historial")
+		return
+	}
+
+	// Sin eventos en la ventana el consenso queda vacío, salvo que el ticker no tenga
+	// ningún evento hasta esa fecha
+	if len(events) == 0 {
+		if past {
+			_, err = h.repo.GetStockByTickerAsOf(r.Context(), ticker, to)
+		} else {
+			_, err = h.repo.GetStockByTicker(r.Context(), ticker)
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				writeError(w, r, http.StatusNotFound, CodeNotFound, "Stock no encontrado: "+ticker)
+				return
+			}
+			RespondError(w, r, err, "Error al obtener stock")
+			return
+		}
+	}
+
+	sendJSONResponse(w, consensus.Build(ticker, events, from, to), http.StatusOK)
+}
+
+// ListUnmappedRatings lista las calificaciones de los datos que no corresponden a
+// ninguna categoría de la taxonomía, con la cantidad de eventos de cada una
+func (h *StockHandler) ListUnmappedRatings(w http.ResponseWriter, r *http.Request) {
+	unmapped, err := h.repo.GetUnmappedRatings(r.Context())
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener ratings sin categoría")
+		return
+	}
+
+	response := map[string]interface{}{
+		"ratings": unmapped,
+		"count":   len(unmapped),
+	}
+
+	sendJSONResponse(w, response, http.StatusOK)
 }
 
 // parsePagination extrae y valida los parámetros de paginación de la solicitud
-func parsePagination(r *http.Request) Pagination {
+func parsePagination(r *http.Request) (Pagination, error) {
 	page := 1
 	pageSize := 10
 
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p < 1 {
+			return Pagination{}, fmt.Errorf("parámetro page inválido: %q", pageStr)
 		}
+		page = p
 	}
 
 	if sizeStr := r.URL.Query().Get("page_size"); sizeStr != "" {
-		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 {
-			if s > 100 {
-				s = 100
-			}
-			pageSize = s
+		s, err := strconv.Atoi(sizeStr)
+		if err != nil || s < 1 || s > 100 {
+			return Pagination{}, fmt.Errorf("parámetro page_size inválido: %q (debe estar entre 1 y 100)", sizeStr)
 		}
+		pageSize = s
 	}
 
 	// Calcular offset para la consulta a la BD
@@ -193,5 +383,5 @@ func parsePagination(r *http.Request) Pagination {
 		Page:   page,
 		Limit:  pageSize,
 		Offset: offset,
-	}
+	}, nil
 }
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/consensus"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// newTestStockHandler crea un handler sobre un repositorio en memoria con eventos
+// de AAPL, MSFT y TSLA, y un reloj fijo el 2025-03-10
+func newTestStockHandler(t *testing.T) *StockHandler {
+	t.Helper()
+
+	stocks := []models.Stock{
+		{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "Barclays", Action: "target raised by", RatingFrom: "Buy", RatingTo: "Buy", TargetFrom: "$140.00", TargetTo: "$150.00", Time: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "Goldman Sachs", Action: "upgraded by", RatingFrom: "Neutral", RatingTo: "Buy", TargetFrom: "$150.00", TargetTo: "$180.00", Time: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "MSFT", Company: "Microsoft", Brokerage: "JPMorgan", Action: "downgraded by", RatingFrom: "Buy", RatingTo: "Neutral", TargetFrom: "$400.00", TargetTo: "$380.00", Time: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "TSLA", Company: "Tesla", Brokerage: "Citigroup", Action: "target lowered by", RatingFrom: "Sell", RatingTo: "Sell", TargetFrom: "$100.00", TargetTo: "$90.00", Time: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Cada evento se ingiere en la fecha en que se publica
+	repo := memory.NewStockRepository()
+	for _, stock := range stocks {
+		saveIngested(t, repo, stock.Time, stock)
+	}
+
+	handler := NewStockHandler(repo)
+	handler.SetClock(recommendation.FixedClock(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)))
+	return handler
+}
+
+// saveIngested guarda los stocks en repo como ingeridos en la fecha dada
+func saveIngested(t *testing.T, repo *memory.StockRepository, ingested time.Time, stocks ...models.Stock) {
+	t.Helper()
+
+	taxonomy := models.DefaultRatingTaxonomy()
+	for i := range stocks {
+		stocks[i].ParseTargets()
+		stocks[i].ClassifyRatings(taxonomy)
+	}
+
+	repo.SetClock(recommendation.FixedClock(ingested))
+	if _, err := repo.SaveStocks(context.Background(), stocks); err != nil {
+		t.Fatalf("SaveStocks() error: %v", err)
+	}
+}
+
+// serve ejecuta handler con las variables de ruta dadas
+func serve(handler http.HandlerFunc, req *http.Request, vars map[string]string) *httptest.ResponseRecorder {
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+}
+
+func TestListStocks(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	tests := []struct {
+		query   string
+		tickers []string
+		total   int
+	}{
+		{"", []string{"TSLA", "AAPL", "MSFT"}, 3},
+		{"order_by=ticker&sort=ASC", []string{"AAPL", "MSFT", "TSLA"}, 3},
+		{"ticker=aap", []string{"AAPL"}, 1},
+		{"brokerage=JPMorgan&brokerage=Citigroup", []string{"TSLA", "MSFT"}, 2},
+		{"rating_to=Buy", []string{"AAPL"}, 1},
+		{"rating=buy", []string{"AAPL", "MSFT"}, 2},
+		{"rating=sell", []string{"TSLA"}, 1},
+		{"min_target_change=10", []string{"AAPL"}, 1},
+		{"from=2025-03-01&to=2025-03-04", []string{"AAPL"}, 1},
+		{"page=2&page_size=2", []string{"MSFT"}, 3},
+		{"ticker=NONE", []string{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := serve(handler.ListStocks, httptest.NewRequest(http.MethodGet, "/api/v1/stocks?"+tt.query, nil), nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			var response struct {
+				Stocks      []models.Stock `json:"stocks"`
+				TotalStocks int            `json:"total_stocks"`
+			}
+			decodeBody(t, rec, &response)
+
+			tickers := []string{}
+			for _, stock := range response.Stocks {
+				tickers = append(tickers, stock.Ticker)
+			}
+			if !reflect.DeepEqual(tickers, tt.tickers) || response.TotalStocks != tt.total {
+				t.Errorf("stocks = %v (total %d), want %v (total %d)", tickers, response.TotalStocks, tt.tickers, tt.total)
+			}
+		})
+	}
+}
+
+func TestListStocksInvalidParameters(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	tests := []string{
+		"order_by=price",
+		"sort=sideways",
+		"page=0",
+		"page_size=101",
+		"from=yesterday",
+		"from=2025-03-02&to=2025-03-01",
+		"min_target=cheap",
+		"cursor=not-a-cursor",
+	}
+
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			rec := serve(handler.ListStocks, httptest.NewRequest(http.MethodGet, "/api/v1/stocks?"+query, nil), nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", rec.Code)
+			}
+
+			var response ErrorResponse
+			decodeBody(t, rec, &response)
+			if response.Error.Code != CodeInvalidParameter {
+				t.Errorf("code = %q, want %q", response.Error.Code, CodeInvalidParameter)
+			}
+		})
+	}
+}
+
+func TestListStocksCursorPagination(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	var tickers []string
+	query := "page_size=2&order_by=ticker&sort=ASC"
+	for page := 0; page < 5; page++ {
+		rec := serve(handler.ListStocks, httptest.NewRequest(http.MethodGet, "/api/v1/stocks?"+query, nil), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+
+		var response struct {
+			Stocks     []models.Stock `json:"stocks"`
+			NextCursor string         `json:"next_cursor"`
+		}
+		decodeBody(t, rec, &response)
+		for _, stock := range response.Stocks {
+			tickers = append(tickers, stock.Ticker)
+		}
+
+		if response.NextCursor == "" {
+			break
+		}
+		query = "page_size=2&cursor=" + response.NextCursor
+	}
+
+	if want := []string{"AAPL", "MSFT", "TSLA"}; !reflect.DeepEqual(tickers, want) {
+		t.Errorf("pages = %v, want %v", tickers, want)
+	}
+}
+
+func TestGetStockDetails(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	tests := []struct {
+		name      string
+		ticker    string
+		query     string
+		status    int
+		brokerage string
+	}{
+		{"latest event", "AAPL", "", http.StatusOK, "Goldman Sachs"},
+		{"as of a past date", "AAPL", "as_of=2025-02-01", http.StatusOK, "Barclays"},
+		{"future as_of", "AAPL", "as_of=2026-01-01", http.StatusOK, "Goldman Sachs"},
+		{"no events until as_of", "AAPL", "as_of=2024-12-31", http.StatusNotFound, ""},
+		{"unknown ticker", "NONE", "", http.StatusNotFound, ""},
+		{"invalid as_of", "AAPL", "as_of=tomorrow", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stocks/"+tt.ticker+"?"+tt.query, nil)
+			rec := serve(handler.GetStockDetails, req, map[string]string{"ticker": tt.ticker})
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var stock models.Stock
+			decodeBody(t, rec, &stock)
+			if stock.Ticker != tt.ticker || stock.Brokerage != tt.brokerage {
+				t.Errorf("stock = %s by %s, want %s by %s", stock.Ticker, stock.Brokerage, tt.ticker, tt.brokerage)
+			}
+		})
+	}
+}
+
+func TestStockAsOfIgnoresLateIngestion(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	// Publicado antes de as_of pero ingerido después: no forma parte del estado en as_of
+	late := models.Stock{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "JPMorgan", RatingFrom: "Buy", RatingTo: "Sell", TargetFrom: "$150.00", TargetTo: "$120.00", Time: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)}
+	saveIngested(t, handler.repo.(*memory.StockRepository), time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), late)
+
+	tests := []struct {
+		asOf      string
+		brokerage string
+		events    int
+	}{
+		{"2025-02-01", "Barclays", 1},
+		{"2025-03-01", "Goldman Sachs", 2},
+		{"2025-03-02", "Goldman Sachs", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asOf, func(t *testing.T) {
+			vars := map[string]string{"ticker": "AAPL"}
+
+			rec := serve(handler.GetStockDetails, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/AAPL?as_of="+tt.asOf, nil), vars)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("details status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var stock models.Stock
+			decodeBody(t, rec, &stock)
+			if stock.Brokerage != tt.brokerage {
+				t.Errorf("brokerage = %s, want %s", stock.Brokerage, tt.brokerage)
+			}
+
+			rec = serve(handler.GetStockConsensus, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/AAPL/consensus?days=90&as_of="+tt.asOf, nil), vars)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("consensus status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var result consensus.Consensus
+			decodeBody(t, rec, &result)
+			if result.Events != tt.events {
+				t.Errorf("consensus events = %d, want %d", result.Events, tt.events)
+			}
+		})
+	}
+}
+
+func TestGetStockDetailsConditional(t *testing.T) {
+	handler := newTestStockHandler(t)
+	vars := map[string]string{"ticker": "AAPL"}
+
+	rec := serve(handler.GetStockDetails, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/AAPL", nil), vars)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q; want 200 with an ETag", rec.Code, etag)
+	}
+	if got := rec.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Last-Modified = %q, want none", got)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"matching etag", "If-None-Match", etag, http.StatusNotModified},
+		{"weak matching etag", "If-None-Match", "W/" + etag, http.StatusNotModified},
+		{"other etag", "If-None-Match", `"other"`, http.StatusOK},
+		{"if-modified-since is ignored", "If-Modified-Since", time.Now().UTC().Format(http.TimeFormat), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stocks/AAPL", nil)
+			req.Header.Set(tt.header, tt.value)
+			rec := serve(handler.GetStockDetails, req, vars)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 response has a body: %q", rec.Body)
+			}
+		})
+	}
+}
+
+func TestGetStockConsensus(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	tests := []struct {
+		name       string
+		ticker     string
+		query      string
+		status     int
+		events     int
+		brokerages int
+		to         time.Time
+	}{
+		{"default window", "AAPL", "", http.StatusOK, 1, 1, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{"longer window", "AAPL", "days=90", http.StatusOK, 2, 2, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{"as of a past date", "AAPL", "as_of=2025-02-01", http.StatusOK, 1, 1, time.Date(2025, 2, 1, 23, 59, 59, 999999000, time.UTC)},
+		{"future as_of is capped", "AAPL", "as_of=2026-01-01", http.StatusOK, 1, 1, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{"no events in the window", "MSFT", "days=7", http.StatusOK, 0, 0, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{"no events until as_of", "AAPL", "as_of=2024-12-31", http.StatusNotFound, 0, 0, time.Time{}},
+		{"unknown ticker", "NONE", "", http.StatusNotFound, 0, 0, time.Time{}},
+		{"invalid days", "AAPL", "days=0", http.StatusBadRequest, 0, 0, time.Time{}},
+		{"invalid as_of", "AAPL", "as_of=tomorrow", http.StatusBadRequest, 0, 0, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stocks/"+tt.ticker+"/consensus?"+tt.query, nil)
+			rec := serve(handler.GetStockConsensus, req, map[string]string{"ticker": tt.ticker})
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var result consensus.Consensus
+			decodeBody(t, rec, &result)
+			if result.Events != tt.events || result.Brokerages != tt.brokerages {
+				t.Errorf("events = %d, brokerages = %d; want %d, %d", result.Events, result.Brokerages, tt.events, tt.brokerages)
+			}
+			if !result.To.Equal(tt.to) {
+				t.Errorf("to = %v, want %v", result.To, tt.to)
+			}
+		})
+	}
+}
+
+func TestExportStocks(t *testing.T) {
+	handler := newTestStockHandler(t)
+
+	tests := []struct {
+		query  string
+		status int
+		lines  []string
+	}{
+		{"ticker=AAPL,MSFT&order_by=ticker&sort=ASC", http.StatusOK, []string{strings.Join(stockExportHeader, ","), "AAPL,", "MSFT,"}},
+		{"ticker=NONE", http.StatusOK, []string{strings.Join(stockExportHeader, ",")}},
+		{"format=xml", http.StatusBadRequest, nil},
+		{"order_by=price", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := serve(handler.ExportStocks, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/export?"+tt.query, nil), nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+			if len(lines) != len(tt.lines) {
+				t.Fatalf("lines = %q, want %d lines", lines, len(tt.lines))
+			}
+			for i, prefix := range tt.lines {
+				if !strings.HasPrefix(lines[i], prefix) {
+					t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestListUnmappedRatings(t *testing.T) {
+	stocks := []models.Stock{
+		{Ticker: "AAA", Brokerage: "Barclays", RatingFrom: "Speculative", RatingTo: "Buy", Time: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "BBB", Brokerage: "JPMorgan", RatingFrom: "Hold", RatingTo: "Speculative", Time: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "CCC", Brokerage: "Barclays", RatingFrom: "Perform", RatingTo: "Hold", Time: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		// Perform solo tiene categoría en la escala de Oppenheimer
+		{Ticker: "DDD", Brokerage: "Oppenheimer", RatingFrom: "Perform", RatingTo: "Outperform", Time: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+	taxonomy := models.DefaultRatingTaxonomy()
+	for i := range stocks {
+		stocks[i].ClassifyRatings(taxonomy)
+	}
+
+	repo := memory.NewStockRepository()
+	if _, err := repo.SaveStocks(context.Background(), stocks); err != nil {
+		t.Fatalf("SaveStocks() error: %v", err)
+	}
+
+	rec := serve(NewStockHandler(repo).ListUnmappedRatings, httptest.NewRequest(http.MethodGet, "/api/v1/ratings/unmapped", nil), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var response struct {
+		Ratings []models.UnmappedRating `json:"ratings"`
+		Count   int                     `json:"count"`
+	}
+	decodeBody(t, rec, &response)
+
+	want := []models.UnmappedRating{
+		{Rating: "Speculative", Count: 2, Brokerages: []string{"Barclays", "JPMorgan"}},
+		{Rating: "Perform", Count: 1, Brokerages: []string{"Barclays"}},
+	}
+	if response.Count != len(want) || !reflect.DeepEqual(response.Ratings, want) {
+		t.Errorf("unmapped ratings = %+v (count %d), want %+v", response.Ratings, response.Count, want)
+	}
+}
@@ -1,89 +1,132 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"os"
-	"time"
+	"strconv"
 
-	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cockroachdb"
-	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/stockapi"
+	"github.com/gorilla/mux"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
 )
 
 // SyncHandler maneja las solicitudes para sincronizar datos
 type SyncHandler struct {
-	repo   *cockroachdb.StockRepository
-	client *stockapi.Client
+	service *services.SyncService
 }
 
 // NewSyncHandler crea una nueva instancia de SyncHandler
-func NewSyncHandler(repo *cockroachdb.StockRepository, client *stockapi.Client) *SyncHandler {
+func NewSyncHandler(service *services.SyncService) *SyncHandler {
 	return &SyncHandler{
-		repo:   repo,
-		client: client,
+		service: service,
 	}
 }
 
 type SyncResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
+	JobID   string `json:"job_id,omitempty"`
 }
 
 // Maneja la solicitud para sincronizar stocks desde la API externa
 func (h *SyncHandler) SyncStocks(w http.ResponseWriter, r *http.Request) {
 	apiToken := os.Getenv("STOCK_API_AUTH_TOKEN")
 	if apiToken == "" {
-		response := SyncResponse{
-			Status:  "error",
-			Message: "Error de configuración: No se encontró el token de autenticación para la API",
-		}
-		sendJSONResponse(w, response, http.StatusInternalServerError)
+		log.Printf("[%s] Sincronización rechazada: STOCK_API_AUTH_TOKEN no configurado", RequestIDFromContext(r.Context()))
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Error de configuración del servidor")
+		return
+	}
+
+	// Por defecto la sincronización es incremental, mode=full recorre todo el dataset
+	mode := models.SyncModeIncremental
+	switch modeParam := r.URL.Query().Get("mode"); modeParam {
+	case "", string(models.SyncModeIncremental):
+	case string(models.SyncModeFull):
+		mode = models.SyncModeFull
+	default:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Modo de sincronización inválido: "+modeParam+" (use incremental o full)")
 		return
 	}
 
-	// Responder inmediatamente
+	job, err := h.service.StartSync(r.Context(), mode)
+	if errors.Is(err, services.ErrSyncInProgress) {
+		// El cliente puede seguir el trabajo en curso con el job_id de los detalles
+		writeErrorDetails(w, r, http.StatusConflict, CodeConflict, "Ya hay una sincronización en curso",
+			map[string]string{"job_id": job.ID})
+		return
+	}
+	if err != nil {
+		RespondError(w, r, err, "No se pudo iniciar la sincronización")
+		return
+	}
+
+	// Responder inmediatamente, la sincronización continúa en segundo plano
+	w.Header().Set("Location", "/api/v1/sync/"+job.ID)
 	response := SyncResponse{
 		Status:  "accepted",
 		Message: "Sincronización iniciada, esto puede tomar varios minutos",
+		JobID:   job.ID,
 	}
 	sendJSONResponse(w, response, http.StatusAccepted)
+}
 
-	// Ejecutar la sincronización en una goroutine separada
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-		defer cancel()
+// Maneja la solicitud para consultar el estado de un trabajo de sincronización
+func (h *SyncHandler) GetSyncJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
 
-		stocks, err := h.client.FetchAllStocks()
-		if err != nil {
-			log.Printf("Error al obtener stocks de la API: %v", err)
-			return
-		}
+	job, err := h.service.GetJob(r.Context(), id)
+	if err != nil {
+		RespondError(w, r, err, "Error al obtener el trabajo de sincronización")
+		return
+	}
 
-		if len(stocks) == 0 {
-			log.Printf("No se encontraron stocks para sincronizar")
-			return
-		}
+	sendJSONResponse(w, job, http.StatusOK)
+}
 
-		if err := h.repo.SaveStocks(ctx, stocks); err != nil {
-			log.Printf("Error al guardar stocks en la base de datos: %v", err)
-			return
+// Maneja la solicitud para listar los trabajos de sincronización recientes
+func (h *SyncHandler) ListSyncJobs(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			if l > 100 {
+				l = 100
+			}
+			limit = l
 		}
+	}
+
+	jobs, err := h.service.ListJobs(r.Context(), limit)
+	if err != nil {
+		RespondError(w, r, err, "Error al listar los trabajos de sincronización")
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.SyncJob{}
+	}
 
-		log.Printf("Sincronización completada: %d stocks guardados", len(stocks))
-	}()
+	response := map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	}
+	sendJSONResponse(w, response, http.StatusOK)
 }
 
-// Envía una respuesta JSON con el código de estado dado
+// Envía una respuesta JSON con el código de estado dado. La respuesta se codifica
+// antes de escribir el encabezado para poder responder un 500 si falla
 func sendJSONResponse(w http.ResponseWriter, data interface{}, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	body, err := json.Marshal(data)
+	if err != nil {
 		log.Printf("Error al codificar respuesta JSON: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"status":"error","message":"Error interno al generar respuesta"}`)
+		statusCode = http.StatusInternalServerError
+		body = []byte(`{"error":{"code":"` + CodeInternal + `","message":"Error interno al generar respuesta"}}`)
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(append(body, '\n'))
 }
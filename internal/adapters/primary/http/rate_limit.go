@@ -0,0 +1,227 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/primary/http/handlers"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+)
+
+// RateLimit configura un token bucket: Rate solicitudes por segundo en promedio,
+// con ráfagas de hasta Burst. Rate 0 desactiva el límite
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits define el límite por defecto y los límites de grupos de rutas,
+// identificados por el prefijo de su plantilla, por ejemplo "/api/v1/stocks".
+// PerIP limita por IP todas las solicitudes antes de validar la API key, de modo
+// que las keys ausentes o inválidas también quedan limitadas
+type RateLimits struct {
+	Default RateLimit
+	Routes  map[string]RateLimit
+	PerIP   RateLimit
+}
+
+// bucketSweepInterval es cada cuánto se descartan los buckets de clientes inactivos
+const bucketSweepInterval = time.Minute
+
+// tokenBucket guarda los tokens disponibles de un cliente en un grupo de rutas
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Duration
+}
+
+// limitResult es el resultado de consumir un token
+type limitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+// rateLimiter mantiene en memoria un token bucket por cliente y grupo de rutas
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    RateLimits
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits:    limits,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// group retorna el grupo de una plantilla de ruta y su límite: el prefijo
+// configurado más largo que la contiene, o el límite por defecto
+func (l *rateLimiter) group(template string) (string, RateLimit) {
+	name, limit := "", l.limits.Default
+	for prefix, routeLimit := range l.limits.Routes {
+		if strings.HasPrefix(template, prefix) && len(prefix) > len(name) {
+			name, limit = prefix, routeLimit
+		}
+	}
+	return name, limit
+}
+
+// allow consume un token del bucket del cliente en el grupo de la ruta
+func (l *rateLimiter) allow(client, template string) limitResult {
+	group, limit := l.group(template)
+	if limit.Rate <= 0 {
+		return limitResult{allowed: true}
+	}
+
+	burst := float64(max(limit.Burst, 1))
+	result := limitResult{limit: int(burst)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key := group + "|" + client
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: burst, last: now, full: secondsToDuration(burst / limit.Rate)}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*limit.Rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = secondsToDuration((1 - bucket.tokens) / limit.Rate)
+	}
+
+	result.remaining = int(bucket.tokens)
+	result.reset = secondsToDuration((burst - bucket.tokens) / limit.Rate)
+
+	return result
+}
+
+// sweep descarta los buckets que ya se llenaron, equivalentes a uno nuevo.
+// Se llama con el mutex tomado
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= bucket.full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Limita las solicitudes por cliente, identificado por su API key o, sin
+// autenticación, por su IP según proxies, con un límite por grupo de rutas. Con
+// keys no nil también descuenta la cuota diaria de la API key
+func rateLimitMiddleware(limiter *rateLimiter, keys *services.APIKeyService, proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			template := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if t, err := route.GetPathTemplate(); err == nil {
+					template = t
+				}
+			}
+
+			key, authenticated := handlers.APIKeyFromContext(r.Context())
+			client := "ip:" + proxies.clientIP(r)
+			if authenticated {
+				client = "key:" + key.ID
+			}
+
+			if !applyLimit(w, r, limiter.allow(client, template)) {
+				return
+			}
+
+			if authenticated && keys != nil {
+				quota, err := keys.ConsumeQuota(r.Context(), key)
+				if quota.Limit > 0 {
+					w.Header().Set("X-Quota-Limit", strconv.Itoa(quota.Limit))
+					w.Header().Set("X-Quota-Remaining", strconv.Itoa(quota.Remaining()))
+					w.Header().Set("X-Quota-Reset", strconv.FormatInt(quota.ResetAt.Unix(), 10))
+				}
+
+				switch {
+				case errors.Is(err, services.ErrQuotaExceeded):
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(time.Until(quota.ResetAt))))
+					handlers.TooManyRequests(w, r, handlers.CodeQuotaExceeded, "Cuota diaria agotada, se renueva a las 00:00 UTC")
+					return
+				case err != nil:
+					// Una falla al contar el uso no debe dejar la API sin servicio
+					log.Printf("[%s] Error al descontar la cuota de la API key %s: %v", handlers.RequestIDFromContext(r.Context()), key.ID, err)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Limita las solicitudes por IP antes de la autenticación, con un único bucket por
+// cliente para todas las rutas. Así los intentos con keys inválidas no pueden
+// adivinar keys ni consultar la base de datos sin límite
+func ipRateLimitMiddleware(limiter *rateLimiter, proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !applyLimit(w, r, limiter.allow("ip:"+proxies.clientIP(r), "")) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// applyLimit escribe los headers del límite y responde 429 si la solicitud no
+// tiene tokens. Retorna false si la solicitud fue rechazada
+func applyLimit(w http.ResponseWriter, r *http.Request, result limitResult) bool {
+	if result.limit > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+	}
+	if !result.allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+		handlers.TooManyRequests(w, r, handlers.CodeRateLimited, "Demasiadas solicitudes, intente de nuevo en unos segundos")
+		return false
+	}
+	return true
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ceilSeconds redondea hacia arriba a segundos enteros, como esperan Retry-After y X-RateLimit-Reset
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
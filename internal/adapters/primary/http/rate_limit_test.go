@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/services"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// newTestLimiter crea un limitador cuyo reloj avanza con el puntero retornado
+func newTestLimiter(limits RateLimits) (*rateLimiter, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(limits)
+	limiter.now = func() time.Time { return now }
+	limiter.lastSweep = now
+	return limiter, &now
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	type step struct {
+		wait       time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		limit RateLimit
+		steps []step
+	}{
+		{
+			name:  "burst then reject",
+			limit: RateLimit{Rate: 1, Burst: 3},
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+			},
+		},
+		{
+			name:  "refills at the rate",
+			limit: RateLimit{Rate: 2, Burst: 2},
+			steps: []step{
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+				{250 * time.Millisecond, true, 0, 0},
+			},
+		},
+		{
+			name:  "refill never exceeds the burst",
+			limit: RateLimit{Rate: 10, Burst: 2},
+			steps: []step{
+				{0, true, 1, 0},
+				{time.Hour, true, 1, 0},
+			},
+		},
+		{
+			name:  "burst below one allows one request",
+			limit: RateLimit{Rate: 1},
+			steps: []step{
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, now := newTestLimiter(RateLimits{Default: tt.limit})
+			for i, s := range tt.steps {
+				*now = now.Add(s.wait)
+				result := limiter.allow("client", "/api/v1/stocks")
+				if result.allowed != s.allowed || result.remaining != s.remaining || result.retryAfter != s.retryAfter {
+					t.Errorf("step %d: allow() = %+v, want allowed=%t remaining=%d retryAfter=%v", i, result, s.allowed, s.remaining, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterReset(t *testing.T) {
+	limiter, _ := newTestLimiter(RateLimits{Default: RateLimit{Rate: 2, Burst: 4}})
+	limiter.allow("client", "")
+	result := limiter.allow("client", "")
+
+	if result.limit != 4 || result.reset != time.Second {
+		t.Errorf("allow() = %+v, want limit 4 and reset 1s", result)
+	}
+}
+
+func TestRateLimiterGroups(t *testing.T) {
+	limits := RateLimits{
+		Default: RateLimit{Rate: 1, Burst: 1},
+		Routes: map[string]RateLimit{
+			"/api/v1/stocks":        {Rate: 1, Burst: 2},
+			"/api/v1/stocks/export": {Rate: 1, Burst: 3},
+			"/api/v1/health":        {},
+		},
+	}
+
+	tests := []struct {
+		template string
+		group    string
+		burst    int
+	}{
+		{"/api/v1/sync", "", 1},
+		{"/api/v1/stocks", "/api/v1/stocks", 2},
+		{"/api/v1/stocks/{ticker}", "/api/v1/stocks", 2},
+		{"/api/v1/stocks/export", "/api/v1/stocks/export", 3},
+		{"/api/v1/health", "/api/v1/health", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			limiter, _ := newTestLimiter(limits)
+			if group, limit := limiter.group(tt.template); group != tt.group || limit.Burst != tt.burst {
+				t.Errorf("group(%q) = %q, %+v; want %q with burst %d", tt.template, group, limit, tt.group, tt.burst)
+			}
+		})
+	}
+}
+
+func TestRateLimiterSeparatesClientsAndGroups(t *testing.T) {
+	limiter, _ := newTestLimiter(RateLimits{
+		Default: RateLimit{Rate: 1, Burst: 1},
+		Routes:  map[string]RateLimit{"/api/v1/stocks": {Rate: 1, Burst: 1}},
+	})
+
+	if !limiter.allow("a", "/api/v1/stocks").allowed {
+		t.Fatal("first request of a was rejected")
+	}
+	if limiter.allow("a", "/api/v1/stocks/{ticker}").allowed {
+		t.Error("routes of the same group should share the bucket")
+	}
+	if !limiter.allow("a", "/api/v1/sync").allowed {
+		t.Error("another group should have its own bucket")
+	}
+	if !limiter.allow("b", "/api/v1/stocks").allowed {
+		t.Error("another client should have its own bucket")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter, _ := newTestLimiter(RateLimits{Default: RateLimit{Rate: 0, Burst: 1}})
+
+	for i := 0; i < 10; i++ {
+		if result := limiter.allow("client", ""); !result.allowed || result.limit != 0 {
+			t.Fatalf("allow() = %+v, want allowed without limit", result)
+		}
+	}
+	if len(limiter.buckets) != 0 {
+		t.Errorf("buckets = %d, want none for a disabled limit", len(limiter.buckets))
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	limiter, now := newTestLimiter(RateLimits{Default: RateLimit{Rate: 1, Burst: 10}})
+	limiter.allow("idle", "")
+
+	*now = now.Add(bucketSweepInterval - 5*time.Second)
+	limiter.allow("busy", "")
+
+	// El barrido descarta el bucket que ya se llenó y conserva el que aún se está llenando
+	*now = now.Add(5 * time.Second)
+	limiter.allow("other", "")
+
+	if _, exists := limiter.buckets["|idle"]; exists {
+		t.Error("a full bucket was not swept")
+	}
+	if _, exists := limiter.buckets["|busy"]; !exists {
+		t.Error("a bucket that is still refilling was swept")
+	}
+}
+
+func TestIPRateLimitRunsBeforeAuth(t *testing.T) {
+	keys := services.NewAPIKeyService(memory.NewAPIKeyRepository())
+	if err := keys.EnsureKey(context.Background(), "test", "valid-test-key-0123456789abcdefghijk", models.RoleReader); err != nil {
+		t.Fatalf("EnsureKey() error: %v", err)
+	}
+
+	limits := &RateLimits{PerIP: RateLimit{Rate: 0.001, Burst: 2}}
+	router := NewRouter(memory.NewStockRepository(), nil, nil, nil, nil, nil, keys, Options{RateLimits: limits}).SetupRoutes()
+
+	tests := []struct {
+		key    string
+		status int
+	}{
+		{"invalid", http.StatusUnauthorized},
+		{"invalid", http.StatusUnauthorized},
+		{"invalid", http.StatusTooManyRequests},
+		{"valid-test-key-0123456789abcdefghijk", http.StatusTooManyRequests},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stocks", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("request %d: status = %d, want %d", i, rec.Code, tt.status)
+		}
+	}
+}
@@ -1,16 +1,20 @@
 package http
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 
 	"github.com/RobertCastro/stock-insights-api/internal/adapters/primary/http/handlers"
-	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cockroachdb"
 	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/stockapi"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
 	"github.com/RobertCastro/stock-insights-api/internal/application/services"
 )
 
@@ -19,23 +23,46 @@ type Router struct {
 	syncHandler           *handlers.SyncHandler
 	healthHandler         *handlers.HealthHandler
 	recommendationHandler *handlers.RecommendationHandler
+	backtestHandler       *handlers.BacktestHandler
+	apiKeyHandler         *handlers.APIKeyHandler
+
+	// apiKeys valida las API keys de /api/v1; nil desactiva la autenticación
+	apiKeys *services.APIKeyService
+
+	options Options
 }
 
-// NewRouter crea una nueva instancia del router
-func NewRouter(repo *cockroachdb.StockRepository, client *stockapi.Client) *Router {
+// Options agrupa la configuración HTTP del router
+type Options struct {
+	// AllowedOrigins son los orígenes permitidos por CORS
+	AllowedOrigins []string
 
-	recommendationService := services.NewRecommendationService(repo)
+	// RateLimits limita las solicitudes a /api/v1 por cliente; nil desactiva el límite
+	RateLimits *RateLimits
 
+	// TrustedProxies indica de qué proxies se acepta X-Forwarded-For para identificar
+	// la IP del cliente en los límites de tasa
+	TrustedProxies TrustedProxies
+}
+
+// NewRouter crea una nueva instancia del router. Con apiKeyService nil las rutas de
+// /api/v1 no requieren autenticación
+func NewRouter(repo ports.StockRepository, client *stockapi.Client, syncService *services.SyncService, recommendationService *services.RecommendationService, snapshotService *services.SnapshotService, backtestService *services.BacktestService, apiKeyService *services.APIKeyService, options Options) *Router {
 	stockHandler := handlers.NewStockHandler(repo)
-	syncHandler := handlers.NewSyncHandler(repo, client)
+	syncHandler := handlers.NewSyncHandler(syncService)
 	healthHandler := handlers.NewHealthHandler(repo, client)
-	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService, snapshotService)
+	backtestHandler := handlers.NewBacktestHandler(backtestService)
 
 	return &Router{
 		stockHandler:          stockHandler,
 		syncHandler:           syncHandler,
 		healthHandler:         healthHandler,
 		recommendationHandler: recommendationHandler,
+		backtestHandler:       backtestHandler,
+		apiKeyHandler:         handlers.NewAPIKeyHandler(apiKeyService),
+		apiKeys:               apiKeyService,
+		options:               options,
 	}
 }
 
@@ -49,31 +76,104 @@ func (r *Router) SetupRoutes() http.Handler {
 	// Rutas para la API
 	api := router.PathPrefix("/api/v1").Subrouter()
 
+	// Las rutas inexistentes y los métodos no soportados también responden JSON
+	router.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowed)
+
 	// Rutas para stocks
 	api.HandleFunc("/stocks", r.stockHandler.ListStocks).Methods("GET")
+	api.HandleFunc("/stocks/export", r.stockHandler.ExportStocks).Methods("GET")
 	api.HandleFunc("/stocks/{ticker}", r.stockHandler.GetStockDetails).Methods("GET")
+	api.HandleFunc("/stocks/{ticker}/history", r.stockHandler.GetStockHistory).Methods("GET")
+	api.HandleFunc("/stocks/{ticker}/consensus", r.stockHandler.GetStockConsensus).Methods("GET")
 
-	// Ruta para sincronización
+	// Ruta para la taxonomía de ratings
+	api.HandleFunc("/ratings/unmapped", r.stockHandler.ListUnmappedRatings).Methods("GET")
+
+	// Rutas para sincronización
 	api.HandleFunc("/sync", r.syncHandler.SyncStocks).Methods("POST")
+	api.HandleFunc("/sync", r.syncHandler.ListSyncJobs).Methods("GET")
+	api.HandleFunc("/sync/{id}", r.syncHandler.GetSyncJob).Methods("GET")
 
-	// Ruta para recomendaciones
+	// Rutas para recomendaciones
 	api.HandleFunc("/recommendations", r.recommendationHandler.GetRecommendations).Methods("GET")
+	api.HandleFunc("/recommendations/strategies", r.recommendationHandler.ListStrategies).Methods("GET")
+	api.HandleFunc("/recommendations/avoid", r.recommendationHandler.GetAvoidList).Methods("GET")
+	api.HandleFunc("/recommendations/export", r.recommendationHandler.ExportRecommendations).Methods("GET")
+	api.HandleFunc("/recommendations/history", r.recommendationHandler.GetRecommendationHistory).Methods("GET")
+	api.HandleFunc("/recommendations/diff", r.recommendationHandler.GetRecommendationDiff).Methods("GET")
+
+	// Rutas para backtests de estrategias
+	api.HandleFunc("/backtests", r.backtestHandler.RunBacktest).Methods("POST")
+	api.HandleFunc("/backtests", r.backtestHandler.ListBacktests).Methods("GET")
+	api.HandleFunc("/backtests/{id}", r.backtestHandler.GetBacktest).Methods("GET")
+
+	// Rutas de administración de API keys, solo con autenticación activa
+	if r.apiKeys != nil {
+		api.HandleFunc("/admin/keys", r.apiKeyHandler.CreateAPIKey).Methods("POST")
+		api.HandleFunc("/admin/keys", r.apiKeyHandler.ListAPIKeys).Methods("GET")
+		api.HandleFunc("/admin/keys/{id}", r.apiKeyHandler.RevokeAPIKey).Methods("DELETE")
+	}
+
+	// Límite por IP, autenticación y luego límite de tasa, que identifica al cliente
+	// por su API key. El límite por IP va primero para cubrir las keys inválidas
+	if r.apiKeys != nil && r.options.RateLimits != nil {
+		api.Use(ipRateLimitMiddleware(newRateLimiter(RateLimits{Default: r.options.RateLimits.PerIP}), r.options.TrustedProxies))
+	}
+	if r.apiKeys != nil {
+		api.Use(authMiddleware(r.apiKeys))
+	}
+	if r.options.RateLimits != nil {
+		api.Use(rateLimitMiddleware(newRateLimiter(*r.options.RateLimits), r.apiKeys, r.options.TrustedProxies))
+	}
 
-	// Rutas para health checks
+	// Rutas para health checks, públicas
 	router.HandleFunc("/health", r.healthHandler.BasicHealth).Methods("GET")
 	router.HandleFunc("/health/detailed", r.healthHandler.DetailedHealth).Methods("GET")
 
-	// Configurar CORS
+	// Configurar CORS. Las API keys viajan en headers y no en cookies, por lo que no
+	// se permiten credenciales; además, los navegadores las rechazan con el origen "*"
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
+		AllowedOrigins: r.options.AllowedOrigins,
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-CSRF-Token", "X-Request-ID"},
+		ExposedHeaders: []string{
+			"Link", "Location", "X-Request-ID", "Retry-After",
+			"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+			"X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset",
+		},
+		AllowCredentials: false,
 		MaxAge:           300,
 	})
 
-	return c.Handler(router)
+	// El ID de la solicitud se asigna fuera del router para cubrir también sus 404 y 405
+	return c.Handler(requestIDMiddleware(router))
+}
+
+// requestIDPattern limita los IDs aceptados del cliente para no registrar valores arbitrarios
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Asigna a cada solicitud un ID, el del encabezado X-Request-ID si es válido o uno
+// aleatorio, y lo retorna en la respuesta
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(handlers.WithRequestID(r.Context(), id)))
+	})
+}
+
+// Genera un ID aleatorio de 32 caracteres hexadecimales
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
 }
 
 // Registra información sobre las solicitudes HTTP
@@ -86,7 +186,7 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		duration := time.Since(start)
 		path := r.URL.Path
 		method := r.Method
-		log.Printf("%s %s %s", method, path, duration)
+		log.Printf("[%s] %s %s %s", handlers.RequestIDFromContext(r.Context()), method, path, duration)
 		_ = method
 		_ = path
 		_ = duration
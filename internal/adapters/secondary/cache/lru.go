@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+)
+
+var _ ports.Cache = (*LRU)(nil)
+
+// LRU es un cache en memoria con capacidad fija y expiración. Al llenarse
+// descarta el valor usado hace más tiempo
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+
+	// generation se incrementa con cada Purge
+	generation uint64
+}
+
+// lruEntry es un valor del cache con su fecha de expiración
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewLRU crea un cache de hasta capacity valores que expiran después de ttl.
+// Un ttl de 0 no expira los valores
+func NewLRU(capacity int, ttl time.Duration) *LRU {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &LRU{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Obtiene un valor vigente y lo marca como usado recientemente
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.items[key]
+	if !exists {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.entries.MoveToFront(element)
+	return entry.value, true
+}
+
+// Retorna la generación actual del cache
+func (c *LRU) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// Guarda un valor, descartando el usado hace más tiempo si el cache está lleno.
+// Se ignora si el cache se vació después de la generación indicada
+func (c *LRU) Set(key string, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	expiresAt := c.now().Add(c.ttl)
+
+	if element, exists := c.items[key]; exists {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.entries.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.entries.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.entries.Len() > c.capacity {
+		c.remove(c.entries.Back())
+	}
+}
+
+// Descarta todos los valores
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.Init()
+	c.items = make(map[string]*list.Element)
+	c.generation++
+}
+
+// Len retorna la cantidad de valores guardados, incluidos los expirados que aún no se descartaron
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+// remove descarta un valor. Se llama con el mutex tomado
+func (c *LRU) remove(element *list.Element) {
+	c.entries.Remove(element)
+	delete(c.items, element.Value.(*lruEntry).key)
+}
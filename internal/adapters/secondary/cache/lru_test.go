@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLRU crea un cache cuyo reloj avanza con el puntero retornado
+func newTestLRU(capacity int, ttl time.Duration) (*LRU, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(capacity, ttl)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestLRUEviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		touch   func(c *LRU)
+		present []string
+		evicted string
+	}{
+		{"evicts the oldest key", func(c *LRU) {}, []string{"b", "c", "d"}, "a"},
+		{"a read keeps the key", func(c *LRU) { c.Get("a") }, []string{"a", "c", "d"}, "b"},
+		{"a write keeps the key", func(c *LRU) { c.Set("a", "a", c.Generation()) }, []string{"a", "c", "d"}, "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestLRU(3, 0)
+			for _, key := range []string{"a", "b", "c"} {
+				c.Set(key, key, c.Generation())
+			}
+			tt.touch(c)
+			c.Set("d", "d", c.Generation())
+
+			if c.Len() != 3 {
+				t.Errorf("Len() = %d, want 3", c.Len())
+			}
+			if _, ok := c.Get(tt.evicted); ok {
+				t.Errorf("Get(%q) found an evicted key", tt.evicted)
+			}
+			for _, key := range tt.present {
+				if value, ok := c.Get(key); !ok || value != key {
+					t.Errorf("Get(%q) = %v, %t; want %q", key, value, ok, key)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUExpiration(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		elapsed time.Duration
+		found   bool
+	}{
+		{"before the ttl", time.Minute, 59 * time.Second, true},
+		{"at the ttl", time.Minute, time.Minute, false},
+		{"after the ttl", time.Minute, time.Hour, false},
+		{"no ttl", 0, 24 * time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, now := newTestLRU(2, tt.ttl)
+			c.Set("key", "value", c.Generation())
+			*now = now.Add(tt.elapsed)
+
+			if _, ok := c.Get("key"); ok != tt.found {
+				t.Errorf("Get() found = %t, want %t", ok, tt.found)
+			}
+			if !tt.found && c.Len() != 0 {
+				t.Errorf("Len() = %d, want the expired value removed", c.Len())
+			}
+		})
+	}
+}
+
+func TestLRUSetRefreshesExpiration(t *testing.T) {
+	c, now := newTestLRU(2, time.Minute)
+	c.Set("key", "old", c.Generation())
+	*now = now.Add(50 * time.Second)
+	c.Set("key", "new", c.Generation())
+	*now = now.Add(50 * time.Second)
+
+	if value, ok := c.Get("key"); !ok || value != "new" {
+		t.Errorf("Get() = %v, %t; want new", value, ok)
+	}
+}
+
+func TestLRUPurge(t *testing.T) {
+	c, _ := newTestLRU(2, 0)
+	stale := c.Generation()
+	c.Set("a", 1, stale)
+	c.Purge()
+
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after Purge, want 0", c.Len())
+	}
+	if c.Generation() == stale {
+		t.Fatal("Purge did not advance the generation")
+	}
+
+	// Un valor leído antes del Purge no debe volver a guardarse
+	c.Set("b", 2, stale)
+	if _, ok := c.Get("b"); ok {
+		t.Error("Set with a stale generation stored the value")
+	}
+
+	c.Set("b", 2, c.Generation())
+	if value, ok := c.Get("b"); !ok || value != 2 {
+		t.Errorf("Get() = %v, %t; want 2", value, ok)
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+var _ ports.StockRepository = (*StockRepository)(nil)
+
+// stockKeyPrefix separa las claves de stocks de las demás claves del cache compartido
+const stockKeyPrefix = "stock:"
+
+// StockRepository agrega un cache a las consultas de un stock por ticker. Las demás
+// operaciones pasan directo al repositorio; el cache se invalida al terminar una
+// sincronización
+type StockRepository struct {
+	ports.StockRepository
+	cache ports.Cache
+}
+
+// NewStockRepository envuelve repo con el cache dado
+func NewStockRepository(repo ports.StockRepository, cache ports.Cache) *StockRepository {
+	return &StockRepository{
+		StockRepository: repo,
+		cache:           cache,
+	}
+}
+
+// Obtiene un stock por ticker desde el cache o, si no está, desde el repositorio.
+// Solo se guardan los stocks encontrados
+func (r *StockRepository) GetStockByTicker(ctx context.Context, ticker string) (models.Stock, error) {
+	key := stockKeyPrefix + ticker
+	if cached, ok := r.cache.Get(key); ok {
+		return cached.(models.Stock), nil
+	}
+
+	generation := r.cache.Generation()
+	stock, err := r.StockRepository.GetStockByTicker(ctx, ticker)
+	if err != nil {
+		return stock, err
+	}
+
+	r.cache.Set(key, stock, generation)
+	return stock, nil
+}
@@ -0,0 +1,222 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+var _ ports.APIKeyRepository = (*APIKeyRepository)(nil)
+
+// APIKeyRepository persiste las API keys en CockroachDB
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// Crea una nueva instancia del repositorio de API keys
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// Inicializa la tabla de API keys. Solo se guarda el hash de cada clave
+func (r *APIKeyRepository) InitDB(ctx context.Context) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS api_keys (
+        id STRING PRIMARY KEY,
+        name STRING NOT NULL,
+        prefix STRING NOT NULL,
+        key_hash STRING NOT NULL UNIQUE,
+        role STRING NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        last_used_at TIMESTAMP,
+        revoked_at TIMESTAMP,
+        INDEX api_keys_created_at_idx (created_at DESC)
+    )
+    `
+
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	migrations := []string{
+		`ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_quota INT NOT NULL DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS api_key_usage (
+            key_id STRING NOT NULL,
+            day STRING NOT NULL,
+            requests INT NOT NULL DEFAULT 0,
+            PRIMARY KEY (key_id, day)
+        )`,
+	}
+
+	for _, migration := range migrations {
+		if _, err := r.db.ExecContext(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Registra una API key nueva
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	query := `
+        INSERT INTO api_keys (id, name, prefix, key_hash, role, daily_quota, created_at, last_used_at, revoked_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	_, err := r.db.ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		string(key.Role),
+		key.DailyQuota,
+		key.CreatedAt,
+		key.LastUsedAt,
+		key.RevokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating api key %s: %w", key.ID, err)
+	}
+
+	return nil
+}
+
+// Obtiene una API key por el hash de la clave
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	query := `
+    SELECT id, name, prefix, key_hash, role, daily_quota, created_at, last_used_at, revoked_at
+    FROM api_keys
+    WHERE key_hash = $1
+    `
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return key, ports.ErrAPIKeyNotFound
+		}
+		return key, fmt.Errorf("error getting api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// Lista las API keys, las más recientes primero
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+    SELECT id, name, prefix, key_hash, role, daily_quota, created_at, last_used_at, revoked_at
+    FROM api_keys
+    ORDER BY created_at DESC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Marca una API key como revocada, conservando la fecha si ya lo estaba
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id string, at time.Time) (models.APIKey, error) {
+	query := `
+        UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2)
+        WHERE id = $1
+        RETURNING id, name, prefix, key_hash, role, daily_quota, created_at, last_used_at, revoked_at
+    `
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id, at))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return key, fmt.Errorf("%w: %s", ports.ErrAPIKeyNotFound, id)
+		}
+		return key, fmt.Errorf("error revoking api key %s: %w", id, err)
+	}
+
+	return key, nil
+}
+
+// Registra el último uso de una API key
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	res, err := r.db.ExecContext(ctx, query, id, at)
+	if err != nil {
+		return fmt.Errorf("error updating api key %s: %w", id, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ports.ErrAPIKeyNotFound, id)
+	}
+
+	return nil
+}
+
+// Suma una solicitud al uso de una API key en el día dado. El incremento es atómico,
+// de modo que varias réplicas del servicio comparten la cuota
+func (r *APIKeyRepository) IncrementAPIKeyUsage(ctx context.Context, id, day string) (int, error) {
+	query := `
+        INSERT INTO api_key_usage (key_id, day, requests) VALUES ($1, $2, 1)
+        ON CONFLICT (key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+        RETURNING requests
+    `
+
+	var requests int
+	if err := r.db.QueryRowContext(ctx, query, id, day).Scan(&requests); err != nil {
+		return 0, fmt.Errorf("error updating api key usage %s: %w", id, err)
+	}
+
+	return requests, nil
+}
+
+// scanAPIKey lee una API key desde una fila de resultados
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (models.APIKey, error) {
+	var key models.APIKey
+	var role string
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&role,
+		&key.DailyQuota,
+		&key.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return key, err
+	}
+
+	key.Role = models.Role(role)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
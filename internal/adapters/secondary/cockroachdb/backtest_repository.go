@@ -0,0 +1,151 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+var _ ports.BacktestRepository = (*BacktestRepository)(nil)
+
+// BacktestRepository persiste los resultados de los backtests en CockroachDB
+type BacktestRepository struct {
+	db *sql.DB
+}
+
+// Crea una nueva instancia del repositorio de backtests
+func NewBacktestRepository(db *sql.DB) *BacktestRepository {
+	return &BacktestRepository{
+		db: db,
+	}
+}
+
+// Inicializa la tabla de backtests
+func (r *BacktestRepository) InitDB(ctx context.Context) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS backtests (
+        id STRING PRIMARY KEY,
+        params JSONB NOT NULL,
+        prices_source STRING NOT NULL DEFAULT '',
+        result JSONB NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        duration_ms INT NOT NULL DEFAULT 0,
+        INDEX backtests_created_at_idx (created_at DESC)
+    )
+    `
+
+	_, err := r.db.ExecContext(ctx, query)
+	return err
+}
+
+// Guarda un backtest ejecutado
+func (r *BacktestRepository) SaveBacktest(ctx context.Context, report backtest.Report) error {
+	params, err := json.Marshal(report.Params)
+	if err != nil {
+		return fmt.Errorf("error encoding backtest params: %w", err)
+	}
+	result, err := json.Marshal(report.Result)
+	if err != nil {
+		return fmt.Errorf("error encoding backtest result: %w", err)
+	}
+
+	query := `
+        INSERT INTO backtests (id, params, prices_source, result, created_at, duration_ms)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+
+	_, err = r.db.ExecContext(ctx, query,
+		report.ID,
+		params,
+		report.PricesSource,
+		result,
+		report.CreatedAt,
+		report.DurationMs,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving backtest: %w", err)
+	}
+
+	return nil
+}
+
+// Obtiene un backtest por su ID
+func (r *BacktestRepository) GetBacktest(ctx context.Context, id string) (backtest.Report, error) {
+	query := `
+    SELECT id, params, prices_source, result, created_at, duration_ms
+    FROM backtests
+    WHERE id = $1
+    `
+
+	report, err := scanBacktest(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return report, fmt.Errorf("%w: %s", ports.ErrBacktestNotFound, id)
+		}
+		return report, fmt.Errorf("error getting backtest: %w", err)
+	}
+
+	return report, nil
+}
+
+// Lista los backtests más recientes primero
+func (r *BacktestRepository) ListBacktests(ctx context.Context, limit int) ([]backtest.Report, error) {
+	query := `
+    SELECT id, params, prices_source, result, created_at, duration_ms
+    FROM backtests
+    ORDER BY created_at DESC
+    LIMIT $1
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying backtests: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []backtest.Report
+	for rows.Next() {
+		report, err := scanBacktest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning backtest: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backtests: %w", err)
+	}
+
+	return reports, nil
+}
+
+// scanBacktest lee una fila de backtests y decodifica las columnas JSONB
+func scanBacktest(row interface{ Scan(...interface{}) error }) (backtest.Report, error) {
+	var report backtest.Report
+	var params, result []byte
+
+	err := row.Scan(
+		&report.ID,
+		&params,
+		&report.PricesSource,
+		&result,
+		&report.CreatedAt,
+		&report.DurationMs,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	if err := json.Unmarshal(params, &report.Params); err != nil {
+		return report, fmt.Errorf("error decoding backtest params: %w", err)
+	}
+	if err := json.Unmarshal(result, &report.Result); err != nil {
+		return report, fmt.Errorf("error decoding backtest result: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,176 @@
+package cockroachdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// orderColumns asocia los campos de ordenamiento públicos con su expresión SQL
+var orderColumns = map[string]string{
+	"ticker":        "ticker",
+	"company":       "company",
+	"brokerage":     "brokerage",
+	"rating_from":   "rating_from",
+	"rating_to":     "rating_to",
+	"time":          "time",
+	"target_from":   "target_from_value",
+	"target_to":     "target_to_value",
+	"target_change": "target_change_pct",
+}
+
+// queryBuilder acumula condiciones WHERE y sus argumentos posicionales
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg agrega un argumento y retorna su placeholder ($n)
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// where agrega una condición ya construida
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// anyOf agrega "(expr(v1) OR expr(v2) ...)" para una lista de valores
+func (b *queryBuilder) anyOf(values []string, expr func(placeholder string) string) {
+	if len(values) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, expr(b.arg(value)))
+	}
+
+	b.where("(" + strings.Join(parts, " OR ") + ")")
+}
+
+// ratings agrega una condición que acepta cualquiera de las categorías o de los
+// ratings sin categoría, en rating_from o rating_to
+func (b *queryBuilder) ratings(buckets []models.RatingBucket, ratings []string) {
+	var parts []string
+	for _, bucket := range buckets {
+		p := b.arg(string(bucket))
+		parts = append(parts, "rating_from_bucket = "+p+" OR rating_to_bucket = "+p)
+	}
+	for _, rating := range ratings {
+		p := b.arg(rating)
+		parts = append(parts, "lower(rating_from) = lower("+p+") OR lower(rating_to) = lower("+p+")")
+	}
+
+	if len(parts) > 0 {
+		b.where("(" + strings.Join(parts, " OR ") + ")")
+	}
+}
+
+// clause retorna la cláusula WHERE completa, vacía si no hay condiciones
+func (b *queryBuilder) clause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// newStockQuery traduce un StockFilter en condiciones combinadas con AND
+func newStockQuery(filter models.StockFilter) *queryBuilder {
+	b := &queryBuilder{}
+
+	b.anyOf(filter.Tickers, func(p string) string { return "ticker ILIKE '%' || " + p + " || '%'" })
+	b.anyOf(filter.Brokerages, func(p string) string { return "brokerage = " + p })
+	b.ratings(filter.RatingBuckets, filter.Ratings)
+	b.anyOf(filter.RatingsFrom, func(p string) string { return "rating_from = " + p })
+	b.anyOf(filter.RatingsTo, func(p string) string { return "rating_to = " + p })
+	b.anyOf(filter.Actions, func(p string) string { return "action = " + p })
+
+	if filter.Company != "" {
+		b.where("company ILIKE '%' || " + b.arg(filter.Company) + " || '%'")
+	}
+	if filter.From != nil {
+		b.where("time >= " + b.arg(*filter.From))
+	}
+	if filter.To != nil {
+		b.where("time <= " + b.arg(*filter.To))
+	}
+	if filter.MinTarget != nil {
+		b.where("target_to_value >= " + b.arg(*filter.MinTarget))
+	}
+	if filter.MaxTarget != nil {
+		b.where("target_to_value <= " + b.arg(*filter.MaxTarget))
+	}
+	if filter.MinTargetChange != nil {
+		b.where("target_change_pct >= " + b.arg(*filter.MinTargetChange))
+	}
+	if filter.MaxTargetChange != nil {
+		b.where("target_change_pct <= " + b.arg(*filter.MaxTargetChange))
+	}
+
+	return b
+}
+
+// cursorValue convierte el valor serializado del cursor al tipo de la columna
+func cursorValue(orderBy, value string) (interface{}, error) {
+	switch orderBy {
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value for %s: %w", orderBy, err)
+		}
+		return t, nil
+	case "target_from", "target_to", "target_change":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value for %s: %w", orderBy, err)
+		}
+		return f, nil
+	}
+	return value, nil
+}
+
+// keyset agrega la condición que selecciona las filas posteriores al cursor (o
+// anteriores si retrocede) según el orden "(col IS NULL), col DIR, ticker ASC"
+func (b *queryBuilder) keyset(cursor models.StockCursor, column string, desc bool) error {
+	ticker := b.arg(cursor.Ticker)
+
+	if cursor.Null {
+		// Las filas con NULL están al final y solo se ordenan por ticker
+		if cursor.Backward {
+			b.where(fmt.Sprintf("(%s IS NOT NULL OR ticker < %s)", column, ticker))
+		} else {
+			b.where(fmt.Sprintf("(%s IS NULL AND ticker > %s)", column, ticker))
+		}
+		return nil
+	}
+
+	value, err := cursorValue(cursor.OrderBy, cursor.Value)
+	if err != nil {
+		return err
+	}
+	v := b.arg(value)
+
+	// "after" indica si se buscan valores mayores en la columna de orden
+	after := !cursor.Backward != desc
+	cmp, tie := "<", "<"
+	if after {
+		cmp = ">"
+	}
+	if !cursor.Backward {
+		tie = ">"
+	}
+
+	condition := fmt.Sprintf("(%s IS NOT NULL AND (%s %s %s OR (%s = %s AND ticker %s %s)))",
+		column, column, cmp, v, column, v, tie, ticker)
+	if !cursor.Backward {
+		condition = fmt.Sprintf("(%s OR %s IS NULL)", condition, column)
+	}
+	b.where(condition)
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package cockroachdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestNewStockQuery(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	minChange := 10.0
+
+	tests := []struct {
+		name   string
+		filter models.StockFilter
+		clause string
+		args   []interface{}
+	}{
+		{
+			name:   "empty filter",
+			filter: models.StockFilter{},
+			clause: "",
+			args:   nil,
+		},
+		{
+			name:   "values of one field are combined with OR",
+			filter: models.StockFilter{Brokerages: []string{"JPMorgan", "Barclays"}},
+			clause: "WHERE (brokerage = $1 OR brokerage = $2)",
+			args:   []interface{}{"JPMorgan", "Barclays"},
+		},
+		{
+			name: "fields are combined with AND",
+			filter: models.StockFilter{
+				Tickers:         []string{"AA"},
+				Actions:         []string{"upgraded by"},
+				From:            &from,
+				MinTargetChange: &minChange,
+			},
+			clause: "WHERE (ticker ILIKE '%' || $1 || '%') AND (action = $2) AND time >= $3 AND target_change_pct >= $4",
+			args:   []interface{}{"AA", "upgraded by", from, minChange},
+		},
+		{
+			name: "buckets and unmapped ratings share one condition",
+			filter: models.StockFilter{
+				RatingBuckets: []models.RatingBucket{models.RatingBuy},
+				Ratings:       []string{"Top Pick"},
+			},
+			clause: "WHERE (rating_from_bucket = $1 OR rating_to_bucket = $1 OR lower(rating_from) = lower($2) OR lower(rating_to) = lower($2))",
+			args:   []interface{}{"buy", "Top Pick"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newStockQuery(tt.filter)
+			if got := b.clause(); got != tt.clause {
+				t.Errorf("clause() = %q, want %q", got, tt.clause)
+			}
+			if !reflect.DeepEqual(b.args, tt.args) {
+				t.Errorf("args = %v, want %v", b.args, tt.args)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		cursor    models.StockCursor
+		column    string
+		desc      bool
+		condition string
+		args      []interface{}
+	}{
+		{
+			name:      "forward descending",
+			cursor:    models.StockCursor{OrderBy: "time", SortOrder: "DESC", Value: at.Format(time.RFC3339Nano), Ticker: "AAPL"},
+			column:    "time",
+			desc:      true,
+			condition: "((time IS NOT NULL AND (time < $2 OR (time = $2 AND ticker > $1))) OR time IS NULL)",
+			args:      []interface{}{"AAPL", at},
+		},
+		{
+			name:      "forward ascending",
+			cursor:    models.StockCursor{OrderBy: "ticker", SortOrder: "ASC", Value: "AAPL", Ticker: "AAPL"},
+			column:    "ticker",
+			condition: "((ticker IS NOT NULL AND (ticker > $2 OR (ticker = $2 AND ticker > $1))) OR ticker IS NULL)",
+			args:      []interface{}{"AAPL", "AAPL"},
+		},
+		{
+			name:      "backward descending",
+			cursor:    models.StockCursor{OrderBy: "target_change", SortOrder: "DESC", Value: "12.5", Ticker: "MSFT", Backward: true},
+			column:    "target_change_pct",
+			desc:      true,
+			condition: "(target_change_pct IS NOT NULL AND (target_change_pct > $2 OR (target_change_pct = $2 AND ticker < $1)))",
+			args:      []interface{}{"MSFT", 12.5},
+		},
+		{
+			name:      "backward ascending",
+			cursor:    models.StockCursor{OrderBy: "company", SortOrder: "ASC", Value: "Apple", Ticker: "AAPL", Backward: true},
+			column:    "company",
+			condition: "(company IS NOT NULL AND (company < $2 OR (company = $2 AND ticker < $1)))",
+			args:      []interface{}{"AAPL", "Apple"},
+		},
+		{
+			name:      "forward from a null value",
+			cursor:    models.StockCursor{OrderBy: "target_to", SortOrder: "DESC", Null: true, Ticker: "TSLA"},
+			column:    "target_to_value",
+			desc:      true,
+			condition: "(target_to_value IS NULL AND ticker > $1)",
+			args:      []interface{}{"TSLA"},
+		},
+		{
+			name:      "backward from a null value",
+			cursor:    models.StockCursor{OrderBy: "target_to", SortOrder: "DESC", Null: true, Ticker: "TSLA", Backward: true},
+			column:    "target_to_value",
+			desc:      true,
+			condition: "(target_to_value IS NOT NULL OR ticker < $1)",
+			args:      []interface{}{"TSLA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &queryBuilder{}
+			if err := b.keyset(tt.cursor, tt.column, tt.desc); err != nil {
+				t.Fatalf("keyset() error: %v", err)
+			}
+			if got := b.clause(); got != "WHERE "+tt.condition {
+				t.Errorf("clause() = %q, want %q", got, "WHERE "+tt.condition)
+			}
+			if !reflect.DeepEqual(b.args, tt.args) {
+				t.Errorf("args = %v, want %v", b.args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCursorValueInvalid(t *testing.T) {
+	tests := []struct {
+		orderBy string
+		value   string
+	}{
+		{"time", "yesterday"},
+		{"target_from", "n/a"},
+		{"target_change", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.orderBy, func(t *testing.T) {
+			if _, err := cursorValue(tt.orderBy, tt.value); err == nil {
+				t.Errorf("cursorValue(%q, %q) error = nil, want error", tt.orderBy, tt.value)
+			}
+		})
+	}
+}
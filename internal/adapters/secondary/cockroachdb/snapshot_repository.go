@@ -0,0 +1,132 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+var _ ports.SnapshotRepository = (*SnapshotRepository)(nil)
+
+// SnapshotRepository persiste los snapshots de recomendaciones en CockroachDB
+type SnapshotRepository struct {
+	db *sql.DB
+}
+
+// Crea una nueva instancia del repositorio de snapshots
+func NewSnapshotRepository(db *sql.DB) *SnapshotRepository {
+	return &SnapshotRepository{
+		db: db,
+	}
+}
+
+// Inicializa la tabla de snapshots. La fecha se guarda como texto YYYY-MM-DD,
+// que se ordena igual que la fecha
+func (r *SnapshotRepository) InitDB(ctx context.Context) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS recommendation_snapshots (
+        date STRING NOT NULL,
+        strategy STRING NOT NULL,
+        taken_at TIMESTAMP NOT NULL,
+        as_of TIMESTAMP NOT NULL,
+        recommendations JSONB NOT NULL,
+        PRIMARY KEY (strategy, date)
+    )
+    `
+
+	_, err := r.db.ExecContext(ctx, query)
+	return err
+}
+
+// Guarda un snapshot, reemplazando el del mismo día y estrategia
+func (r *SnapshotRepository) SaveSnapshot(ctx context.Context, snapshot recommendation.Snapshot) error {
+	recommendations, err := json.Marshal(snapshot.Recommendations)
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot recommendations: %w", err)
+	}
+
+	query := `
+        UPSERT INTO recommendation_snapshots (date, strategy, taken_at, as_of, recommendations)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+	_, err = r.db.ExecContext(ctx, query,
+		snapshot.Date,
+		snapshot.Strategy,
+		snapshot.TakenAt,
+		snapshot.AsOf,
+		recommendations,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving recommendation snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Obtiene el snapshot de un día y estrategia
+func (r *SnapshotRepository) GetSnapshot(ctx context.Context, date, strategy string) (recommendation.Snapshot, error) {
+	query := `
+    SELECT date, strategy, taken_at, as_of, recommendations
+    FROM recommendation_snapshots
+    WHERE strategy = $1 AND date = $2
+    `
+
+	snapshot, err := scanSnapshot(r.db.QueryRowContext(ctx, query, strategy, date))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return snapshot, fmt.Errorf("%w: %s (%s)", ports.ErrSnapshotNotFound, date, strategy)
+		}
+		return snapshot, fmt.Errorf("error getting recommendation snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// Obtiene el snapshot más reciente de una estrategia
+func (r *SnapshotRepository) GetLatestSnapshot(ctx context.Context, strategy string) (recommendation.Snapshot, error) {
+	query := `
+    SELECT date, strategy, taken_at, as_of, recommendations
+    FROM recommendation_snapshots
+    WHERE strategy = $1
+    ORDER BY date DESC
+    LIMIT 1
+    `
+
+	snapshot, err := scanSnapshot(r.db.QueryRowContext(ctx, query, strategy))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return snapshot, fmt.Errorf("%w: %s", ports.ErrSnapshotNotFound, strategy)
+		}
+		return snapshot, fmt.Errorf("error getting latest recommendation snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// scanSnapshot lee una fila de snapshots y decodifica las recomendaciones
+func scanSnapshot(row interface{ Scan(...interface{}) error }) (recommendation.Snapshot, error) {
+	var snapshot recommendation.Snapshot
+	var recommendations []byte
+
+	err := row.Scan(
+		&snapshot.Date,
+		&snapshot.Strategy,
+		&snapshot.TakenAt,
+		&snapshot.AsOf,
+		&recommendations,
+	)
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := json.Unmarshal(recommendations, &snapshot.Recommendations); err != nil {
+		return snapshot, fmt.Errorf("error decoding snapshot recommendations: %w", err)
+	}
+
+	return snapshot, nil
+}
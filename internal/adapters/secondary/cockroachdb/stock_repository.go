@@ -4,22 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
 	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
 )
 
+var _ ports.StockRepository = (*StockRepository)(nil)
+
 // Implementa la interfaz de repositorio
 type StockRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	taxonomy *models.RatingTaxonomy
 }
 
 // Crea una nueva instancia del repositorio
 func NewStockRepository(db *sql.DB) *StockRepository {
 	return &StockRepository{
-		db: db,
+		db:       db,
+		taxonomy: models.DefaultRatingTaxonomy(),
 	}
 }
 
@@ -40,32 +47,417 @@ func (r *StockRepository) InitDB(ctx context.Context) error {
     )
     `
 
-	_, err := r.db.ExecContext(ctx, query)
-	return err
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	// Historial append-only de acciones de analistas. Un evento se identifica
+	// por ticker, casa de bolsa y fecha, por lo que reingestar es idempotente.
+	eventsQuery := `
+    CREATE TABLE IF NOT EXISTS rating_events (
+        ticker STRING NOT NULL,
+        brokerage STRING NOT NULL,
+        time TIMESTAMP NOT NULL,
+        company STRING NOT NULL,
+        target_from STRING NOT NULL,
+        target_to STRING NOT NULL,
+        action STRING NOT NULL,
+        rating_from STRING NOT NULL,
+        rating_to STRING NOT NULL,
+        created_at TIMESTAMP DEFAULT current_timestamp(),
+        PRIMARY KEY (ticker, brokerage, time),
+        INDEX rating_events_time_idx (time DESC)
+    )
+    `
+
+	if _, err := r.db.ExecContext(ctx, eventsQuery); err != nil {
+		return err
+	}
+
+	// Columnas numéricas de precio objetivo, interpretadas al ingerir los datos
+	for _, table := range []string{"stocks", "rating_events"} {
+		migrations := []string{
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS target_from_value DECIMAL(18,4)`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS target_from_currency STRING`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS target_to_value DECIMAL(18,4)`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS target_to_currency STRING`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS target_change_pct DECIMAL(12,4)`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS rating_from_bucket STRING`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS rating_to_bucket STRING`,
+			`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS target_parse_status STRING`,
+		}
+
+		for _, migration := range migrations {
+			if _, err := r.db.ExecContext(ctx, migration); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Conserva como evento la última actualización ya almacenada en stocks
+	backfillQuery := `
+    INSERT INTO rating_events (
+        ticker, brokerage, time, company, target_from, target_to,
+        action, rating_from, rating_to
+    )
+    SELECT ticker, brokerage, time, company, target_from, target_to,
+        action, rating_from, rating_to
+    FROM stocks
+    ON CONFLICT (ticker, brokerage, time) DO NOTHING
+    `
+
+	if _, err := r.db.ExecContext(ctx, backfillQuery); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"stocks", "rating_events"} {
+		if err := r.backfillTargetPrices(ctx, table); err != nil {
+			return err
+		}
+		if err := r.backfillRatingBuckets(ctx, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// Guarda múltiples stocks en la base de datos
-func (r *StockRepository) SaveStocks(ctx context.Context, stocks []models.Stock) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
+// backfillRatingBuckets reclasifica las calificaciones guardadas con la taxonomía
+// vigente. Se recorre cada par distinto de casa de bolsa y calificación, por lo que
+// los cambios en la taxonomía se aplican al reiniciar
+func (r *StockRepository) backfillRatingBuckets(ctx context.Context, table string) error {
+	for _, column := range []string{"rating_from", "rating_to"} {
+		rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT brokerage, `+column+` FROM `+table)
+		if err != nil {
+			return fmt.Errorf("error loading %s ratings to classify: %w", table, err)
+		}
+
+		type pair struct{ brokerage, rating string }
+		var pairs []pair
+		for rows.Next() {
+			var p pair
+			if err := rows.Scan(&p.brokerage, &p.rating); err != nil {
+				rows.Close()
+				return fmt.Errorf("error scanning %s ratings: %w", table, err)
+			}
+			pairs = append(pairs, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating %s ratings: %w", table, err)
+		}
+
+		update := `
+            UPDATE ` + table + ` SET ` + column + `_bucket = $3
+            WHERE brokerage = $1 AND ` + column + ` = $2
+                AND ` + column + `_bucket IS DISTINCT FROM $3
+        `
+
+		updated := int64(0)
+		for _, p := range pairs {
+			res, err := r.db.ExecContext(ctx, update, p.brokerage, p.rating, bucketArg(r.taxonomy, p.brokerage, p.rating))
+			if err != nil {
+				return fmt.Errorf("error classifying %s ratings: %w", table, err)
+			}
+			if n, err := res.RowsAffected(); err == nil {
+				updated += n
+			}
+		}
+
+		if updated > 0 {
+			log.Printf("Calificaciones reclasificadas en %s.%s: %d filas actualizadas", table, column, updated)
+		}
+	}
+
+	return nil
+}
+
+// bucketArg clasifica una calificación como valor SQL, NULL si no está en la taxonomía
+func bucketArg(taxonomy *models.RatingTaxonomy, brokerage, rating string) interface{} {
+	if bucket, ok := taxonomy.Classify(brokerage, rating); ok {
+		return string(bucket)
+	}
+	return nil
+}
+
+// Estados de target_parse_status. Las filas sin estado aún no se interpretaron
+const (
+	targetParseOK      = "ok"
+	targetParseInvalid = "invalid"
+)
+
+// targetParseStatus indica si los precios objetivo no vacíos del stock se interpretaron
+func targetParseStatus(stock models.Stock) string {
+	if (stock.TargetFrom != "" && stock.TargetFromPrice == nil) || (stock.TargetTo != "" && stock.TargetToPrice == nil) {
+		return targetParseInvalid
+	}
+	return targetParseOK
+}
+
+// backfillTargetPrices interpreta los precios objetivo de filas guardadas antes
+// de que existieran las columnas numéricas. Cada fila queda marcada con su estado,
+// de modo que los valores no interpretables no se vuelven a procesar en cada inicio.
+// Las filas se recorren por clave primaria en lotes de maxBatchSize, para no cargar
+// el historial completo en memoria
+func (r *StockRepository) backfillTargetPrices(ctx context.Context, table string) error {
+	first := `
+    SELECT ` + stockSelectColumns + `
+    FROM ` + table + `
+    WHERE target_parse_status IS NULL
+    ORDER BY ticker, brokerage, time
+    LIMIT $1
+    `
+	next := `
+    SELECT ` + stockSelectColumns + `
+    FROM ` + table + `
+    WHERE target_parse_status IS NULL AND (ticker, brokerage, time) > ($2, $3, $4)
+    ORDER BY ticker, brokerage, time
+    LIMIT $1
+    `
+
+	update := `
+        UPDATE ` + table + ` SET
+            target_from_value = $4,
+            target_from_currency = $5,
+            target_to_value = $6,
+            target_to_currency = $7,
+            target_change_pct = $8,
+            target_parse_status = $9
+        WHERE ticker = $1 AND brokerage = $2 AND time = $3
+    `
+
+	processed, unparsed := 0, 0
+	var last *models.Stock
+	for {
+		var batch []models.Stock
+		var err error
+		if last == nil {
+			batch, err = r.queryStocks(ctx, first, maxBatchSize)
+		} else {
+			batch, err = r.queryStocks(ctx, next, maxBatchSize, last.Ticker, last.Brokerage, last.Time)
+		}
+		if err != nil {
+			return fmt.Errorf("error loading %s rows to backfill: %w", table, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			if errs := batch[i].ParseTargets(); len(errs) > 0 {
+				unparsed++
+			}
+		}
+
+		err = runInTx(ctx, r.db, func(tx *sql.Tx) error {
+			for _, stock := range batch {
+				fromValue, fromCurrency, toValue, toCurrency, changePct := priceArgs(stock)
+				if _, err := tx.ExecContext(ctx, update,
+					stock.Ticker, stock.Brokerage, stock.Time,
+					fromValue, fromCurrency, toValue, toCurrency, changePct,
+					targetParseStatus(stock),
+				); err != nil {
+					return fmt.Errorf("error backfilling target prices for %s: %w", stock.Ticker, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		processed += len(batch)
+		last = &batch[len(batch)-1]
+		if len(batch) < maxBatchSize {
+			break
+		}
+	}
+
+	if processed > 0 {
+		log.Printf("Precios objetivo interpretados en %s: %d filas interpretadas, %d con valores no interpretables",
+			table, processed-unparsed, unparsed)
+	}
+
+	return nil
+}
+
+// maxBatchSize limita las filas escritas por transacción en SaveStocks y en la
+// interpretación de precios de filas existentes
+const maxBatchSize = 500
+
+// stockColumns son las columnas escritas por fila en SaveStocks
+const stockColumns = 17
+
+// stockSelectColumns son las columnas leídas por scanStock, en el mismo orden
+const stockSelectColumns = `
+        ticker, company, target_from, target_to,
+        action, brokerage, rating_from, rating_to, time,
+        target_from_value, target_from_currency,
+        target_to_value, target_to_currency, target_change_pct,
+        rating_from_bucket, rating_to_bucket`
+
+// Guarda múltiples stocks en la base de datos. Cada stock se agrega al
+// historial de eventos y la tabla stocks conserva la actualización más reciente.
+// Los stocks se escriben en lotes de varias filas, cada uno en su propia transacción
+func (r *StockRepository) SaveStocks(ctx context.Context, stocks []models.Stock) (models.SaveResult, error) {
+	var result models.SaveResult
+
+	for start := 0; start < len(stocks); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(stocks) {
+			end = len(stocks)
+		}
+
+		batch, err := r.saveBatch(ctx, stocks[start:end])
+		if err != nil {
+			return result, err
+		}
+
+		result.Inserted += batch.Inserted
+		result.Updated += batch.Updated
 	}
 
-	stmt, err := tx.PrepareContext(ctx, `
-        UPSERT INTO stocks (
-            ticker, company, target_from, target_to, 
-            action, brokerage, rating_from, rating_to, time
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-    `)
+	return result, nil
+}
+
+// saveBatch escribe un lote con un INSERT de varias filas por tabla
+func (r *StockRepository) saveBatch(ctx context.Context, stocks []models.Stock) (models.SaveResult, error) {
+	var result models.SaveResult
+
+	events := uniqueEvents(stocks)
+	latest := latestPerTicker(stocks)
+
+	eventQuery := `
+        INSERT INTO rating_events (
+            ticker, company, target_from, target_to,
+            action, brokerage, rating_from, rating_to, time,
+            target_from_value, target_from_currency,
+            target_to_value, target_to_currency, target_change_pct,
+            rating_from_bucket, rating_to_bucket, target_parse_status
+        ) VALUES ` + valuePlaceholders(len(events), stockColumns) + `
+        ON CONFLICT (ticker, brokerage, time) DO NOTHING
+    `
+
+	stockQuery := `
+        INSERT INTO stocks (
+            ticker, company, target_from, target_to,
+            action, brokerage, rating_from, rating_to, time,
+            target_from_value, target_from_currency,
+            target_to_value, target_to_currency, target_change_pct,
+            rating_from_bucket, rating_to_bucket, target_parse_status
+        ) VALUES ` + valuePlaceholders(len(latest), stockColumns) + `
+        ON CONFLICT (ticker) DO UPDATE SET
+            company = excluded.company,
+            target_from = excluded.target_from,
+            target_to = excluded.target_to,
+            action = excluded.action,
+            brokerage = excluded.brokerage,
+            rating_from = excluded.rating_from,
+            rating_to = excluded.rating_to,
+            time = excluded.time,
+            target_from_value = excluded.target_from_value,
+            target_from_currency = excluded.target_from_currency,
+            target_to_value = excluded.target_to_value,
+            target_to_currency = excluded.target_to_currency,
+            target_change_pct = excluded.target_change_pct,
+            rating_from_bucket = excluded.rating_from_bucket,
+            rating_to_bucket = excluded.rating_to_bucket,
+            target_parse_status = excluded.target_parse_status
+        WHERE stocks.time < excluded.time
+    `
+
+	err := runInTx(ctx, r.db, func(tx *sql.Tx) error {
+		result = models.SaveResult{}
+
+		res, err := tx.ExecContext(ctx, eventQuery, stockArgs(events)...)
+		if err != nil {
+			return fmt.Errorf("error saving rating events: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			result.Inserted = int(n)
+		}
+
+		res, err = tx.ExecContext(ctx, stockQuery, stockArgs(latest)...)
+		if err != nil {
+			return fmt.Errorf("error saving stocks: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			result.Updated = int(n)
+		}
+
+		return nil
+	})
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error preparing statement: %w", err)
+		return models.SaveResult{}, err
+	}
+
+	return result, nil
+}
+
+// uniqueEvents elimina eventos repetidos dentro de un lote, un mismo INSERT no puede afectar dos veces la misma fila
+func uniqueEvents(stocks []models.Stock) []models.Stock {
+	type key struct {
+		ticker    string
+		brokerage string
+		time      time.Time
+	}
+
+	seen := make(map[key]bool, len(stocks))
+	events := make([]models.Stock, 0, len(stocks))
+	for _, stock := range stocks {
+		k := key{ticker: stock.Ticker, brokerage: stock.Brokerage, time: stock.Time}
+		if !seen[k] {
+			seen[k] = true
+			events = append(events, stock)
+		}
+	}
+
+	return events
+}
+
+// latestPerTicker conserva la actualización más reciente de cada ticker del lote
+func latestPerTicker(stocks []models.Stock) []models.Stock {
+	index := make(map[string]int, len(stocks))
+	latest := make([]models.Stock, 0, len(stocks))
+	for _, stock := range stocks {
+		i, exists := index[stock.Ticker]
+		if !exists {
+			index[stock.Ticker] = len(latest)
+			latest = append(latest, stock)
+		} else if stock.Time.After(latest[i].Time) {
+			latest[i] = stock
+		}
+	}
+
+	return latest
+}
+
+// valuePlaceholders genera "($1, $2, ...), ($n+1, ...)" para un INSERT de varias filas
+func valuePlaceholders(rows, columns int) string {
+	var sb strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j := 0; j < columns; j++ {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", i*columns+j+1)
+		}
+		sb.WriteString(")")
 	}
-	defer stmt.Close()
 
+	return sb.String()
+}
+
+// stockArgs aplana los stocks en el orden de columnas de SaveStocks
+func stockArgs(stocks []models.Stock) []interface{} {
+	args := make([]interface{}, 0, len(stocks)*stockColumns)
 	for _, stock := range stocks {
-		_, err := stmt.ExecContext(
-			ctx,
+		fromValue, fromCurrency, toValue, toCurrency, changePct := priceArgs(stock)
+		args = append(args,
 			stock.Ticker,
 			stock.Company,
 			stock.TargetFrom,
@@ -75,60 +467,100 @@ func (r *StockRepository) SaveStocks(ctx context.Context, stocks []models.Stock)
 			stock.RatingFrom,
 			stock.RatingTo,
 			stock.Time,
+			fromValue,
+			fromCurrency,
+			toValue,
+			toCurrency,
+			changePct,
+			nullableBucket(stock.RatingFromBucket),
+			nullableBucket(stock.RatingToBucket),
+			targetParseStatus(stock),
 		)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("error saving stock %s: %w", stock.Ticker, err)
-		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
+	return args
+}
+
+// priceArgs convierte los precios interpretados en valores SQL, NULL cuando faltan
+func priceArgs(stock models.Stock) (fromValue, fromCurrency, toValue, toCurrency, changePct interface{}) {
+	if stock.TargetFromPrice != nil {
+		fromValue, fromCurrency = stock.TargetFromPrice.Amount, stock.TargetFromPrice.Currency
+	}
+	if stock.TargetToPrice != nil {
+		toValue, toCurrency = stock.TargetToPrice.Amount, stock.TargetToPrice.Currency
+	}
+	if stock.TargetChangePct != nil {
+		changePct = *stock.TargetChangePct
 	}
 
-	return nil
+	return fromValue, fromCurrency, toValue, toCurrency, changePct
 }
 
-// Recupera stocks con paginación y ordenamiento
-func (r *StockRepository) GetStocks(ctx context.Context, orderBy string, sortOrder string, offset, limit int) ([]models.Stock, error) {
+// nullableBucket guarda las calificaciones sin categoría como NULL
+func nullableBucket(bucket models.RatingBucket) interface{} {
+	if bucket == "" {
+		return nil
+	}
+	return string(bucket)
+}
 
-	if orderBy == "" {
-		orderBy = "time"
+// scanStock lee un stock con las columnas de stockSelectColumns
+func scanStock(row interface{ Scan(...interface{}) error }) (models.Stock, error) {
+	var stock models.Stock
+	var fromValue, toValue, changePct sql.NullFloat64
+	var fromCurrency, toCurrency sql.NullString
+	var fromBucket, toBucket sql.NullString
+
+	err := row.Scan(
+		&stock.Ticker,
+		&stock.Company,
+		&stock.TargetFrom,
+		&stock.TargetTo,
+		&stock.Action,
+		&stock.Brokerage,
+		&stock.RatingFrom,
+		&stock.RatingTo,
+		&stock.Time,
+		&fromValue,
+		&fromCurrency,
+		&toValue,
+		&toCurrency,
+		&changePct,
+		&fromBucket,
+		&toBucket,
+	)
+	if err != nil {
+		return stock, err
 	}
-	if sortOrder == "" {
-		sortOrder = "DESC"
+
+	stock.RatingFromBucket = models.RatingBucket(fromBucket.String)
+	stock.RatingToBucket = models.RatingBucket(toBucket.String)
+
+	if fromValue.Valid {
+		stock.TargetFromPrice = &models.Price{Amount: fromValue.Float64, Currency: fromCurrency.String}
+	}
+	if toValue.Valid {
+		stock.TargetToPrice = &models.Price{Amount: toValue.Float64, Currency: toCurrency.String}
+	}
+	if changePct.Valid {
+		stock.TargetChangePct = &changePct.Float64
 	}
 
-	// Consulta ordenamiento y paginación
-	query := fmt.Sprintf(`
-		SELECT 
-			ticker, company, target_from, target_to, 
-			action, brokerage, rating_from, rating_to, time
-		FROM stocks
-		ORDER BY %s %s
-		LIMIT $1 OFFSET $2
-	`, orderBy, sortOrder)
+	return stock, nil
+}
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+// queryStocks ejecuta una consulta que selecciona stockSelectColumns y lee todas las filas
+func (r *StockRepository) queryStocks(ctx context.Context, query string, args ...interface{}) ([]models.Stock, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error querying stocks: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
 	var stocks []models.Stock
 	for rows.Next() {
-		var stock models.Stock
-		if err := rows.Scan(
-			&stock.Ticker,
-			&stock.Company,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.Action,
-			&stock.Brokerage,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.Time,
-		); err != nil {
+		stock, err := scanStock(rows)
+		if err != nil {
 			return nil, fmt.Errorf("error scanning stock: %w", err)
 		}
 		stocks = append(stocks, stock)
@@ -141,211 +573,172 @@ func (r *StockRepository) GetStocks(ctx context.Context, orderBy string, sortOrd
 	return stocks, nil
 }
 
-// Cuenta el total de stocks en la base de datos
-func (r *StockRepository) CountStocks(ctx context.Context) (int, error) {
-	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks").Scan(&count)
+// Recupera stocks con filtros, paginación y ordenamiento
+func (r *StockRepository) GetStocks(ctx context.Context, filter models.StockFilter, orderBy string, sortOrder string, offset, limit int) ([]models.Stock, error) {
+	column, sortOrder, err := resolveOrder(orderBy, sortOrder)
 	if err != nil {
-		return 0, fmt.Errorf("error counting stocks: %w", err)
+		return nil, err
 	}
-	return count, nil
-}
 
-// Recupera stocks filtrados por brokerage con paginación
-func (r *StockRepository) GetStocksByBrokerage(ctx context.Context, brokerage string, offset, limit int) ([]models.Stock, error) {
-	query := `
-		SELECT 
-			ticker, company, target_from, target_to, 
-			action, brokerage, rating_from, rating_to, time
+	q := newStockQuery(filter)
+	limitArg, offsetArg := q.arg(limit), q.arg(offset)
+
+	// Los valores NULL (precios no interpretables) quedan siempre al final
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM stocks
-		WHERE brokerage = $1
-		ORDER BY time DESC
-		LIMIT $2 OFFSET $3
-	`
+		%s
+		ORDER BY (%s IS NULL), %s %s, ticker ASC
+		LIMIT %s OFFSET %s
+	`, stockSelectColumns, q.clause(), column, column, sortOrder, limitArg, offsetArg)
 
-	rows, err := r.db.QueryContext(ctx, query, brokerage, limit, offset)
+	stocks, err := r.queryStocks(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("error querying stocks by brokerage: %w", err)
-	}
-	defer rows.Close()
-
-	var stocks []models.Stock
-	for rows.Next() {
-		var stock models.Stock
-		if err := rows.Scan(
-			&stock.Ticker,
-			&stock.Company,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.Action,
-			&stock.Brokerage,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.Time,
-		); err != nil {
-			return nil, fmt.Errorf("error scanning stock: %w", err)
-		}
-		stocks = append(stocks, stock)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating stocks: %w", err)
+		return nil, fmt.Errorf("error querying stocks: %w", err)
 	}
 
 	return stocks, nil
 }
 
-// Cuenta el total de stocks para un brokerage específico
-func (r *StockRepository) CountStocksByBrokerage(ctx context.Context, brokerage string) (int, error) {
-	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks WHERE brokerage = $1", brokerage).Scan(&count)
+// Obtiene la página de stocks posterior (o anterior) al cursor, paginando por
+// la columna de ordenamiento y el ticker en lugar de OFFSET
+func (r *StockRepository) GetStocksByCursor(ctx context.Context, filter models.StockFilter, cursor models.StockCursor, limit int) ([]models.Stock, error) {
+	column, sortOrder, err := resolveOrder(cursor.OrderBy, cursor.SortOrder)
 	if err != nil {
-		return 0, fmt.Errorf("error counting stocks by brokerage: %w", err)
+		return nil, err
 	}
-	return count, nil
-}
+	cursor.OrderBy = orderByOrDefault(cursor.OrderBy)
 
-// Recupera stocks cuyo ticker coincida con un patrón
-func (r *StockRepository) GetStocksByTickerPattern(ctx context.Context, tickerPattern string, offset, limit int) ([]models.Stock, error) {
-	query := `
-		SELECT 
-			ticker, company, target_from, target_to, 
-			action, brokerage, rating_from, rating_to, time
-		FROM stocks
-		WHERE ticker ILIKE $1
-		ORDER BY time DESC
-		LIMIT $2 OFFSET $3
-	`
+	q := newStockQuery(filter)
+	if err := q.keyset(cursor, column, sortOrder == "DESC"); err != nil {
+		return nil, err
+	}
+	limitArg := q.arg(limit)
 
-	// Realizamos búsqueda parcial
-	pattern := "%" + tickerPattern + "%"
+	// Hacia atrás se invierte el orden y luego se restaura el de la página
+	order := fmt.Sprintf("(%s IS NULL), %s %s, ticker ASC", column, column, sortOrder)
+	if cursor.Backward {
+		order = fmt.Sprintf("(%s IS NULL) DESC, %s %s, ticker DESC", column, column, reverseOrder(sortOrder))
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, pattern, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM stocks
+		%s
+		ORDER BY %s
+		LIMIT %s
+	`, stockSelectColumns, q.clause(), order, limitArg)
+
+	stocks, err := r.queryStocks(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("error querying stocks by ticker pattern: %w", err)
+		return nil, fmt.Errorf("error querying stocks by cursor: %w", err)
 	}
-	defer rows.Close()
 
-	var stocks []models.Stock
-	for rows.Next() {
-		var stock models.Stock
-		if err := rows.Scan(
-			&stock.Ticker,
-			&stock.Company,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.Action,
-			&stock.Brokerage,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.Time,
-		); err != nil {
-			return nil, fmt.Errorf("error scanning stock: %w", err)
+	if cursor.Backward {
+		for i, j := 0, len(stocks)-1; i < j; i, j = i+1, j-1 {
+			stocks[i], stocks[j] = stocks[j], stocks[i]
 		}
-		stocks = append(stocks, stock)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating stocks: %w", err)
 	}
 
 	return stocks, nil
 }
 
-// Cuenta el total de stocks que coinciden con un patrón de ticker
-func (r *StockRepository) CountStocksByTickerPattern(ctx context.Context, tickerPattern string) (int, error) {
-	var count int
-
-	pattern := "%" + tickerPattern + "%"
-
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks WHERE ticker ILIKE $1", pattern).Scan(&count)
+// Recorre los stocks que cumplen los filtros leyendo las filas del cursor de la
+// base de datos una por una, sin cargarlas en memoria
+func (r *StockRepository) StreamStocks(ctx context.Context, filter models.StockFilter, orderBy string, sortOrder string, fn func(models.Stock) error) error {
+	column, sortOrder, err := resolveOrder(orderBy, sortOrder)
 	if err != nil {
-		return 0, fmt.Errorf("error counting stocks by ticker pattern: %w", err)
+		return err
 	}
-	return count, nil
-}
 
-// Recupera stocks por su rating (from o to)
-func (r *StockRepository) GetStocksByRating(ctx context.Context, rating string, offset, limit int) ([]models.Stock, error) {
-	query := `
-		SELECT 
-			ticker, company, target_from, target_to, 
-			action, brokerage, rating_from, rating_to, time
+	q := newStockQuery(filter)
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM stocks
-		WHERE rating_from = $1 OR rating_to = $1
-		ORDER BY time DESC
-		LIMIT $2 OFFSET $3
-	`
+		%s
+		ORDER BY (%s IS NULL), %s %s, ticker ASC
+	`, stockSelectColumns, q.clause(), column, column, sortOrder)
 
-	rows, err := r.db.QueryContext(ctx, query, rating, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("error querying stocks by rating: %w", err)
+		return fmt.Errorf("error querying stocks: %w", err)
 	}
 	defer rows.Close()
 
-	var stocks []models.Stock
 	for rows.Next() {
-		var stock models.Stock
-		if err := rows.Scan(
-			&stock.Ticker,
-			&stock.Company,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.Action,
-			&stock.Brokerage,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.Time,
-		); err != nil {
-			return nil, fmt.Errorf("error scanning stock: %w", err)
+		stock, err := scanStock(rows)
+		if err != nil {
+			return fmt.Errorf("error scanning stock: %w", err)
+		}
+		if err := fn(stock); err != nil {
+			return err
 		}
-		stocks = append(stocks, stock)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating stocks: %w", err)
+		return fmt.Errorf("error iterating stocks: %w", err)
 	}
 
-	return stocks, nil
+	return nil
 }
 
-// Cuenta el total de stocks con un rating específico
-func (r *StockRepository) CountStocksByRating(ctx context.Context, rating string) (int, error) {
+// resolveOrder valida el campo y sentido de ordenamiento y retorna la columna SQL
+func resolveOrder(orderBy, sortOrder string) (string, string, error) {
+	orderBy = orderByOrDefault(orderBy)
+	if sortOrder == "" {
+		sortOrder = "DESC"
+	}
+
+	column, ok := orderColumns[orderBy]
+	if !ok {
+		return "", "", fmt.Errorf("invalid order field: %s", orderBy)
+	}
+	sortOrder = strings.ToUpper(sortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		return "", "", fmt.Errorf("invalid sort order: %s", sortOrder)
+	}
+
+	return column, sortOrder, nil
+}
+
+func orderByOrDefault(orderBy string) string {
+	if orderBy == "" {
+		return "time"
+	}
+	return orderBy
+}
+
+func reverseOrder(sortOrder string) string {
+	if sortOrder == "ASC" {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// Cuenta el total de stocks que cumplen los filtros
+func (r *StockRepository) CountStocks(ctx context.Context, filter models.StockFilter) (int, error) {
+	q := newStockQuery(filter)
+
 	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks WHERE rating_from = $1 OR rating_to = $1", rating).Scan(&count)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks "+q.clause(), q.args...).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("error counting stocks by rating: %w", err)
+		return 0, fmt.Errorf("error counting stocks: %w", err)
 	}
 	return count, nil
 }
 
 // Obtiene un stock por su ticker
 func (r *StockRepository) GetStockByTicker(ctx context.Context, ticker string) (models.Stock, error) {
-	var stock models.Stock
-
 	query := `
-    SELECT 
-        ticker, company, target_from, target_to, 
-        action, brokerage, rating_from, rating_to, time
-    FROM stocks 
+    SELECT ` + stockSelectColumns + `
+    FROM stocks
     WHERE ticker = $1
     `
 
-	err := r.db.QueryRowContext(ctx, query, ticker).Scan(
-		&stock.Ticker,
-		&stock.Company,
-		&stock.TargetFrom,
-		&stock.TargetTo,
-		&stock.Action,
-		&stock.Brokerage,
-		&stock.RatingFrom,
-		&stock.RatingTo,
-		&stock.Time,
-	)
-
+	stock, err := scanStock(r.db.QueryRowContext(ctx, query, ticker))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return stock, fmt.Errorf("stock not found: %s", ticker)
+			return stock, fmt.Errorf("%w: %s", ports.ErrStockNotFound, ticker)
 		}
 		return stock, fmt.Errorf("error getting stock: %w", err)
 	}
@@ -353,47 +746,171 @@ func (r *StockRepository) GetStockByTicker(ctx context.Context, ticker string) (
 	return stock, nil
 }
 
+// GetStockByTickerAsOf recupera el evento más reciente de un ticker publicado e
+// ingerido hasta asOf
+func (r *StockRepository) GetStockByTickerAsOf(ctx context.Context, ticker string, asOf time.Time) (models.Stock, error) {
+	query := `
+    SELECT ` + stockSelectColumns + `
+    FROM rating_events
+    WHERE ticker = $1 AND time <= $2 AND created_at <= $2
+    ORDER BY time DESC, brokerage ASC
+    LIMIT 1
+    `
+
+	stock, err := scanStock(r.db.QueryRowContext(ctx, query, ticker, asOf))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return stock, fmt.Errorf("%w: %s as of %s", ports.ErrStockNotFound, ticker, asOf.Format(time.RFC3339))
+		}
+		return stock, fmt.Errorf("error getting stock as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+
+	return stock, nil
+}
+
 // GetStocksByDateRange recupera stocks en un rango de fechas específico
 func (r *StockRepository) GetStocksByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.Stock, error) {
 	query := `
-		SELECT 
-			ticker, company, target_from, target_to, 
-			action, brokerage, rating_from, rating_to, time
+		SELECT ` + stockSelectColumns + `
 		FROM stocks
 		WHERE time BETWEEN $1 AND $2
 		ORDER BY time DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, startDate, endDate)
+	stocks, err := r.queryStocks(ctx, query, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("error querying stocks by date range: %w", err)
 	}
+
+	return stocks, nil
+}
+
+// GetRatingEventsByDateRange recupera los eventos de rating de un rango de fechas
+func (r *StockRepository) GetRatingEventsByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.Stock, error) {
+	query := `
+		SELECT ` + stockSelectColumns + `
+		FROM rating_events
+		WHERE time BETWEEN $1 AND $2
+		ORDER BY time DESC, ticker ASC, brokerage ASC
+	`
+
+	events, err := r.queryStocks(ctx, query, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("error querying rating events by date range: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetRatingEventsAsOf recupera los eventos de rating publicados entre startDate y
+// asOf que ya estaban ingeridos en asOf
+func (r *StockRepository) GetRatingEventsAsOf(ctx context.Context, startDate, asOf time.Time) ([]models.Stock, error) {
+	query := `
+		SELECT ` + stockSelectColumns + `
+		FROM rating_events
+		WHERE time BETWEEN $1 AND $2 AND created_at <= $2
+		ORDER BY time DESC, ticker ASC, brokerage ASC
+	`
+
+	events, err := r.queryStocks(ctx, query, startDate, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("error querying rating events as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+
+	return events, nil
+}
+
+// GetStockHistory recupera todos los eventos de rating de un ticker, del más reciente al más antiguo
+func (r *StockRepository) GetStockHistory(ctx context.Context, ticker string) ([]models.Stock, error) {
+	query := `
+		SELECT ` + stockSelectColumns + `
+		FROM rating_events
+		WHERE ticker = $1
+		ORDER BY time DESC, brokerage ASC
+	`
+
+	events, err := r.queryStocks(ctx, query, ticker)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stock history: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetStockHistoryByDateRange recupera los eventos de rating de un ticker en un rango
+// de fechas, del más reciente al más antiguo
+func (r *StockRepository) GetStockHistoryByDateRange(ctx context.Context, ticker string, startDate, endDate time.Time) ([]models.Stock, error) {
+	query := `
+		SELECT ` + stockSelectColumns + `
+		FROM rating_events
+		WHERE ticker = $1 AND time BETWEEN $2 AND $3
+		ORDER BY time DESC, brokerage ASC
+	`
+
+	events, err := r.queryStocks(ctx, query, ticker, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stock history by date range: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetStockHistoryAsOf recupera los eventos de rating de un ticker publicados entre
+// startDate y asOf que ya estaban ingeridos en asOf, del más reciente al más antiguo
+func (r *StockRepository) GetStockHistoryAsOf(ctx context.Context, ticker string, startDate, asOf time.Time) ([]models.Stock, error) {
+	query := `
+		SELECT ` + stockSelectColumns + `
+		FROM rating_events
+		WHERE ticker = $1 AND time BETWEEN $2 AND $3 AND created_at <= $3
+		ORDER BY time DESC, brokerage ASC
+	`
+
+	events, err := r.queryStocks(ctx, query, ticker, startDate, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stock history as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+
+	return events, nil
+}
+
+// GetUnmappedRatings lista las calificaciones del historial que no tienen categoría,
+// de la más frecuente a la menos frecuente
+func (r *StockRepository) GetUnmappedRatings(ctx context.Context) ([]models.UnmappedRating, error) {
+	query := `
+		SELECT rating, count(*), array_agg(DISTINCT brokerage ORDER BY brokerage)
+		FROM (
+			SELECT rating_from AS rating, brokerage FROM rating_events
+			WHERE rating_from_bucket IS NULL AND rating_from != ''
+			UNION ALL
+			SELECT rating_to AS rating, brokerage FROM rating_events
+			WHERE rating_to_bucket IS NULL AND rating_to != ''
+		) AS ratings
+		GROUP BY rating
+		ORDER BY count(*) DESC, rating ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying unmapped ratings: %w", err)
+	}
 	defer rows.Close()
 
-	var stocks []models.Stock
+	unmapped := []models.UnmappedRating{}
 	for rows.Next() {
-		var stock models.Stock
-		if err := rows.Scan(
-			&stock.Ticker,
-			&stock.Company,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.Action,
-			&stock.Brokerage,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.Time,
-		); err != nil {
-			return nil, fmt.Errorf("error scanning stock: %w", err)
+		var rating models.UnmappedRating
+		var brokerages pq.StringArray
+		if err := rows.Scan(&rating.Rating, &rating.Count, &brokerages); err != nil {
+			return nil, fmt.Errorf("error scanning unmapped rating: %w", err)
 		}
-		stocks = append(stocks, stock)
+		rating.Brokerages = brokerages
+		unmapped = append(unmapped, rating)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating stocks: %w", err)
+		return nil, fmt.Errorf("error iterating unmapped ratings: %w", err)
 	}
 
-	return stocks, nil
+	return unmapped, nil
 }
 
 // Ping verifica la conexión a la base de datos
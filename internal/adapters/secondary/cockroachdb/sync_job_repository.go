@@ -0,0 +1,372 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+var _ ports.SyncJobRepository = (*SyncJobRepository)(nil)
+
+// SyncJobRepository persiste los trabajos de sincronización en CockroachDB
+type SyncJobRepository struct {
+	db *sql.DB
+}
+
+// Crea una nueva instancia del repositorio de trabajos de sincronización
+func NewSyncJobRepository(db *sql.DB) *SyncJobRepository {
+	return &SyncJobRepository{
+		db: db,
+	}
+}
+
+// Inicializa la tabla de trabajos de sincronización
+func (r *SyncJobRepository) InitDB(ctx context.Context) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS sync_jobs (
+        id STRING PRIMARY KEY,
+        state STRING NOT NULL,
+        pages INT NOT NULL DEFAULT 0,
+        items_fetched INT NOT NULL DEFAULT 0,
+        items_inserted INT NOT NULL DEFAULT 0,
+        items_updated INT NOT NULL DEFAULT 0,
+        error STRING NOT NULL DEFAULT '',
+        created_at TIMESTAMP NOT NULL,
+        started_at TIMESTAMP,
+        finished_at TIMESTAMP,
+        INDEX sync_jobs_created_at_idx (created_at DESC)
+    )
+    `
+
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	migrations := []string{
+		`ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS mode STRING NOT NULL DEFAULT 'full'`,
+		`ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS items_skipped INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS target_parse_errors INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS error_code STRING NOT NULL DEFAULT ''`,
+		`CREATE TABLE IF NOT EXISTS sync_checkpoints (
+            id STRING PRIMARY KEY,
+            newest_event_time TIMESTAMP NOT NULL,
+            updated_at TIMESTAMP NOT NULL
+        )`,
+		`ALTER TABLE sync_checkpoints DROP COLUMN IF EXISTS last_next_page`,
+		`CREATE TABLE IF NOT EXISTS sync_leases (
+            id STRING PRIMARY KEY,
+            job_id STRING NOT NULL,
+            expires_at TIMESTAMP NOT NULL
+        )`,
+	}
+
+	for _, migration := range migrations {
+		if _, err := r.db.ExecContext(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stocksCheckpointID identifica el checkpoint y el lease de la sincronización de stocks
+const stocksCheckpointID = "stocks"
+
+// Registra un trabajo nuevo
+func (r *SyncJobRepository) CreateSyncJob(ctx context.Context, job models.SyncJob) error {
+	query := `
+        INSERT INTO sync_jobs (
+            id, state, mode, pages, items_fetched, items_inserted, items_updated,
+            items_skipped, target_parse_errors, error_code, error, created_at, started_at, finished_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+    `
+
+	errorCode, errorMessage := syncJobErrorColumns(job)
+
+	_, err := r.db.ExecContext(ctx, query,
+		job.ID,
+		string(job.State),
+		string(job.Mode),
+		job.Pages,
+		job.ItemsFetched,
+		job.ItemsInserted,
+		job.ItemsUpdated,
+		job.ItemsSkipped,
+		job.TargetParseErrors,
+		errorCode,
+		errorMessage,
+		job.CreatedAt,
+		job.StartedAt,
+		job.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating sync job %s: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+// Actualiza el estado y los contadores de un trabajo existente
+func (r *SyncJobRepository) UpdateSyncJob(ctx context.Context, job models.SyncJob) error {
+	query := `
+        UPDATE sync_jobs SET
+            state = $2,
+            pages = $3,
+            items_fetched = $4,
+            items_inserted = $5,
+            items_updated = $6,
+            items_skipped = $7,
+            target_parse_errors = $8,
+            error_code = $9,
+            error = $10,
+            started_at = $11,
+            finished_at = $12
+        WHERE id = $1
+    `
+
+	errorCode, errorMessage := syncJobErrorColumns(job)
+
+	res, err := r.db.ExecContext(ctx, query,
+		job.ID,
+		string(job.State),
+		job.Pages,
+		job.ItemsFetched,
+		job.ItemsInserted,
+		job.ItemsUpdated,
+		job.ItemsSkipped,
+		job.TargetParseErrors,
+		errorCode,
+		errorMessage,
+		job.StartedAt,
+		job.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating sync job %s: %w", job.ID, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ports.ErrSyncJobNotFound, job.ID)
+	}
+
+	return nil
+}
+
+// Obtiene un trabajo por su ID
+func (r *SyncJobRepository) GetSyncJob(ctx context.Context, id string) (models.SyncJob, error) {
+	query := `
+    SELECT
+        id, state, mode, pages, items_fetched, items_inserted, items_updated,
+        items_skipped, target_parse_errors, error_code, error, created_at, started_at, finished_at
+    FROM sync_jobs
+    WHERE id = $1
+    `
+
+	job, err := scanSyncJob(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return job, fmt.Errorf("%w: %s", ports.ErrSyncJobNotFound, id)
+		}
+		return job, fmt.Errorf("error getting sync job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Lista los trabajos más recientes primero
+func (r *SyncJobRepository) ListSyncJobs(ctx context.Context, limit int) ([]models.SyncJob, error) {
+	query := `
+    SELECT
+        id, state, mode, pages, items_fetched, items_inserted, items_updated,
+        items_skipped, target_parse_errors, error_code, error, created_at, started_at, finished_at
+    FROM sync_jobs
+    ORDER BY created_at DESC
+    LIMIT $1
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sync jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.SyncJob
+	for rows.Next() {
+		job, err := scanSyncJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sync job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sync jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Obtiene el checkpoint de la última sincronización exitosa
+func (r *SyncJobRepository) GetSyncCheckpoint(ctx context.Context) (models.SyncCheckpoint, bool, error) {
+	var checkpoint models.SyncCheckpoint
+
+	query := `
+    SELECT newest_event_time, updated_at
+    FROM sync_checkpoints
+    WHERE id = $1
+    `
+
+	err := r.db.QueryRowContext(ctx, query, stocksCheckpointID).Scan(
+		&checkpoint.NewestEventTime,
+		&checkpoint.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return checkpoint, false, nil
+		}
+		return checkpoint, false, fmt.Errorf("error getting sync checkpoint: %w", err)
+	}
+
+	return checkpoint, true, nil
+}
+
+// Guarda el checkpoint de sincronización
+func (r *SyncJobRepository) SaveSyncCheckpoint(ctx context.Context, checkpoint models.SyncCheckpoint) error {
+	query := `
+        UPSERT INTO sync_checkpoints (id, newest_event_time, updated_at)
+        VALUES ($1, $2, $3)
+    `
+
+	_, err := r.db.ExecContext(ctx, query,
+		stocksCheckpointID,
+		checkpoint.NewestEventTime,
+		checkpoint.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving sync checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// leaseExpiry calcula el vencimiento de un lease con el reloj de la base de datos, de
+// modo que la diferencia entre los relojes de las réplicas no afecte la exclusión.
+// La duración se recibe en milisegundos
+const leaseExpiry = `now()::TIMESTAMP + ($3::INT8 * INTERVAL '1 millisecond')`
+
+// Toma el lease de sincronización si está libre o expirado
+func (r *SyncJobRepository) AcquireSyncLease(ctx context.Context, jobID string, ttl time.Duration) (string, bool, error) {
+	query := `
+        INSERT INTO sync_leases (id, job_id, expires_at)
+        VALUES ($1, $2, ` + leaseExpiry + `)
+        ON CONFLICT (id) DO UPDATE SET
+            job_id = excluded.job_id,
+            expires_at = excluded.expires_at
+        WHERE sync_leases.expires_at <= now()::TIMESTAMP
+        RETURNING job_id
+    `
+
+	var holder string
+	err := r.db.QueryRowContext(ctx, query, stocksCheckpointID, jobID, ttl.Milliseconds()).Scan(&holder)
+	if err == nil {
+		return holder, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", false, fmt.Errorf("error acquiring sync lease: %w", err)
+	}
+
+	// El lease vigente pertenece a otro trabajo
+	err = r.db.QueryRowContext(ctx, `SELECT job_id FROM sync_leases WHERE id = $1`, stocksCheckpointID).Scan(&holder)
+	if err != nil && err != sql.ErrNoRows {
+		return "", false, fmt.Errorf("error getting sync lease: %w", err)
+	}
+
+	return holder, false, nil
+}
+
+// Extiende el lease de sincronización del trabajo
+func (r *SyncJobRepository) RenewSyncLease(ctx context.Context, jobID string, ttl time.Duration) (bool, error) {
+	query := `UPDATE sync_leases SET expires_at = ` + leaseExpiry + ` WHERE id = $1 AND job_id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, stocksCheckpointID, jobID, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("error renewing sync lease: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error renewing sync lease: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// Libera el lease de sincronización del trabajo
+func (r *SyncJobRepository) ReleaseSyncLease(ctx context.Context, jobID string) error {
+	query := `DELETE FROM sync_leases WHERE id = $1 AND job_id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, stocksCheckpointID, jobID); err != nil {
+		return fmt.Errorf("error releasing sync lease: %w", err)
+	}
+
+	return nil
+}
+
+// legacyJobErrorMessage reemplaza los errores guardados antes de existir los códigos,
+// que contienen el error original
+const legacyJobErrorMessage = "Error interno durante la sincronización"
+
+// syncJobErrorColumns retorna el código y el mensaje de error de un trabajo, vacíos si no falló
+func syncJobErrorColumns(job models.SyncJob) (string, string) {
+	if job.Error == nil {
+		return "", ""
+	}
+	return job.Error.Code, job.Error.Message
+}
+
+// scanSyncJob lee un trabajo desde una fila de resultados
+func scanSyncJob(row interface{ Scan(...interface{}) error }) (models.SyncJob, error) {
+	var job models.SyncJob
+	var state, mode, errorCode, errorMessage string
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&state,
+		&mode,
+		&job.Pages,
+		&job.ItemsFetched,
+		&job.ItemsInserted,
+		&job.ItemsUpdated,
+		&job.ItemsSkipped,
+		&job.TargetParseErrors,
+		&errorCode,
+		&errorMessage,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return job, err
+	}
+
+	job.State = models.SyncJobState(state)
+	job.Mode = models.SyncMode(mode)
+	switch {
+	case errorCode != "":
+		job.Error = &models.SyncJobError{Code: errorCode, Message: errorMessage}
+	case errorMessage != "":
+		job.Error = &models.SyncJobError{Code: models.SyncErrorInternal, Message: legacyJobErrorMessage}
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return job, nil
+}
@@ -0,0 +1,68 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxTxRetries limita los reintentos de una transacción abortada por contención
+	maxTxRetries = 5
+
+	// txRetryBaseDelay es la espera inicial entre reintentos de transacción
+	txRetryBaseDelay = 50 * time.Millisecond
+)
+
+// runInTx ejecuta fn dentro de una transacción y la reintenta cuando
+// CockroachDB la aborta con un error de serialización (SQLSTATE 40001)
+func runInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	var err error
+
+	for attempt := 0; attempt <= maxTxRetries; attempt++ {
+		if attempt > 0 {
+			wait := txRetryBaseDelay * time.Duration(1<<(attempt-1))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		err = execTx(ctx, db, fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("transaction failed after %d retries: %w", maxTxRetries, err)
+}
+
+// execTx ejecuta un único intento de la transacción
+func execTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isRetryableTxError indica si el error corresponde a un reintento de transacción de CockroachDB
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "40001"
+}
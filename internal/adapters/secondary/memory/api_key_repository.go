@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+var _ ports.APIKeyRepository = (*APIKeyRepository)(nil)
+
+// APIKeyRepository guarda las API keys en memoria
+type APIKeyRepository struct {
+	mu    sync.RWMutex
+	keys  map[string]models.APIKey
+	usage map[string]int
+}
+
+// NewAPIKeyRepository crea un repositorio de API keys vacío
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys:  make(map[string]models.APIKey),
+		usage: make(map[string]int),
+	}
+}
+
+// Registra una API key nueva
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys {
+		if existing.ID == key.ID || existing.Hash == key.Hash {
+			return fmt.Errorf("api key already exists: %s", key.ID)
+		}
+	}
+	r.keys[key.ID] = key
+
+	return nil
+}
+
+// Obtiene una API key por el hash de la clave
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return models.APIKey{}, ports.ErrAPIKeyNotFound
+}
+
+// Lista las API keys, las más recientes primero
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	r.mu.RLock()
+	keys := make([]models.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// Marca una API key como revocada
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id string, at time.Time) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return models.APIKey{}, fmt.Errorf("%w: %s", ports.ErrAPIKeyNotFound, id)
+	}
+
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		r.keys[id] = key
+	}
+
+	return key, nil
+}
+
+// Registra el último uso de una API key
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ports.ErrAPIKeyNotFound, id)
+	}
+
+	key.LastUsedAt = &at
+	r.keys[id] = key
+
+	return nil
+}
+
+// Suma una solicitud al uso de una API key en el día dado
+func (r *APIKeyRepository) IncrementAPIKeyUsage(ctx context.Context, id, day string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usageKey := id + "/" + day
+	r.usage[usageKey]++
+
+	return r.usage[usageKey], nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+var _ ports.BacktestRepository = (*BacktestRepository)(nil)
+
+// BacktestRepository guarda los resultados de los backtests en memoria
+type BacktestRepository struct {
+	mu      sync.RWMutex
+	reports map[string]backtest.Report
+}
+
+// NewBacktestRepository crea un repositorio de backtests vacío
+func NewBacktestRepository() *BacktestRepository {
+	return &BacktestRepository{
+		reports: make(map[string]backtest.Report),
+	}
+}
+
+// Guarda un backtest ejecutado
+func (r *BacktestRepository) SaveBacktest(ctx context.Context, report backtest.Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.reports[report.ID]; exists {
+		return fmt.Errorf("backtest already exists: %s", report.ID)
+	}
+	r.reports[report.ID] = report
+
+	return nil
+}
+
+// Obtiene un backtest por su ID
+func (r *BacktestRepository) GetBacktest(ctx context.Context, id string) (backtest.Report, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report, exists := r.reports[id]
+	if !exists {
+		return backtest.Report{}, fmt.Errorf("%w: %s", ports.ErrBacktestNotFound, id)
+	}
+
+	return report, nil
+}
+
+// Lista los backtests más recientes primero
+func (r *BacktestRepository) ListBacktests(ctx context.Context, limit int) ([]backtest.Report, error) {
+	r.mu.RLock()
+	reports := make([]backtest.Report, 0, len(r.reports))
+	for _, report := range r.reports {
+		reports = append(reports, report)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+
+	if limit > 0 && len(reports) > limit {
+		reports = reports[:limit]
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+var _ ports.SnapshotRepository = (*SnapshotRepository)(nil)
+
+// snapshotKey identifica el snapshot de un día y estrategia
+type snapshotKey struct {
+	date     string
+	strategy string
+}
+
+// SnapshotRepository guarda los snapshots de recomendaciones en memoria
+type SnapshotRepository struct {
+	mu        sync.RWMutex
+	snapshots map[snapshotKey]recommendation.Snapshot
+}
+
+// NewSnapshotRepository crea un repositorio de snapshots vacío
+func NewSnapshotRepository() *SnapshotRepository {
+	return &SnapshotRepository{
+		snapshots: make(map[snapshotKey]recommendation.Snapshot),
+	}
+}
+
+// Guarda un snapshot, reemplazando el del mismo día y estrategia
+func (r *SnapshotRepository) SaveSnapshot(ctx context.Context, snapshot recommendation.Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshots[snapshotKey{snapshot.Date, snapshot.Strategy}] = snapshot
+	return nil
+}
+
+// Obtiene el snapshot de un día y estrategia
+func (r *SnapshotRepository) GetSnapshot(ctx context.Context, date, strategy string) (recommendation.Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot, exists := r.snapshots[snapshotKey{date, strategy}]
+	if !exists {
+		return recommendation.Snapshot{}, fmt.Errorf("%w: %s (%s)", ports.ErrSnapshotNotFound, date, strategy)
+	}
+
+	return snapshot, nil
+}
+
+// Obtiene el snapshot más reciente de una estrategia
+func (r *SnapshotRepository) GetLatestSnapshot(ctx context.Context, strategy string) (recommendation.Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest recommendation.Snapshot
+	found := false
+	for key, snapshot := range r.snapshots {
+		// Las fechas YYYY-MM-DD se ordenan como texto
+		if key.strategy == strategy && (!found || key.date > latest.Date) {
+			latest = snapshot
+			found = true
+		}
+	}
+
+	if !found {
+		return recommendation.Snapshot{}, fmt.Errorf("%w: %s", ports.ErrSnapshotNotFound, strategy)
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,617 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+var _ ports.StockRepository = (*StockRepository)(nil)
+
+// eventKey identifica un evento de rating igual que la llave primaria de rating_events
+type eventKey struct {
+	ticker    string
+	brokerage string
+	time      time.Time
+}
+
+// StockRepository implementa ports.StockRepository en memoria. Es seguro para
+// uso concurrente y está pensado para pruebas de integración y modo demo.
+type StockRepository struct {
+	mu     sync.RWMutex
+	stocks map[string]models.Stock
+	events map[eventKey]models.Stock
+
+	// ingested guarda la fecha de ingesta de cada evento, como created_at en rating_events
+	ingested map[eventKey]time.Time
+	clock    recommendation.Clock
+}
+
+// NewStockRepository crea un repositorio en memoria vacío
+func NewStockRepository() *StockRepository {
+	return &StockRepository{
+		stocks:   make(map[string]models.Stock),
+		events:   make(map[eventKey]models.Stock),
+		ingested: make(map[eventKey]time.Time),
+		clock:    recommendation.SystemClock,
+	}
+}
+
+// SetClock reemplaza el reloj con el que se registra la fecha de ingesta de los eventos
+func (r *StockRepository) SetClock(clock recommendation.Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clock = clock
+}
+
+// Guarda múltiples stocks. Cada stock se agrega al historial y el snapshot
+// por ticker conserva la actualización más reciente
+func (r *StockRepository) SaveStocks(ctx context.Context, stocks []models.Stock) (models.SaveResult, error) {
+	var result models.SaveResult
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	for _, stock := range stocks {
+		key := eventKey{ticker: stock.Ticker, brokerage: stock.Brokerage, time: stock.Time}
+		if _, exists := r.events[key]; !exists {
+			r.events[key] = stock
+			r.ingested[key] = now
+			result.Inserted++
+		}
+
+		existing, exists := r.stocks[stock.Ticker]
+		if !exists || stock.Time.After(existing.Time) {
+			r.stocks[stock.Ticker] = stock
+			result.Updated++
+		}
+	}
+
+	return result, nil
+}
+
+// Recupera stocks con filtros, paginación y ordenamiento
+func (r *StockRepository) GetStocks(ctx context.Context, filter models.StockFilter, orderBy string, sortOrder string, offset, limit int) ([]models.Stock, error) {
+	if orderBy == "" {
+		orderBy = "time"
+	}
+	if sortOrder == "" {
+		sortOrder = "DESC"
+	}
+
+	stocks := r.filter(matchesFilter(filter))
+	if err := sortStocks(stocks, orderBy, sortOrder); err != nil {
+		return nil, err
+	}
+
+	return paginate(stocks, offset, limit), nil
+}
+
+// Recorre los stocks que cumplen los filtros en el orden indicado
+func (r *StockRepository) StreamStocks(ctx context.Context, filter models.StockFilter, orderBy string, sortOrder string, fn func(models.Stock) error) error {
+	if orderBy == "" {
+		orderBy = "time"
+	}
+	if sortOrder == "" {
+		sortOrder = "DESC"
+	}
+
+	stocks := r.filter(matchesFilter(filter))
+	if err := sortStocks(stocks, orderBy, sortOrder); err != nil {
+		return err
+	}
+
+	for _, stock := range stocks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(stock); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Obtiene la página de stocks posterior (o anterior) al cursor
+func (r *StockRepository) GetStocksByCursor(ctx context.Context, filter models.StockFilter, cursor models.StockCursor, limit int) ([]models.Stock, error) {
+	if cursor.OrderBy == "" {
+		cursor.OrderBy = "time"
+	}
+	if cursor.SortOrder == "" {
+		cursor.SortOrder = "DESC"
+	}
+
+	compare, err := stockOrdering(cursor.OrderBy, cursor.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+	position, err := cursorStock(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	stocks := r.filter(matchesFilter(filter))
+	sort.Slice(stocks, func(i, j int) bool { return compare(stocks[i], stocks[j]) < 0 })
+
+	// Índice del primer stock posterior al cursor
+	start := sort.Search(len(stocks), func(i int) bool { return compare(stocks[i], position) > 0 })
+
+	if cursor.Backward {
+		// Los anteriores al cursor son los que no lo igualan ni superan
+		end := sort.Search(len(stocks), func(i int) bool { return compare(stocks[i], position) >= 0 })
+		begin := 0
+		if limit > 0 && end-limit > 0 {
+			begin = end - limit
+		}
+		return stocks[begin:end], nil
+	}
+
+	return paginate(stocks, start, limit), nil
+}
+
+// Cuenta el total de stocks que cumplen los filtros
+func (r *StockRepository) CountStocks(ctx context.Context, filter models.StockFilter) (int, error) {
+	return len(r.filter(matchesFilter(filter))), nil
+}
+
+// Obtiene un stock por su ticker
+func (r *StockRepository) GetStockByTicker(ctx context.Context, ticker string) (models.Stock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, exists := r.stocks[ticker]
+	if !exists {
+		return models.Stock{}, fmt.Errorf("%w: %s", ports.ErrStockNotFound, ticker)
+	}
+
+	return stock, nil
+}
+
+// GetStockByTickerAsOf recupera el evento más reciente de un ticker publicado e
+// ingerido hasta asOf
+func (r *StockRepository) GetStockByTickerAsOf(ctx context.Context, ticker string, asOf time.Time) (models.Stock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest models.Stock
+	found := false
+	for key, event := range r.events {
+		if key.ticker != ticker || event.Time.After(asOf) || r.ingested[key].After(asOf) {
+			continue
+		}
+		if !found || event.Time.After(latest.Time) ||
+			(event.Time.Equal(latest.Time) && event.Brokerage < latest.Brokerage) {
+			latest = event
+			found = true
+		}
+	}
+
+	if !found {
+		return models.Stock{}, fmt.Errorf("%w: %s as of %s", ports.ErrStockNotFound, ticker, asOf.Format(time.RFC3339))
+	}
+
+	return latest, nil
+}
+
+// GetStockHistory recupera todos los eventos de rating de un ticker, del más reciente al más antiguo
+func (r *StockRepository) GetStockHistory(ctx context.Context, ticker string) ([]models.Stock, error) {
+	return r.tickerEvents(ticker, func(eventKey) bool { return true }), nil
+}
+
+// GetStockHistoryByDateRange recupera los eventos de rating de un ticker en un rango de fechas
+func (r *StockRepository) GetStockHistoryByDateRange(ctx context.Context, ticker string, startDate, endDate time.Time) ([]models.Stock, error) {
+	return r.tickerEvents(ticker, func(key eventKey) bool {
+		return !key.time.Before(startDate) && !key.time.After(endDate)
+	}), nil
+}
+
+// GetStockHistoryAsOf recupera los eventos de rating de un ticker publicados entre
+// startDate y asOf que ya estaban ingeridos en asOf
+func (r *StockRepository) GetStockHistoryAsOf(ctx context.Context, ticker string, startDate, asOf time.Time) ([]models.Stock, error) {
+	return r.tickerEvents(ticker, func(key eventKey) bool {
+		return !key.time.Before(startDate) && !key.time.After(asOf) && !r.ingested[key].After(asOf)
+	}), nil
+}
+
+// tickerEvents retorna los eventos del ticker que cumplen keep, del más reciente al
+// más antiguo. keep se llama con el lock de lectura tomado
+func (r *StockRepository) tickerEvents(ticker string, keep func(eventKey) bool) []models.Stock {
+	r.mu.RLock()
+	var events []models.Stock
+	for key, event := range r.events {
+		if key.ticker == ticker && keep(key) {
+			events = append(events, event)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.After(events[j].Time)
+		}
+		return events[i].Brokerage < events[j].Brokerage
+	})
+
+	return events
+}
+
+// GetStocksByDateRange recupera stocks en un rango de fechas específico
+func (r *StockRepository) GetStocksByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.Stock, error) {
+	stocks := r.filter(func(stock models.Stock) bool {
+		return !stock.Time.Before(startDate) && !stock.Time.After(endDate)
+	})
+	sortStocks(stocks, "time", "DESC")
+
+	return stocks, nil
+}
+
+// GetRatingEventsByDateRange recupera los eventos de rating de un rango de fechas
+func (r *StockRepository) GetRatingEventsByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.Stock, error) {
+	return r.ratingEvents(func(key eventKey) bool {
+		return !key.time.Before(startDate) && !key.time.After(endDate)
+	}), nil
+}
+
+// GetRatingEventsAsOf recupera los eventos de rating publicados entre startDate y
+// asOf que ya estaban ingeridos en asOf
+func (r *StockRepository) GetRatingEventsAsOf(ctx context.Context, startDate, asOf time.Time) ([]models.Stock, error) {
+	return r.ratingEvents(func(key eventKey) bool {
+		return !key.time.Before(startDate) && !key.time.After(asOf) && !r.ingested[key].After(asOf)
+	}), nil
+}
+
+// ratingEvents retorna los eventos que cumplen keep ordenados por fecha descendente,
+// ticker y casa de bolsa. keep se llama con el lock de lectura tomado
+func (r *StockRepository) ratingEvents(keep func(eventKey) bool) []models.Stock {
+	r.mu.RLock()
+	var events []models.Stock
+	for key, event := range r.events {
+		if keep(key) {
+			events = append(events, event)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.After(events[j].Time)
+		}
+		if events[i].Ticker != events[j].Ticker {
+			return events[i].Ticker < events[j].Ticker
+		}
+		return events[i].Brokerage < events[j].Brokerage
+	})
+
+	return events
+}
+
+// GetUnmappedRatings lista las calificaciones del historial que no tienen categoría
+func (r *StockRepository) GetUnmappedRatings(ctx context.Context) ([]models.UnmappedRating, error) {
+	counts := make(map[string]int)
+	brokerages := make(map[string]map[string]bool)
+
+	add := func(rating, brokerage string) {
+		if rating == "" {
+			return
+		}
+		counts[rating]++
+		if brokerages[rating] == nil {
+			brokerages[rating] = make(map[string]bool)
+		}
+		brokerages[rating][brokerage] = true
+	}
+
+	r.mu.RLock()
+	for _, event := range r.events {
+		if event.RatingFromBucket == "" {
+			add(event.RatingFrom, event.Brokerage)
+		}
+		if event.RatingToBucket == "" {
+			add(event.RatingTo, event.Brokerage)
+		}
+	}
+	r.mu.RUnlock()
+
+	unmapped := make([]models.UnmappedRating, 0, len(counts))
+	for rating, count := range counts {
+		names := make([]string, 0, len(brokerages[rating]))
+		for brokerage := range brokerages[rating] {
+			names = append(names, brokerage)
+		}
+		sort.Strings(names)
+
+		unmapped = append(unmapped, models.UnmappedRating{Rating: rating, Count: count, Brokerages: names})
+	}
+
+	sort.Slice(unmapped, func(i, j int) bool {
+		if unmapped[i].Count != unmapped[j].Count {
+			return unmapped[i].Count > unmapped[j].Count
+		}
+		return unmapped[i].Rating < unmapped[j].Rating
+	})
+
+	return unmapped, nil
+}
+
+// Ping siempre responde correctamente, el almacenamiento en memoria no tiene conexión
+func (r *StockRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// filter devuelve una copia de los stocks que cumplen el predicado
+func (r *StockRepository) filter(match func(models.Stock) bool) []models.Stock {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stocks := make([]models.Stock, 0, len(r.stocks))
+	for _, stock := range r.stocks {
+		if match(stock) {
+			stocks = append(stocks, stock)
+		}
+	}
+
+	return stocks
+}
+
+// matchesFilter replica en memoria las condiciones del query builder de CockroachDB
+func matchesFilter(filter models.StockFilter) func(models.Stock) bool {
+	return func(stock models.Stock) bool {
+		if len(filter.Tickers) > 0 && !anyMatch(filter.Tickers, func(v string) bool { return containsFold(stock.Ticker, v) }) {
+			return false
+		}
+		if len(filter.Brokerages) > 0 && !anyMatch(filter.Brokerages, func(v string) bool { return stock.Brokerage == v }) {
+			return false
+		}
+		if (len(filter.RatingBuckets) > 0 || len(filter.Ratings) > 0) && !matchesRating(stock, filter) {
+			return false
+		}
+		if len(filter.RatingsFrom) > 0 && !anyMatch(filter.RatingsFrom, func(v string) bool { return stock.RatingFrom == v }) {
+			return false
+		}
+		if len(filter.RatingsTo) > 0 && !anyMatch(filter.RatingsTo, func(v string) bool { return stock.RatingTo == v }) {
+			return false
+		}
+		if len(filter.Actions) > 0 && !anyMatch(filter.Actions, func(v string) bool { return stock.Action == v }) {
+			return false
+		}
+		if filter.Company != "" && !containsFold(stock.Company, filter.Company) {
+			return false
+		}
+		if filter.From != nil && stock.Time.Before(*filter.From) {
+			return false
+		}
+		if filter.To != nil && stock.Time.After(*filter.To) {
+			return false
+		}
+		if !inRange(targetToValue(stock), filter.MinTarget, filter.MaxTarget) {
+			return false
+		}
+		if !inRange(stock.TargetChangePct, filter.MinTargetChange, filter.MaxTargetChange) {
+			return false
+		}
+		return true
+	}
+}
+
+// matchesRating replica la condición de categorías o ratings sin categoría del query builder
+func matchesRating(stock models.Stock, filter models.StockFilter) bool {
+	for _, bucket := range filter.RatingBuckets {
+		if stock.RatingFromBucket == bucket || stock.RatingToBucket == bucket {
+			return true
+		}
+	}
+	return anyMatch(filter.Ratings, func(v string) bool {
+		return strings.EqualFold(stock.RatingFrom, v) || strings.EqualFold(stock.RatingTo, v)
+	})
+}
+
+// anyMatch indica si algún valor cumple el predicado
+func anyMatch(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold replica la búsqueda parcial ILIKE '%valor%'
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// inRange se comporta como una comparación SQL: un valor NULL no cumple ningún límite
+func inRange(value *float64, min, max *float64) bool {
+	if min == nil && max == nil {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	if min != nil && *value < *min {
+		return false
+	}
+	if max != nil && *value > *max {
+		return false
+	}
+	return true
+}
+
+func targetFromValue(stock models.Stock) *float64 {
+	if stock.TargetFromPrice == nil {
+		return nil
+	}
+	return &stock.TargetFromPrice.Amount
+}
+
+func targetToValue(stock models.Stock) *float64 {
+	if stock.TargetToPrice == nil {
+		return nil
+	}
+	return &stock.TargetToPrice.Amount
+}
+
+// sortStocks ordena por el campo indicado usando el ticker como desempate
+func sortStocks(stocks []models.Stock, orderBy string, sortOrder string) error {
+	compare, err := stockOrdering(orderBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(stocks, func(i, j int) bool { return compare(stocks[i], stocks[j]) < 0 })
+	return nil
+}
+
+// stockOrdering retorna la comparación completa equivalente a
+// "ORDER BY (col IS NULL), col DIR, ticker ASC"
+func stockOrdering(orderBy string, sortOrder string) (func(a, b models.Stock) int, error) {
+	var less func(a, b models.Stock) int
+
+	switch orderBy {
+	case "ticker":
+		less = func(a, b models.Stock) int { return strings.Compare(a.Ticker, b.Ticker) }
+	case "company":
+		less = func(a, b models.Stock) int { return strings.Compare(a.Company, b.Company) }
+	case "brokerage":
+		less = func(a, b models.Stock) int { return strings.Compare(a.Brokerage, b.Brokerage) }
+	case "rating_from":
+		less = func(a, b models.Stock) int { return strings.Compare(a.RatingFrom, b.RatingFrom) }
+	case "rating_to":
+		less = func(a, b models.Stock) int { return strings.Compare(a.RatingTo, b.RatingTo) }
+	case "time":
+		less = func(a, b models.Stock) int { return a.Time.Compare(b.Time) }
+	case "target_from":
+		less = compareNumeric(targetFromValue)
+	case "target_to":
+		less = compareNumeric(targetToValue)
+	case "target_change":
+		less = compareNumeric(func(s models.Stock) *float64 { return s.TargetChangePct })
+	default:
+		return nil, fmt.Errorf("invalid order field: %s", orderBy)
+	}
+
+	desc := strings.EqualFold(sortOrder, "DESC")
+	return func(a, b models.Stock) int {
+		// Igual que en SQL, los valores nulos quedan al final en ambos sentidos
+		if nulls := nullOrder(a, b, orderBy); nulls != 0 {
+			return nulls
+		}
+
+		c := less(a, b)
+		if c == 0 {
+			return strings.Compare(a.Ticker, b.Ticker)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}, nil
+}
+
+// cursorStock construye un stock con los valores del cursor para compararlo
+// con los demás usando el mismo ordenamiento
+func cursorStock(cursor models.StockCursor) (models.Stock, error) {
+	stock := models.Stock{Ticker: cursor.Ticker}
+	if cursor.Null {
+		return stock, nil
+	}
+
+	switch cursor.OrderBy {
+	case "ticker":
+		stock.Ticker = cursor.Value
+	case "company":
+		stock.Company = cursor.Value
+	case "brokerage":
+		stock.Brokerage = cursor.Value
+	case "rating_from":
+		stock.RatingFrom = cursor.Value
+	case "rating_to":
+		stock.RatingTo = cursor.Value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return stock, fmt.Errorf("invalid cursor value for %s: %w", cursor.OrderBy, err)
+		}
+		stock.Time = t
+	case "target_from", "target_to", "target_change":
+		f, err := strconv.ParseFloat(cursor.Value, 64)
+		if err != nil {
+			return stock, fmt.Errorf("invalid cursor value for %s: %w", cursor.OrderBy, err)
+		}
+		price := &models.Price{Amount: f}
+		stock.TargetFromPrice, stock.TargetToPrice, stock.TargetChangePct = price, price, &f
+	}
+
+	return stock, nil
+}
+
+// compareNumeric compara un campo numérico opcional
+func compareNumeric(value func(models.Stock) *float64) func(a, b models.Stock) int {
+	return func(a, b models.Stock) int {
+		va, vb := value(a), value(b)
+		if va == nil || vb == nil {
+			return 0
+		}
+		switch {
+		case *va < *vb:
+			return -1
+		case *va > *vb:
+			return 1
+		}
+		return 0
+	}
+}
+
+// nullOrder retorna -1 si solo b es nulo y 1 si solo a es nulo en el campo de ordenamiento
+func nullOrder(a, b models.Stock, orderBy string) int {
+	var value func(models.Stock) *float64
+
+	switch orderBy {
+	case "target_from":
+		value = targetFromValue
+	case "target_to":
+		value = targetToValue
+	case "target_change":
+		value = func(s models.Stock) *float64 { return s.TargetChangePct }
+	default:
+		return 0
+	}
+
+	aNull, bNull := value(a) == nil, value(b) == nil
+	switch {
+	case aNull && !bNull:
+		return 1
+	case !aNull && bNull:
+		return -1
+	}
+	return 0
+}
+
+// paginate aplica LIMIT/OFFSET sobre un slice ya ordenado
+func paginate(stocks []models.Stock, offset, limit int) []models.Stock {
+	if offset >= len(stocks) {
+		return nil
+	}
+
+	end := len(stocks)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	return stocks[offset:end]
+}
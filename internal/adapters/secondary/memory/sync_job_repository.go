@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+var _ ports.SyncJobRepository = (*SyncJobRepository)(nil)
+
+// SyncJobRepository guarda los trabajos de sincronización en memoria
+type SyncJobRepository struct {
+	mu         sync.RWMutex
+	jobs       map[string]models.SyncJob
+	checkpoint *models.SyncCheckpoint
+
+	// Lease de sincronización, vacío si está libre
+	leaseJobID     string
+	leaseExpiresAt time.Time
+
+	// now es el reloj del repositorio, que en memoria comparten todos los servicios
+	now func() time.Time
+}
+
+// NewSyncJobRepository crea un repositorio de trabajos vacío
+func NewSyncJobRepository() *SyncJobRepository {
+	return &SyncJobRepository{
+		jobs: make(map[string]models.SyncJob),
+		now:  time.Now,
+	}
+}
+
+// Registra un trabajo nuevo
+func (r *SyncJobRepository) CreateSyncJob(ctx context.Context, job models.SyncJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[job.ID]; exists {
+		return fmt.Errorf("sync job already exists: %s", job.ID)
+	}
+	r.jobs[job.ID] = job
+
+	return nil
+}
+
+// Actualiza el estado y los contadores de un trabajo existente
+func (r *SyncJobRepository) UpdateSyncJob(ctx context.Context, job models.SyncJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[job.ID]; !exists {
+		return fmt.Errorf("%w: %s", ports.ErrSyncJobNotFound, job.ID)
+	}
+	r.jobs[job.ID] = job
+
+	return nil
+}
+
+// Obtiene un trabajo por su ID
+func (r *SyncJobRepository) GetSyncJob(ctx context.Context, id string) (models.SyncJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return models.SyncJob{}, fmt.Errorf("%w: %s", ports.ErrSyncJobNotFound, id)
+	}
+
+	return job, nil
+}
+
+// Lista los trabajos más recientes primero
+func (r *SyncJobRepository) ListSyncJobs(ctx context.Context, limit int) ([]models.SyncJob, error) {
+	r.mu.RLock()
+	jobs := make([]models.SyncJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	return jobs, nil
+}
+
+// Obtiene el checkpoint de la última sincronización exitosa
+func (r *SyncJobRepository) GetSyncCheckpoint(ctx context.Context) (models.SyncCheckpoint, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.checkpoint == nil {
+		return models.SyncCheckpoint{}, false, nil
+	}
+
+	return *r.checkpoint, true, nil
+}
+
+// Guarda el checkpoint de sincronización
+func (r *SyncJobRepository) SaveSyncCheckpoint(ctx context.Context, checkpoint models.SyncCheckpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkpoint = &checkpoint
+	return nil
+}
+
+// Toma el lease de sincronización si está libre o expirado
+func (r *SyncJobRepository) AcquireSyncLease(ctx context.Context, jobID string, ttl time.Duration) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if r.leaseJobID != "" && r.leaseExpiresAt.After(now) {
+		return r.leaseJobID, false, nil
+	}
+
+	r.leaseJobID, r.leaseExpiresAt = jobID, now.Add(ttl)
+	return jobID, true, nil
+}
+
+// Extiende el lease de sincronización del trabajo
+func (r *SyncJobRepository) RenewSyncLease(ctx context.Context, jobID string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leaseJobID != jobID {
+		return false, nil
+	}
+
+	r.leaseExpiresAt = r.now().Add(ttl)
+	return true, nil
+}
+
+// Libera el lease de sincronización del trabajo
+func (r *SyncJobRepository) ReleaseSyncLease(ctx context.Context, jobID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leaseJobID == jobID {
+		r.leaseJobID, r.leaseExpiresAt = "", time.Time{}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package prices
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+// loadCSV lee un CSV con encabezado. Las filas con precios vacíos se omiten
+func loadCSV(path string) (*backtest.PriceHistory, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv header: %w", err)
+	}
+
+	dateIdx, okDate := findColumn(header, dateColumns)
+	tickerIdx, okTicker := findColumn(header, tickerColumns)
+	closeIdx, okClose := findColumn(header, closeColumns)
+	if !okDate || !okTicker || !okClose {
+		return nil, fmt.Errorf("csv must have date, ticker and close columns, got %v", header)
+	}
+
+	history := backtest.NewPriceHistory()
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("error reading csv line %d: %w", line, err)
+		}
+		if len(record) <= max(dateIdx, tickerIdx, closeIdx) {
+			return nil, fmt.Errorf("csv line %d: missing columns", line)
+		}
+		if strings.TrimSpace(record[closeIdx]) == "" {
+			continue
+		}
+
+		date, err := parseDate(record[dateIdx])
+		if err != nil {
+			return nil, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		price, err := parseClose(record[closeIdx])
+		if err != nil {
+			return nil, fmt.Errorf("csv line %d: %w", line, err)
+		}
+
+		history.Add(record[tickerIdx], date, price)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,99 @@
+package prices
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+var _ ports.PriceSource = (*FileSource)(nil)
+
+// Nombres de columna aceptados, en orden de preferencia
+var (
+	dateColumns   = []string{"date", "day", "timestamp"}
+	tickerColumns = []string{"ticker", "symbol"}
+	closeColumns  = []string{"adj_close", "adjclose", "adjusted_close", "close"}
+)
+
+// FileSource carga precios de cierre diarios desde un archivo CSV o Parquet local.
+// El archivo debe tener columnas de fecha, ticker y precio de cierre
+type FileSource struct {
+	Path string
+}
+
+// NewFileSource crea una fuente de precios para el archivo indicado
+func NewFileSource(path string) *FileSource {
+	return &FileSource{Path: path}
+}
+
+// LoadPrices lee el archivo completo en cada llamada, de modo que los cambios
+// en el archivo se reflejan en el siguiente backtest
+func (s *FileSource) LoadPrices(ctx context.Context) (*backtest.PriceHistory, error) {
+	if s.Path == "" {
+		return nil, ports.ErrPricesUnavailable
+	}
+
+	var history *backtest.PriceHistory
+	var err error
+
+	switch strings.ToLower(filepath.Ext(s.Path)) {
+	case ".csv":
+		history, err = loadCSV(s.Path)
+	case ".parquet":
+		history, err = loadParquet(s.Path)
+	default:
+		return nil, fmt.Errorf("%w: unsupported price file extension %q", ports.ErrPricesUnavailable, filepath.Ext(s.Path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ports.ErrPricesUnavailable, err)
+	}
+	if history.Len() == 0 {
+		return nil, fmt.Errorf("%w: no prices in %s", ports.ErrPricesUnavailable, s.Path)
+	}
+
+	return history, nil
+}
+
+// String retorna la ruta del archivo de precios
+func (s *FileSource) String() string {
+	return s.Path
+}
+
+// findColumn retorna la primera columna del encabezado que coincide con alguno de los nombres
+func findColumn(header []string, names []string) (int, bool) {
+	for _, name := range names {
+		for i, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// parseDate acepta fechas YYYY-MM-DD o RFC 3339
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseClose interpreta un precio de cierre, que debe ser positivo
+func parseClose(value string) (float64, error) {
+	price, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(value), "$"), 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("invalid close price %q", value)
+	}
+	return price, nil
+}
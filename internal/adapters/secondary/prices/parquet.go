@@ -0,0 +1,173 @@
+package prices
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+// parquetBatchSize es la cantidad de filas leídas por llamada
+const parquetBatchSize = 1024
+
+// parquetColumn es una columna hoja de nivel superior del esquema
+type parquetColumn struct {
+	index   int
+	logical *format.LogicalType
+}
+
+// loadParquet lee un archivo Parquet con columnas planas. La fecha puede ser
+// texto, DATE o TIMESTAMP y el cierre cualquier tipo numérico
+func loadParquet(path string) (*backtest.PriceHistory, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("error opening parquet file: %w", err)
+	}
+
+	schema := pf.Schema()
+	header := make([]string, 0, len(schema.Fields()))
+	for _, field := range schema.Fields() {
+		header = append(header, field.Name())
+	}
+
+	lookup := func(names []string) (parquetColumn, bool) {
+		i, ok := findColumn(header, names)
+		if !ok {
+			return parquetColumn{}, false
+		}
+		leaf, ok := schema.Lookup(header[i])
+		if !ok {
+			return parquetColumn{}, false
+		}
+		return parquetColumn{index: leaf.ColumnIndex, logical: leaf.Node.Type().LogicalType()}, true
+	}
+
+	dateCol, okDate := lookup(dateColumns)
+	tickerCol, okTicker := lookup(tickerColumns)
+	closeCol, okClose := lookup(closeColumns)
+	if !okDate || !okTicker || !okClose {
+		return nil, fmt.Errorf("parquet file must have date, ticker and close columns, got %v", header)
+	}
+
+	history := backtest.NewPriceHistory()
+	reader := parquet.NewReader(pf)
+	defer reader.Close()
+
+	rows := make([]parquet.Row, parquetBatchSize)
+	rowNumber := 0
+	for {
+		n, err := reader.ReadRows(rows)
+		for _, row := range rows[:n] {
+			rowNumber++
+
+			var date time.Time
+			var ticker string
+			var price float64
+			var hasDate, hasTicker, hasPrice bool
+
+			for _, value := range row {
+				if value.IsNull() {
+					continue
+				}
+				switch value.Column() {
+				case dateCol.index:
+					d, err := parquetDate(value, dateCol.logical)
+					if err != nil {
+						return nil, fmt.Errorf("parquet row %d: %w", rowNumber, err)
+					}
+					date, hasDate = d, true
+				case tickerCol.index:
+					ticker, hasTicker = string(value.ByteArray()), true
+				case closeCol.index:
+					p, err := parquetClose(value)
+					if err != nil {
+						return nil, fmt.Errorf("parquet row %d: %w", rowNumber, err)
+					}
+					price, hasPrice = p, true
+				}
+			}
+
+			// Igual que en CSV, las filas sin precio se omiten
+			if !hasPrice {
+				continue
+			}
+			if !hasDate || !hasTicker {
+				return nil, fmt.Errorf("parquet row %d: missing date or ticker", rowNumber)
+			}
+			history.Add(ticker, date, price)
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading parquet rows: %w", err)
+		}
+	}
+
+	return history, nil
+}
+
+// parquetDate convierte una fecha según su tipo físico y lógico
+func parquetDate(value parquet.Value, logical *format.LogicalType) (time.Time, error) {
+	switch value.Kind() {
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return parseDate(string(value.ByteArray()))
+	case parquet.Int32:
+		// DATE: días desde la época Unix
+		return time.Unix(0, 0).UTC().AddDate(0, 0, int(value.Int32())), nil
+	case parquet.Int64:
+		v := value.Int64()
+		if logical != nil && logical.Timestamp != nil {
+			switch {
+			case logical.Timestamp.Unit.Nanos != nil:
+				return time.Unix(0, v).UTC(), nil
+			case logical.Timestamp.Unit.Micros != nil:
+				return time.UnixMicro(v).UTC(), nil
+			}
+		}
+		return time.UnixMilli(v).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported date type %s", value.Kind())
+}
+
+// parquetClose convierte un precio de cierre numérico o de texto
+func parquetClose(value parquet.Value) (float64, error) {
+	var price float64
+	switch value.Kind() {
+	case parquet.Double:
+		price = value.Double()
+	case parquet.Float:
+		price = float64(value.Float())
+	case parquet.Int32:
+		price = float64(value.Int32())
+	case parquet.Int64:
+		price = float64(value.Int64())
+	case parquet.ByteArray:
+		return parseClose(string(value.ByteArray()))
+	default:
+		return 0, fmt.Errorf("unsupported close type %s", value.Kind())
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("invalid close price %s", strconv.FormatFloat(price, 'f', -1, 64))
+	}
+	return price, nil
+}
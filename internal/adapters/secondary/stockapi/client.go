@@ -1,6 +1,7 @@
 package stockapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,24 +23,44 @@ type APIResponse struct {
 	NextPage string         `json:"next_page"`
 }
 
-// Representa un error de la API
-type APIError struct {
-	StatusCode int
-	Body       string
-	URL        string
+type Client struct {
+	httpClient  *http.Client
+	baseURL     string
+	authToken   string
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
 }
 
-func (e *APIError) Error() string {
-	return fmt.Sprintf("API returned status %d for URL %s: %s", e.StatusCode, e.URL, e.Body)
+// Option personaliza un Client creado con NewClient
+type Option func(*Client)
+
+// WithRetryPolicy reemplaza la política de reintentos por defecto
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
 }
 
-type Client struct {
-	httpClient *http.Client
-	baseURL    string
-	authToken  string
+// WithRateLimit limita las solicitudes a la API externa. Un valor de
+// requestsPerSecond menor o igual a 0 desactiva el límite
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newRateLimiter(requestsPerSecond, burst)
+	}
+}
+
+// WithHTTPClient reemplaza el cliente HTTP usado para las solicitudes
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
 }
 
-func NewClient() *Client {
+func NewClient(opts ...Option) *Client {
 	baseURL := os.Getenv("STOCK_API_BASE_URL")
 	if baseURL == "" {
 		baseURL = defaultBaseURL
@@ -50,18 +71,26 @@ func NewClient() *Client {
 		authToken = defaultAuthToken
 	}
 
-	return &Client{
+	c := &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		baseURL:   baseURL,
-		authToken: authToken,
+		baseURL:     baseURL,
+		authToken:   authToken,
+		retryPolicy: DefaultRetryPolicy(),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
-func (c *Client) FetchStocks(nextPage string) ([]models.Stock, string, error) {
+// FetchStocks obtiene una página de stocks. La solicitud se cancela junto con el contexto
+func (c *Client) FetchStocks(ctx context.Context, nextPage string) ([]models.Stock, string, error) {
 	if c.authToken == "" {
-		return nil, "", fmt.Errorf("no se ha configurado el token de autenticación (STOCK_API_AUTH_TOKEN)")
+		return nil, "", ErrMissingToken
 	}
 
 	// Parámetros de paginación
@@ -72,7 +101,13 @@ func (c *Client) FetchStocks(nextPage string) ([]models.Stock, string, error) {
 		reqURL = fmt.Sprintf("%s?%s", c.baseURL, params.Encode())
 	}
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, "", fmt.Errorf("error creating request: %w", err)
 	}
@@ -83,61 +118,79 @@ func (c *Client) FetchStocks(nextPage string) ([]models.Stock, string, error) {
 	// Realizar la solicitud
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("error making request: %w", err)
+		return nil, "", &TransportError{URL: reqURL, Err: err}
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, "", fmt.Errorf("error reading response body: %w", err)
+		return nil, "", &TransportError{URL: reqURL, Err: err}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusGone {
-			return nil, "", fmt.Errorf("API resource is no longer available (410 Gone). The API endpoint might have been deprecated or moved")
-		}
-
-		return nil, "", &APIError{
+		apiErr := &APIError{
 			StatusCode: resp.StatusCode,
 			Body:       string(bodyBytes),
 			URL:        reqURL,
 		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+
+		return nil, "", apiErr
 	}
 
 	var apiResp APIResponse
 	if err := json.Unmarshal(bodyBytes, &apiResp); err != nil {
-		return nil, "", fmt.Errorf("error decoding response: %w", err)
+		return nil, "", &DecodeError{URL: reqURL, Err: err}
 	}
 
 	return apiResp.Items, apiResp.NextPage, nil
 }
 
-// Recuperamos todos los stocks paginando
-func (c *Client) FetchAllStocks() ([]models.Stock, error) {
-	var allStocks []models.Stock
+// FetchPages recorre todas las páginas de la API entregando cada una al handler.
+// Los errores transitorios se reintentan según la política de reintentos del cliente.
+// Si el handler retorna un error la paginación se detiene y se retorna ese error
+func (c *Client) FetchPages(ctx context.Context, handle func(stocks []models.Stock, nextPage string) error) error {
+	policy := c.retryPolicy
+	if policy.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Deadline)
+		defer cancel()
+	}
+
 	nextPage := ""
-	maxRetries := 3
 	retryCount := 0
 
 	for {
-		stocks, newNextPage, err := c.FetchStocks(nextPage)
+		stocks, newNextPage, err := c.FetchStocks(ctx, nextPage)
 		if err != nil {
-			if err.Error() == "API resource is no longer available (410 Gone). The API endpoint might have been deprecated or moved" {
-				return nil, err
+			if !IsRetryable(err) || retryCount >= policy.MaxRetries {
+				return err
 			}
 
 			retryCount++
-			if retryCount <= maxRetries {
-				time.Sleep(2 * time.Second)
-				continue
+			wait := policy.delay(retryCount, err)
+
+			// No esperar si el reintento ocurriría después del plazo total
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+				return err
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
 			}
-			return nil, err
+			continue
 		}
 
 		retryCount = 0
 
-		if len(stocks) > 0 {
-			allStocks = append(allStocks, stocks...)
+		if err := handle(stocks, newNextPage); err != nil {
+			return err
 		}
 
 		if newNextPage == "" {
@@ -147,5 +200,20 @@ func (c *Client) FetchAllStocks() ([]models.Stock, error) {
 		nextPage = newNextPage
 	}
 
+	return nil
+}
+
+// Recuperamos todos los stocks paginando
+func (c *Client) FetchAllStocks(ctx context.Context) ([]models.Stock, error) {
+	var allStocks []models.Stock
+
+	err := c.FetchPages(ctx, func(stocks []models.Stock, _ string) error {
+		allStocks = append(allStocks, stocks...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return allStocks, nil
 }
@@ -0,0 +1,102 @@
+package stockapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// Errores que pueden verificarse con errors.Is sobre los errores del cliente
+var (
+	// ErrMissingToken indica que no se configuró STOCK_API_AUTH_TOKEN
+	ErrMissingToken = models.NewError(models.ErrMisconfigured, "stockapi: auth token is not configured (STOCK_API_AUTH_TOKEN)")
+
+	// ErrGone indica que el recurso ya no está disponible (410 Gone)
+	ErrGone = errors.New("stockapi: resource is no longer available")
+
+	// ErrUnauthorized indica que el token fue rechazado (401 o 403)
+	ErrUnauthorized = errors.New("stockapi: unauthorized")
+
+	// ErrRateLimited indica que la API limitó la tasa de solicitudes (429)
+	ErrRateLimited = errors.New("stockapi: rate limited")
+
+	// ErrDecode indica que la respuesta no pudo decodificarse
+	ErrDecode = errors.New("stockapi: decode failure")
+)
+
+// Representa un error de la API
+type APIError struct {
+	StatusCode int
+	Body       string
+	URL        string
+
+	// RetryAfter es la espera indicada por la cabecera Retry-After en respuestas 429 y 503
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d for URL %s: %s", e.StatusCode, e.URL, e.Body)
+}
+
+// Is permite comparar el error con los errores sentinela según el código de estado.
+// Solo los 5xx y 429 indican que la API no está disponible; un token rechazado es un
+// error de configuración y un 404 se reporta como recurso inexistente
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case models.ErrUnavailable:
+		return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+	case models.ErrMisconfigured:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case models.ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrGone:
+		return e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// TransportError representa una solicitud que no obtuvo respuesta de la API
+// (conexión rechazada, timeout, DNS) o cuya respuesta no pudo leerse
+type TransportError struct {
+	URL string
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("error requesting %s: %v", e.URL, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is permite verificar el error con errors.Is(err, models.ErrUnavailable)
+func (e *TransportError) Is(target error) bool {
+	return target == models.ErrUnavailable
+}
+
+// DecodeError representa una respuesta que no pudo decodificarse como JSON
+type DecodeError struct {
+	URL string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("error decoding response from %s: %v", e.URL, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is permite verificar el error con errors.Is(err, ErrDecode)
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode || target == models.ErrUnavailable
+}
@@ -0,0 +1,45 @@
+package stockapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestAPIErrorCategories(t *testing.T) {
+	tests := []struct {
+		status   int
+		category error
+	}{
+		{http.StatusInternalServerError, models.ErrUnavailable},
+		{http.StatusBadGateway, models.ErrUnavailable},
+		{http.StatusTooManyRequests, models.ErrUnavailable},
+		{http.StatusUnauthorized, models.ErrMisconfigured},
+		{http.StatusForbidden, models.ErrMisconfigured},
+		{http.StatusNotFound, models.ErrNotFound},
+		{http.StatusBadRequest, nil},
+	}
+
+	categories := []error{models.ErrUnavailable, models.ErrMisconfigured, models.ErrNotFound}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			err := fmt.Errorf("sync: %w", &APIError{StatusCode: tt.status})
+			for _, category := range categories {
+				if got := errors.Is(err, category); got != (category == tt.category) {
+					t.Errorf("errors.Is(%d, %v) = %t", tt.status, category, got)
+				}
+			}
+		})
+	}
+
+	if !errors.Is(&TransportError{Err: errors.New("refused")}, models.ErrUnavailable) {
+		t.Error("transport errors should be unavailable")
+	}
+	if !errors.Is(ErrMissingToken, models.ErrMisconfigured) {
+		t.Error("a missing token should be a configuration error")
+	}
+}
@@ -0,0 +1,175 @@
+package stockapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configura los reintentos de FetchPages ante errores transitorios
+type RetryPolicy struct {
+	// MaxRetries es el número máximo de reintentos consecutivos por página
+	MaxRetries int
+
+	// BaseDelay es la espera antes del primer reintento
+	BaseDelay time.Duration
+
+	// MaxDelay limita la espera calculada por el backoff exponencial
+	MaxDelay time.Duration
+
+	// Multiplier es el factor de crecimiento de la espera entre reintentos
+	Multiplier float64
+
+	// Jitter es la fracción (0 a 1) de la espera que se aleatoriza
+	Jitter float64
+
+	// Deadline es el tiempo máximo total de la paginación, 0 para no limitarlo
+	Deadline time.Duration
+}
+
+// DefaultRetryPolicy retorna la política de reintentos por defecto
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 5,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.5,
+		Deadline:   10 * time.Minute,
+	}
+}
+
+// backoff calcula la espera para el reintento número attempt (desde 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay = delay*(1-jitter) + delay*jitter*rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// delay retorna la espera antes del siguiente reintento, respetando Retry-After si la API lo envió
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	wait := p.backoff(attempt)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+	}
+
+	return wait
+}
+
+// IsRetryable indica si un error del cliente es transitorio y vale la pena reintentar
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrMissingToken) || errors.Is(err, ErrDecode) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Errores de red (conexión rechazada, timeouts, DNS) son transitorios
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseRetryAfter interpreta la cabecera Retry-After en segundos o como fecha HTTP
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// rateLimiter es un token bucket que limita las solicitudes hacia la API externa
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait bloquea hasta que haya un token disponible o se cancele el contexto
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package stockapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			if got := policy.backoff(tt.attempt); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, Multiplier: 2, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(2)
+		if got < time.Second || got > 2*time.Second {
+			t.Fatalf("backoff(2) = %v, want between 1s and 2s", got)
+		}
+	}
+}
+
+func TestDelayRespectsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, Multiplier: 2}
+
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{"no retry-after", &APIError{StatusCode: http.StatusServiceUnavailable}, 100 * time.Millisecond},
+		{"longer retry-after", &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}, 3 * time.Second},
+		{"shorter retry-after", &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}, 100 * time.Millisecond},
+		{"wrapped", fmt.Errorf("page 3: %w", &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second}), 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.delay(1, tt.err); got != tt.want {
+				t.Errorf("delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"missing token", ErrMissingToken, false},
+		{"decode", &DecodeError{URL: "u", Err: errors.New("bad json")}, false},
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"408", &APIError{StatusCode: http.StatusRequestTimeout}, true},
+		{"500", &APIError{StatusCode: http.StatusInternalServerError}, true},
+		{"503", &APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"400", &APIError{StatusCode: http.StatusBadRequest}, false},
+		{"401", &APIError{StatusCode: http.StatusUnauthorized}, false},
+		{"404", &APIError{StatusCode: http.StatusNotFound}, false},
+		{"network", &TransportError{URL: "u", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, true},
+		{"transport canceled", &TransportError{URL: "u", Err: context.Canceled}, false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestClient crea un cliente contra server con reintentos sin espera
+func newTestClient(t *testing.T, server *httptest.Server, maxRetries int) *Client {
+	t.Helper()
+	t.Setenv("STOCK_API_BASE_URL", server.URL)
+	t.Setenv("STOCK_API_AUTH_TOKEN", "token")
+
+	return NewClient(WithRetryPolicy(RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Millisecond,
+		Multiplier: 1,
+	}))
+}
+
+func TestFetchPagesRetriesTransientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Query().Get("next_page") == "" {
+			fmt.Fprint(w, `{"items":[{"ticker":"AAPL"}],"next_page":"2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"items":[{"ticker":"MSFT"}],"next_page":""}`)
+	}))
+	defer server.Close()
+
+	stocks, err := newTestClient(t, server, 3).FetchAllStocks(context.Background())
+	if err != nil {
+		t.Fatalf("FetchAllStocks() error: %v", err)
+	}
+	if len(stocks) != 2 || stocks[0].Ticker != "AAPL" || stocks[1].Ticker != "MSFT" {
+		t.Errorf("FetchAllStocks() = %v, want AAPL and MSFT", stocks)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("requests = %d, want 4", got)
+	}
+}
+
+func TestFetchPagesStopsOnPermanentErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server, 3).FetchAllStocks(context.Background())
+	if !errors.Is(err, ErrUnauthorized) || !errors.Is(err, models.ErrMisconfigured) {
+		t.Fatalf("FetchAllStocks() error = %v, want an unauthorized configuration error", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestFetchPagesGivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server, 2).FetchAllStocks(context.Background())
+	if !errors.Is(err, models.ErrUnavailable) {
+		t.Fatalf("FetchAllStocks() error = %v, want unavailable", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrAPIKeyNotFound se retorna cuando no existe una API key con el ID o hash solicitado
+var ErrAPIKeyNotFound = models.NewError(models.ErrNotFound, "api key not found")
+
+// APIKeyRepository persiste las API keys y sus hashes
+type APIKeyRepository interface {
+	// Registra una API key nueva
+	CreateAPIKey(ctx context.Context, key models.APIKey) error
+
+	// Obtiene una API key por el hash de la clave
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+
+	// Lista las API keys, las más recientes primero
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+
+	// Marca una API key como revocada en la fecha dada. Revocar una key ya revocada
+	// no cambia su fecha de revocación
+	RevokeAPIKey(ctx context.Context, id string, at time.Time) (models.APIKey, error)
+
+	// Registra el último uso de una API key
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+
+	// Suma una solicitud al uso de una API key en el día dado (YYYY-MM-DD) y
+	// retorna el total del día, incluida esta solicitud
+	IncrementAPIKeyUsage(ctx context.Context, id, day string) (int, error)
+}
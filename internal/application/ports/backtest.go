@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrBacktestNotFound se retorna cuando no existe un backtest con el ID solicitado
+var ErrBacktestNotFound = models.NewError(models.ErrNotFound, "backtest not found")
+
+// ErrPricesUnavailable se retorna cuando no hay un historial de precios configurado
+var ErrPricesUnavailable = models.NewError(models.ErrUnavailable, "price history unavailable")
+
+// BacktestRepository persiste los resultados de los backtests
+type BacktestRepository interface {
+	// Guarda un backtest ejecutado
+	SaveBacktest(ctx context.Context, report backtest.Report) error
+
+	// Obtiene un backtest por su ID
+	GetBacktest(ctx context.Context, id string) (backtest.Report, error)
+
+	// Lista los backtests más recientes primero
+	ListBacktests(ctx context.Context, limit int) ([]backtest.Report, error)
+}
+
+// PriceSource carga el historial de precios de cierre diarios usado por los backtests
+type PriceSource interface {
+	LoadPrices(ctx context.Context) (*backtest.PriceHistory, error)
+
+	// Describe el origen de los precios, por ejemplo la ruta del archivo
+	String() string
+}
@@ -0,0 +1,19 @@
+package ports
+
+// Cache guarda resultados ya calculados para reutilizarlos entre solicitudes.
+// Los valores se comparten entre quienes los leen, por lo que no deben modificarse
+type Cache interface {
+	// Obtiene un valor vigente. El booleano es false si no existe o expiró
+	Get(key string) (interface{}, bool)
+
+	// Identifica el contenido del cache; cada Purge la incrementa
+	Generation() uint64
+
+	// Guarda un valor calculado en la generación indicada, leída antes de calcularlo,
+	// reemplazando el anterior de la misma clave. Si el cache se vació desde entonces
+	// el valor se descarta, ya que pudo calcularse con datos anteriores
+	Set(key string, value interface{}, generation uint64)
+
+	// Descarta todos los valores, por ejemplo cuando cambian los datos
+	Purge()
+}
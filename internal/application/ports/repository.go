@@ -2,23 +2,66 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
 )
 
-type StockRepository interface {
-	// Guarda un stock en la base de datos
-	SaveStock(ctx context.Context, stock models.Stock) error
+// ErrStockNotFound se retorna cuando no existe un stock con el ticker solicitado
+var ErrStockNotFound = models.NewError(models.ErrNotFound, "stock not found")
 
+// StockRepository define las operaciones de persistencia que usan los handlers y servicios.
+// Las consultas AsOf solo consideran los eventos publicados y también ingeridos hasta
+// asOf, de modo que su resultado no cambia al ingerir después eventos más antiguos
+type StockRepository interface {
 	// Guarda múltiples stocks en la base de datos
-	SaveStocks(ctx context.Context, stocks []models.Stock) error
+	SaveStocks(ctx context.Context, stocks []models.Stock) (models.SaveResult, error)
+
+	// Obtiene stocks que cumplen todos los filtros, con paginación y ordenamiento
+	GetStocks(ctx context.Context, filter models.StockFilter, orderBy string, sortOrder string, offset, limit int) ([]models.Stock, error)
+
+	// Obtiene la página de stocks posterior (o anterior) al cursor, paginando por
+	// la columna de ordenamiento y el ticker en lugar de OFFSET
+	GetStocksByCursor(ctx context.Context, filter models.StockFilter, cursor models.StockCursor, limit int) ([]models.Stock, error)
+
+	// Recorre todos los stocks que cumplen los filtros en el orden indicado, llamando a
+	// fn con cada uno a medida que se leen. Si fn retorna un error el recorrido se
+	// detiene y se retorna ese error
+	StreamStocks(ctx context.Context, filter models.StockFilter, orderBy string, sortOrder string, fn func(models.Stock) error) error
+
+	// Cuenta el total de stocks que cumplen los filtros
+	CountStocks(ctx context.Context, filter models.StockFilter) (int, error)
 
 	// Obtiene un stock por su ticker
 	GetStockByTicker(ctx context.Context, ticker string) (models.Stock, error)
 
-	// Obtiene todos los stocks
-	GetAllStocks(ctx context.Context) ([]models.Stock, error)
+	// Obtiene el estado de un stock en una fecha: su evento de rating más reciente
+	// publicado e ingerido hasta asOf
+	GetStockByTickerAsOf(ctx context.Context, ticker string, asOf time.Time) (models.Stock, error)
+
+	// Obtiene el historial de eventos de rating de un ticker
+	GetStockHistory(ctx context.Context, ticker string) ([]models.Stock, error)
+
+	// Obtiene los eventos de rating de un ticker dentro de un rango de fechas
+	GetStockHistoryByDateRange(ctx context.Context, ticker string, startDate, endDate time.Time) ([]models.Stock, error)
+
+	// Obtiene los eventos de rating de un ticker publicados entre startDate y asOf
+	// que ya estaban ingeridos en asOf
+	GetStockHistoryAsOf(ctx context.Context, ticker string, startDate, asOf time.Time) ([]models.Stock, error)
+
+	// Obtiene stocks actualizados dentro de un rango de fechas
+	GetStocksByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.Stock, error)
+
+	// Obtiene los eventos de rating de todas las casas de bolsa dentro de un rango de fechas
+	GetRatingEventsByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.Stock, error)
+
+	// Obtiene los eventos de rating de todas las casas de bolsa publicados entre
+	// startDate y asOf que ya estaban ingeridos en asOf
+	GetRatingEventsAsOf(ctx context.Context, startDate, asOf time.Time) ([]models.Stock, error)
+
+	// Lista las calificaciones del historial que no corresponden a ninguna categoría de la taxonomía
+	GetUnmappedRatings(ctx context.Context) ([]models.UnmappedRating, error)
 
-	// Obtiene stocks filtrados por casa de bolsa
-	GetStocksByBrokerage(ctx context.Context, brokerage string) ([]models.Stock, error)
+	// Verifica la conexión con el almacenamiento
+	Ping(ctx context.Context) error
 }
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// ErrSnapshotNotFound se retorna cuando no existe un snapshot para la fecha solicitada
+var ErrSnapshotNotFound = models.NewError(models.ErrNotFound, "recommendation snapshot not found")
+
+// SnapshotRepository persiste los snapshots diarios de recomendaciones
+type SnapshotRepository interface {
+	// Guarda un snapshot, reemplazando el del mismo día y estrategia
+	SaveSnapshot(ctx context.Context, snapshot recommendation.Snapshot) error
+
+	// Obtiene el snapshot de un día (YYYY-MM-DD) y estrategia
+	GetSnapshot(ctx context.Context, date, strategy string) (recommendation.Snapshot, error)
+
+	// Obtiene el snapshot más reciente de una estrategia
+	GetLatestSnapshot(ctx context.Context, strategy string) (recommendation.Snapshot, error)
+}
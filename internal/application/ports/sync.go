@@ -0,0 +1,51 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrSyncJobNotFound se retorna cuando no existe un trabajo de sincronización con el ID solicitado
+var ErrSyncJobNotFound = models.NewError(models.ErrNotFound, "sync job not found")
+
+// SyncJobRepository persiste el estado de los trabajos de sincronización
+type SyncJobRepository interface {
+	// Registra un trabajo nuevo
+	CreateSyncJob(ctx context.Context, job models.SyncJob) error
+
+	// Actualiza el estado y los contadores de un trabajo existente
+	UpdateSyncJob(ctx context.Context, job models.SyncJob) error
+
+	// Obtiene un trabajo por su ID
+	GetSyncJob(ctx context.Context, id string) (models.SyncJob, error)
+
+	// Lista los trabajos más recientes primero
+	ListSyncJobs(ctx context.Context, limit int) ([]models.SyncJob, error)
+
+	// Obtiene el checkpoint de la última sincronización exitosa. El booleano es
+	// false si todavía no existe ninguno
+	GetSyncCheckpoint(ctx context.Context) (models.SyncCheckpoint, bool, error)
+
+	// Guarda el checkpoint de sincronización
+	SaveSyncCheckpoint(ctx context.Context, checkpoint models.SyncCheckpoint) error
+
+	// Toma el lease de sincronización para el trabajo por ttl si no hay otro vigente.
+	// El lease es compartido entre réplicas, de modo que una sola sincronización
+	// recorre la API externa a la vez. La vigencia se calcula con el reloj del
+	// repositorio y no con el de cada réplica. Si lo tiene otro trabajo retorna su
+	// ID y false
+	AcquireSyncLease(ctx context.Context, jobID string, ttl time.Duration) (string, bool, error)
+
+	// Extiende el lease del trabajo por ttl. Retorna false si ya no le pertenece
+	RenewSyncLease(ctx context.Context, jobID string, ttl time.Duration) (bool, error)
+
+	// Libera el lease si pertenece al trabajo
+	ReleaseSyncLease(ctx context.Context, jobID string) error
+}
+
+// StockProvider obtiene stocks desde la fuente externa página por página
+type StockProvider interface {
+	FetchPages(ctx context.Context, handle func(stocks []models.Stock, nextPage string) error) error
+}
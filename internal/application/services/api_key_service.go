@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrInvalidAPIKey se retorna cuando la clave no existe o fue revocada
+var ErrInvalidAPIKey = models.NewError(models.ErrUnauthenticated, "invalid api key")
+
+// ErrQuotaExceeded se retorna cuando una API key agotó su cuota diaria
+var ErrQuotaExceeded = models.NewError(models.ErrRateLimited, "daily quota exceeded")
+
+// ErrInvalidAPIKeyParams se retorna cuando el nombre o el rol de una API key nueva no son válidos
+var ErrInvalidAPIKeyParams = models.NewError(models.ErrInvalidArgument, "invalid api key parameters")
+
+const (
+	// apiKeyPrefix identifica las claves de esta API, por ejemplo en un escaneo de secretos
+	apiKeyPrefix = "sia_"
+
+	// apiKeyVisibleChars es la cantidad de caracteres de la clave que se guardan en claro
+	apiKeyVisibleChars = len(apiKeyPrefix) + 8
+
+	// minBootstrapKeyLength es el largo mínimo de la clave de administración inicial
+	minBootstrapKeyLength = 32
+
+	// maxAPIKeyNameLength es el largo máximo del nombre descriptivo de una API key
+	maxAPIKeyNameLength = 100
+
+	// lastUsedResolution evita escribir en la base de datos en cada solicitud
+	lastUsedResolution = time.Minute
+)
+
+// APIKeyService crea, valida y revoca las API keys. Las claves se generan al azar
+// con 256 bits de entropía, por lo que basta un SHA-256 para guardarlas
+type APIKeyService struct {
+	repo ports.APIKeyRepository
+	now  func() time.Time
+
+	// defaultDailyQuota aplica a las keys sin cuota propia; 0 las deja sin cuota
+	defaultDailyQuota int
+}
+
+// QuotaStatus describe el uso de la cuota diaria de una API key. Limit es 0 si
+// la key no tiene cuota
+type QuotaStatus struct {
+	Limit   int
+	Used    int
+	ResetAt time.Time
+}
+
+// Remaining retorna las solicitudes que quedan en el día
+func (q QuotaStatus) Remaining() int {
+	if q.Used >= q.Limit {
+		return 0
+	}
+	return q.Limit - q.Used
+}
+
+// NewAPIKeyService crea una nueva instancia del servicio de API keys
+func NewAPIKeyService(repo ports.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SetDefaultDailyQuota define la cuota diaria de las keys sin cuota propia
+func (s *APIKeyService) SetDefaultDailyQuota(quota int) {
+	s.defaultDailyQuota = quota
+}
+
+// Create genera una API key nueva. La clave en claro solo se retorna aquí,
+// después solo se conoce su hash. dailyQuota 0 usa la cuota por defecto
+func (s *APIKeyService) Create(ctx context.Context, name string, role string, dailyQuota int) (models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return models.APIKey{}, "", fmt.Errorf("%w: name is required and must have at most %d characters", ErrInvalidAPIKeyParams, maxAPIKeyNameLength)
+	}
+
+	if dailyQuota < 0 {
+		return models.APIKey{}, "", fmt.Errorf("%w: daily_quota must not be negative", ErrInvalidAPIKeyParams)
+	}
+
+	parsedRole, err := models.ParseRole(role)
+	if err != nil {
+		return models.APIKey{}, "", fmt.Errorf("%w: %v", ErrInvalidAPIKeyParams, err)
+	}
+
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	key, err := s.create(ctx, name, secret, parsedRole, dailyQuota)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	return key, secret, nil
+}
+
+// EnsureKey registra una clave conocida, como la de administración inicial
+// configurada por variable de entorno, si todavía no existe
+func (s *APIKeyService) EnsureKey(ctx context.Context, name, secret string, role models.Role) error {
+	if len(secret) < minBootstrapKeyLength {
+		return fmt.Errorf("%w: key must have at least %d characters", ErrInvalidAPIKeyParams, minBootstrapKeyLength)
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if err == nil {
+		if key.Revoked() {
+			log.Printf("La API key %s (%s) está revocada y no se vuelve a habilitar", key.Name, key.Prefix)
+		}
+		return nil
+	}
+	if !errors.Is(err, ports.ErrAPIKeyNotFound) {
+		return err
+	}
+
+	_, err = s.create(ctx, name, secret, role, 0)
+	return err
+}
+
+// Authenticate retorna la API key correspondiente a la clave en claro.
+// Las claves desconocidas o revocadas retornan ErrInvalidAPIKey
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (models.APIKey, error) {
+	if secret == "" {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, ports.ErrAPIKeyNotFound) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	if key.Revoked() {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	// El último uso es informativo: un error al guardarlo no rechaza la solicitud
+	now := s.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			log.Printf("Error al registrar el uso de la API key %s: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
+
+// List retorna las API keys registradas, sin sus hashes
+func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	return keys, nil
+}
+
+// Revoke revoca una API key. Las solicitudes con esa clave se rechazan desde ese momento
+func (s *APIKeyService) Revoke(ctx context.Context, id string) (models.APIKey, error) {
+	return s.repo.RevokeAPIKey(ctx, id, s.now())
+}
+
+// ConsumeQuota cuenta una solicitud de la key en el día UTC actual. Retorna
+// ErrQuotaExceeded si con ella se supera la cuota diaria
+func (s *APIKeyService) ConsumeQuota(ctx context.Context, key models.APIKey) (QuotaStatus, error) {
+	limit := key.DailyQuota
+	if limit == 0 {
+		limit = s.defaultDailyQuota
+	}
+	if limit <= 0 {
+		return QuotaStatus{}, nil
+	}
+
+	now := s.now()
+	day := now.Format("2006-01-02")
+	status := QuotaStatus{
+		Limit:   limit,
+		ResetAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+
+	used, err := s.repo.IncrementAPIKeyUsage(ctx, key.ID, day)
+	if err != nil {
+		return status, err
+	}
+	status.Used = used
+
+	if used > limit {
+		return status, ErrQuotaExceeded
+	}
+
+	return status, nil
+}
+
+// create guarda una API key con la clave en claro dada
+func (s *APIKeyService) create(ctx context.Context, name, secret string, role models.Role, dailyQuota int) (models.APIKey, error) {
+	id, err := newAPIKeyID()
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	key := models.APIKey{
+		ID:         id,
+		Name:       name,
+		Prefix:     visiblePrefix(secret),
+		Hash:       hashAPIKey(secret),
+		Role:       role,
+		DailyQuota: dailyQuota,
+		CreatedAt:  s.now(),
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return models.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// hashAPIKey retorna el hash con el que se guarda y se busca una clave
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// visiblePrefix retorna los primeros caracteres de la clave, que se guardan en claro.
+// De las claves elegidas por el operador, como API_ADMIN_KEY, se muestran menos
+func visiblePrefix(secret string) string {
+	if strings.HasPrefix(secret, apiKeyPrefix) && len(secret) > apiKeyVisibleChars {
+		return secret[:apiKeyVisibleChars]
+	}
+	return secret[:4]
+}
+
+// newAPIKeySecret genera una clave aleatoria de 256 bits
+func newAPIKeySecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating api key: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newAPIKeyID genera el identificador público de una API key
+func newAPIKeyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating api key id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+)
+
+// BacktestService ejecuta backtests de las estrategias de recomendación sobre
+// los eventos almacenados y un historial de precios local
+type BacktestService struct {
+	repo            ports.StockRepository
+	results         ports.BacktestRepository
+	recommendations *RecommendationService
+	prices          ports.PriceSource
+}
+
+// NewBacktestService crea una nueva instancia del servicio de backtests.
+// prices puede ser nil si no hay un archivo de precios configurado
+func NewBacktestService(repo ports.StockRepository, results ports.BacktestRepository, recommendations *RecommendationService, prices ports.PriceSource) *BacktestService {
+	return &BacktestService{
+		repo:            repo,
+		results:         results,
+		recommendations: recommendations,
+		prices:          prices,
+	}
+}
+
+// Run ejecuta un backtest y guarda su resultado
+func (s *BacktestService) Run(ctx context.Context, params backtest.Params) (backtest.Report, error) {
+	params = params.WithDefaults()
+	if err := params.Validate(); err != nil {
+		return backtest.Report{}, err
+	}
+
+	strategy, err := s.recommendations.Strategy(params.Strategy)
+	if err != nil {
+		return backtest.Report{}, fmt.Errorf("%w: %v", backtest.ErrInvalidParams, err)
+	}
+
+	if s.prices == nil {
+		return backtest.Report{}, ports.ErrPricesUnavailable
+	}
+
+	started := time.Now()
+
+	prices, err := s.prices.LoadPrices(ctx)
+	if err != nil {
+		return backtest.Report{}, err
+	}
+
+	// Eventos desde el inicio de la ventana del primer día hasta el cierre del último
+	from := params.From.AddDate(0, 0, -params.LookbackDays)
+	to := params.To.AddDate(0, 0, 1)
+	events, err := s.repo.GetRatingEventsByDateRange(ctx, from, to)
+	if err != nil {
+		return backtest.Report{}, err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return backtest.Report{}, err
+	}
+
+	report := backtest.Report{
+		ID:           id,
+		Params:       params,
+		PricesSource: s.prices.String(),
+		Result:       backtest.Run(strategy, events, prices, params),
+		CreatedAt:    started,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+
+	if err := s.results.SaveBacktest(ctx, report); err != nil {
+		return backtest.Report{}, err
+	}
+
+	return report, nil
+}
+
+// Get obtiene un backtest guardado
+func (s *BacktestService) Get(ctx context.Context, id string) (backtest.Report, error) {
+	return s.results.GetBacktest(ctx, id)
+}
+
+// List lista los backtests más recientes
+func (s *BacktestService) List(ctx context.Context, limit int) ([]backtest.Report, error) {
+	return s.results.ListBacktests(ctx, limit)
+}
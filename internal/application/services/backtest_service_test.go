@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/backtest"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// fakePriceSource entrega un historial de precios fijo
+type fakePriceSource struct {
+	prices *backtest.PriceHistory
+	err    error
+}
+
+func (s *fakePriceSource) LoadPrices(ctx context.Context) (*backtest.PriceHistory, error) {
+	return s.prices, s.err
+}
+
+func (s *fakePriceSource) String() string { return "fake" }
+
+func TestBacktestServiceRun(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	history := backtest.NewPriceHistory()
+	for d := 1; d <= 10; d++ {
+		history.Add("AAPL", day(d), 100+float64(d))
+	}
+
+	repo := memory.NewStockRepository()
+	event := rating("AAPL", "Barclays", "Hold", "Buy", "$100", "$120", day(2))
+	event.ParseTargets()
+	event.ClassifyRatings(models.DefaultRatingTaxonomy())
+	if _, err := repo.SaveStocks(context.Background(), []models.Stock{event}); err != nil {
+		t.Fatalf("SaveStocks() error: %v", err)
+	}
+	recommendations := NewRecommendationService(repo)
+
+	params := backtest.Params{From: day(1), To: day(3), Horizons: []int{1}}
+
+	tests := []struct {
+		name    string
+		prices  ports.PriceSource
+		params  backtest.Params
+		picks   int
+		wantErr error
+	}{
+		{"runs over the stored events", &fakePriceSource{prices: history}, params, 2, nil},
+		{"unknown strategy", &fakePriceSource{prices: history}, backtest.Params{Strategy: "magic", From: day(1), To: day(3)}, 0, backtest.ErrInvalidParams},
+		{"invalid params", &fakePriceSource{prices: history}, backtest.Params{From: day(3), To: day(1)}, 0, backtest.ErrInvalidParams},
+		{"no price file", nil, params, 0, ports.ErrPricesUnavailable},
+		{"price file error", &fakePriceSource{err: ports.ErrPricesUnavailable}, params, 0, ports.ErrPricesUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := memory.NewBacktestRepository()
+			s := NewBacktestService(repo, results, recommendations, tt.prices)
+
+			report, err := s.Run(context.Background(), tt.params)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+				}
+				if saved, _ := s.List(context.Background(), 10); len(saved) != 0 {
+					t.Errorf("a failed backtest was saved: %+v", saved)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+
+			if report.ID == "" || report.PricesSource != "fake" || report.Result.Picks != tt.picks {
+				t.Errorf("report = %+v, want an id, the fake source and %d picks", report, tt.picks)
+			}
+			saved, err := s.Get(context.Background(), report.ID)
+			if err != nil || saved.Result.Picks != report.Result.Picks {
+				t.Errorf("Get() = %+v, %v; want the saved report", saved, err)
+			}
+		})
+	}
+}
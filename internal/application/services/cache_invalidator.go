@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+)
+
+// CacheInvalidator vacía el cache de respuestas de la réplica cuando cambian los
+// datos, aunque la sincronización haya corrido en otra réplica. Cada sincronización
+// exitosa actualiza el checkpoint compartido en la base de datos, y la fecha de esa
+// actualización identifica la versión de los datos con la que se llenó el cache
+type CacheInvalidator struct {
+	jobs  ports.SyncJobRepository
+	cache ports.Cache
+
+	mu      sync.Mutex
+	version time.Time
+}
+
+// NewCacheInvalidator crea un invalidador del cache según el checkpoint de sincronización
+func NewCacheInvalidator(jobs ports.SyncJobRepository, cache ports.Cache) *CacheInvalidator {
+	return &CacheInvalidator{
+		jobs:  jobs,
+		cache: cache,
+	}
+}
+
+// Check lee el checkpoint de sincronización y vacía el cache si cambió desde la
+// última lectura
+func (i *CacheInvalidator) Check(ctx context.Context) error {
+	checkpoint, _, err := i.jobs.GetSyncCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !checkpoint.UpdatedAt.Equal(i.version) {
+		i.cache.Purge()
+		i.version = checkpoint.UpdatedAt
+	}
+	return nil
+}
+
+// Run revisa el checkpoint cada interval hasta que se cancele el contexto. El
+// interval acota cuánto tarda una réplica en dejar de servir datos anteriores a
+// una sincronización de otra réplica
+func (i *CacheInvalidator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.Check(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error al revisar el checkpoint de sincronización para invalidar el cache: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cache"
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// Repositorio de sync jobs cuyo checkpoint no se puede leer
+type failingCheckpointRepository struct {
+	ports.SyncJobRepository
+}
+
+func (failingCheckpointRepository) GetSyncCheckpoint(ctx context.Context) (models.SyncCheckpoint, bool, error) {
+	return models.SyncCheckpoint{}, false, errors.New("database unavailable")
+}
+
+func TestCacheInvalidator(t *testing.T) {
+	ctx := context.Background()
+	jobs := memory.NewSyncJobRepository()
+	responses := cache.NewLRU(10, time.Hour)
+	invalidator := NewCacheInvalidator(jobs, responses)
+
+	// Cada paso guarda una respuesta, opcionalmente simula una sincronización de
+	// otra réplica y revisa el checkpoint
+	tests := []struct {
+		name       string
+		checkpoint time.Time
+		wantCached bool
+	}{
+		{"no checkpoint yet", time.Time{}, true},
+		{"sync on another replica", testNow, false},
+		{"unchanged checkpoint", time.Time{}, true},
+		{"newer sync", testNow.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses.Set("recommendations", "cached", responses.Generation())
+			if !tt.checkpoint.IsZero() {
+				if err := jobs.SaveSyncCheckpoint(ctx, models.SyncCheckpoint{NewestEventTime: tt.checkpoint, UpdatedAt: tt.checkpoint}); err != nil {
+					t.Fatalf("SaveSyncCheckpoint() error: %v", err)
+				}
+			}
+
+			if err := invalidator.Check(ctx); err != nil {
+				t.Fatalf("Check() error: %v", err)
+			}
+			if _, cached := responses.Get("recommendations"); cached != tt.wantCached {
+				t.Errorf("cached = %t, want %t", cached, tt.wantCached)
+			}
+		})
+	}
+
+	// Sin poder leer el checkpoint el cache se conserva y el error se retorna
+	failing := NewCacheInvalidator(failingCheckpointRepository{}, responses)
+	responses.Set("recommendations", "cached", responses.Generation())
+	if err := failing.Check(ctx); err == nil {
+		t.Error("Check() error = nil, want the repository error")
+	}
+	if _, cached := responses.Get("recommendations"); !cached {
+		t.Error("a failed check purged the cache")
+	}
+}
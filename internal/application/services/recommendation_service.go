@@ -3,59 +3,406 @@ package services
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
-	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cockroachdb"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
 	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
 )
 
+// ErrInvalidRecommendationOptions se retorna cuando los parámetros de la solicitud
+// están fuera de los límites de la configuración de scoring
+var ErrInvalidRecommendationOptions = models.NewError(models.ErrInvalidArgument, "invalid recommendation options")
+
 // RecommendationService gestiona la generación de recomendaciones de stocks
 type RecommendationService struct {
-	repo        *cockroachdb.StockRepository
-	recommender *recommendation.StockRecommender
+	repo ports.StockRepository
+
+	// strategies se reemplaza completo al recargar la configuración de scoring
+	strategies atomic.Pointer[recommendation.Registry]
+	config     atomic.Pointer[recommendation.ScoringConfig]
+
+	// clock da la fecha de referencia cuando la solicitud no indica as_of
+	clock recommendation.Clock
+
+	// cache guarda las respuestas por parámetros; nil desactiva el cache
+	cache ports.Cache
 }
 
 // NewRecommendationService crea una nueva instancia del servicio de recomendaciones
-func NewRecommendationService(repo *cockroachdb.StockRepository) *RecommendationService {
-	return &RecommendationService{
-		repo:        repo,
-		recommender: recommendation.NewStockRecommender(),
+func NewRecommendationService(repo ports.StockRepository) *RecommendationService {
+	s := &RecommendationService{
+		repo:  repo,
+		clock: recommendation.SystemClock,
 	}
+	// La configuración por defecto siempre es válida
+	if err := s.SetScoringConfig(recommendation.DefaultScoringConfig()); err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// SetScoringConfig valida y aplica una nueva configuración de scoring. Las
+// solicitudes en curso terminan con la configuración anterior
+func (s *RecommendationService) SetScoringConfig(config recommendation.ScoringConfig) error {
+	registry, err := recommendation.NewRegistry(config)
+	if err != nil {
+		return err
+	}
+
+	s.config.Store(&config)
+	s.strategies.Store(registry)
+
+	// Las respuestas guardadas se calcularon con la configuración anterior
+	if s.cache != nil {
+		s.cache.Purge()
+	}
+	return nil
+}
+
+// SetClock reemplaza el reloj del servicio. Debe llamarse antes de atender solicitudes
+func (s *RecommendationService) SetClock(clock recommendation.Clock) {
+	s.clock = clock
+}
+
+// SetCache define el cache de respuestas. Debe llamarse antes de atender solicitudes
+func (s *RecommendationService) SetCache(cache ports.Cache) {
+	s.cache = cache
+}
+
+// ScoringConfig retorna la configuración de scoring vigente
+func (s *RecommendationService) ScoringConfig() recommendation.ScoringConfig {
+	return *s.config.Load()
+}
+
+// Strategies describe las estrategias de recomendación disponibles
+func (s *RecommendationService) Strategies() []recommendation.StrategyInfo {
+	return s.strategies.Load().List()
+}
+
+// Strategy obtiene una estrategia registrada por nombre, un nombre vacío es la
+// estrategia por defecto
+func (s *RecommendationService) Strategy(name string) (recommendation.Strategy, error) {
+	return s.strategies.Load().Get(name)
+}
+
+// RecommendationOptions permite ajustar una solicitud de recomendaciones.
+// Los valores nulos usan los de la configuración de scoring
+type RecommendationOptions struct {
+	Strategy     string
+	Limit        *int
+	LookbackDays *int
+	MinScore     *float64
+
+	// AsOf evalúa las recomendaciones en una fecha pasada, usando solo los eventos
+	// publicados e ingeridos hasta ese momento
+	AsOf *time.Time
+
+	// OmitRationale descarta la explicación en prosa, dejando solo la estructurada
+	OmitRationale bool
+
+	// Constraints diversifica el ranking por casa de bolsa, sector y empresa
+	Constraints recommendation.Constraints
 }
 
 // RecommendationResponse respuesta del servicio de recomendaciones
 type RecommendationResponse struct {
+	Strategy        string                                `json:"strategy"`
+	AsOf            time.Time                             `json:"as_of"`
 	Recommendations []recommendation.RecommendationResult `json:"recommendations"`
 	GeneratedAt     time.Time                             `json:"generated_at"`
 	Count           int                                   `json:"count"`
 	Message         string                                `json:"message"`
+
+	// Restricciones de diversificación aplicadas y candidatos descartados por ellas
+	Constraints *recommendation.Constraints       `json:"constraints,omitempty"`
+	Dropped     []recommendation.DroppedCandidate `json:"dropped,omitempty"`
+	Warnings    []string                          `json:"warnings,omitempty"`
 }
 
-// GetRecommendations genera recomendaciones de stocks
-func (s *RecommendationService) GetRecommendations(ctx context.Context) (*RecommendationResponse, error) {
-	// Obtiene stocks recientes para análisis (últimos 30 días)
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, -1, 0)
+// GetRecommendations genera recomendaciones de stocks, o retorna las guardadas en
+// el cache para los mismos parámetros
+func (s *RecommendationService) GetRecommendations(ctx context.Context, opts RecommendationOptions) (*RecommendationResponse, error) {
+	return s.getRecommendations(ctx, opts, true)
+}
 
-	stocks, err := s.repo.GetStocksByDateRange(ctx, startDate, endDate)
+// getRecommendations genera recomendaciones usando el cache solo si useCache es true
+func (s *RecommendationService) getRecommendations(ctx context.Context, opts RecommendationOptions, useCache bool) (*RecommendationResponse, error) {
+	req, err := s.resolveOptions(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	recommendationResults := s.recommender.GenerateRecommendations(stocks, 10)
+	generate := func() (*RecommendationResponse, error) {
+		ranking, err := s.rank(ctx, req, req.strategy.Recommend)
+		if err != nil {
+			return nil, err
+		}
+
+		return req.response(ranking, s.generateResponseMessage(len(ranking.results), req.past())), nil
+	}
+
+	if !useCache {
+		return generate()
+	}
+	return s.cached(req.cacheKey("recommendations"), generate)
+}
+
+// ExportRecommendations genera el ranking completo para exportarlo: sin opts.Limit
+// incluye todos los candidatos que alcanzan el score mínimo, en lugar del límite por
+// defecto. Un limit explícito conserva el máximo de la configuración de scoring.
+// No usa el cache
+func (s *RecommendationService) ExportRecommendations(ctx context.Context, opts RecommendationOptions) (*RecommendationResponse, error) {
+	req, err := s.resolveOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Limit == nil {
+		req.limit = 0
+	}
+
+	ranking, err := s.rank(ctx, req, req.strategy.Recommend)
+	if err != nil {
+		return nil, err
+	}
+
+	return req.response(ranking, s.generateResponseMessage(len(ranking.results), req.past())), nil
+}
+
+// GetAvoidList lista las acciones a evitar: rebajas de rating y recortes del precio
+// objetivo ordenados por severidad. Acepta las mismas opciones que GetRecommendations
+// y min_score se aplica sobre el score de severidad
+func (s *RecommendationService) GetAvoidList(ctx context.Context, opts RecommendationOptions) (*RecommendationResponse, error) {
+	req, err := s.resolveOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	avoider, ok := req.strategy.(recommendation.AvoidStrategy)
+	if !ok {
+		return nil, fmt.Errorf("%w: strategy %s does not support avoid lists", ErrInvalidRecommendationOptions, req.strategy.Name())
+	}
+
+	return s.cached(req.cacheKey("avoid"), func() (*RecommendationResponse, error) {
+		ranking, err := s.rank(ctx, req, avoider.Avoid)
+		if err != nil {
+			return nil, err
+		}
+
+		return req.response(ranking, generateAvoidMessage(len(ranking.results))), nil
+	})
+}
+
+// cached retorna una copia de la respuesta guardada con la clave dada o la genera
+// y la guarda. La generación se lee antes de calcular la respuesta, de modo que una
+// respuesta calculada mientras se vaciaba el cache, con la configuración o los datos
+// anteriores, no se guarda
+func (s *RecommendationService) cached(key string, generate func() (*RecommendationResponse, error)) (*RecommendationResponse, error) {
+	if s.cache == nil {
+		return generate()
+	}
+
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(*RecommendationResponse).clone(), nil
+	}
 
+	generation := s.cache.Generation()
+	response, err := generate()
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Set(key, response, generation)
+	return response.clone(), nil
+}
+
+// clone copia la respuesta para que quien la recibe pueda modificarla sin alterar
+// la guardada en el cache. Los parámetros de cada motivo se comparten
+func (r *RecommendationResponse) clone() *RecommendationResponse {
+	c := *r
+	c.Recommendations = slices.Clone(r.Recommendations)
+	for i, result := range c.Recommendations {
+		c.Recommendations[i].Reasons = slices.Clone(result.Reasons)
+		if result.Breakdown != nil {
+			breakdown := *result.Breakdown
+			breakdown.Components = slices.Clone(breakdown.Components)
+			c.Recommendations[i].Breakdown = &breakdown
+		}
+	}
+	if r.Constraints != nil {
+		constraints := *r.Constraints
+		c.Constraints = &constraints
+	}
+	c.Dropped = slices.Clone(r.Dropped)
+	c.Warnings = slices.Clone(r.Warnings)
+
+	return &c
+}
+
+// recommendationRequest es una solicitud con las opciones ya validadas
+type recommendationRequest struct {
+	strategy      recommendation.Strategy
+	limit         int
+	lookbackDays  int
+	minScore      float64
+	omitRationale bool
+	constraints   recommendation.Constraints
+	startDate     time.Time
+	endDate       time.Time
+	now           time.Time
+}
+
+// past indica si la solicitud evalúa una fecha anterior a la actual
+func (req recommendationRequest) past() bool {
+	return req.endDate.Before(req.now)
+}
+
+// cacheKey identifica la respuesta de la solicitud en el cache. Sin as_of la
+// ventana termina en la hora actual, que no forma parte de la clave: la respuesta
+// guardada vale hasta que expira o se invalida el cache
+func (req recommendationRequest) cacheKey(kind string) string {
+	asOf := "latest"
+	if req.past() {
+		asOf = req.endDate.UTC().Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprintf("%s:%s:limit=%d:lookback=%d:min_score=%g:omit_rationale=%t:brokerage=%d:sector=%d:dedupe=%t:as_of=%s",
+		kind, req.strategy.Name(), req.limit, req.lookbackDays, req.minScore, req.omitRationale,
+		req.constraints.MaxPerBrokerage, req.constraints.MaxPerSector, req.constraints.DedupeCompanies, asOf)
+}
+
+// ranking es el resultado de evaluar una solicitud
+type ranking struct {
+	results  []recommendation.RecommendationResult
+	dropped  []recommendation.DroppedCandidate
+	warnings []string
+}
+
+// response arma la respuesta con el ranking de la solicitud
+func (req recommendationRequest) response(ranking ranking, message string) *RecommendationResponse {
 	response := &RecommendationResponse{
-		Recommendations: recommendationResults,
-		GeneratedAt:     time.Now(),
-		Count:           len(recommendationResults),
-		Message:         s.generateResponseMessage(len(recommendationResults)),
+		Strategy:        req.strategy.Name(),
+		AsOf:            req.endDate,
+		Recommendations: ranking.results,
+		GeneratedAt:     req.now,
+		Count:           len(ranking.results),
+		Message:         message,
+		Dropped:         ranking.dropped,
+		Warnings:        ranking.warnings,
+	}
+	if !req.constraints.IsZero() {
+		constraints := req.constraints
+		response.Constraints = &constraints
+	}
+
+	return response
+}
+
+// resolveOptions valida las opciones contra la configuración de scoring vigente
+func (s *RecommendationService) resolveOptions(opts RecommendationOptions) (recommendationRequest, error) {
+	config := *s.config.Load()
+	req := recommendationRequest{omitRationale: opts.OmitRationale, constraints: opts.Constraints}
+
+	strategy, err := s.strategies.Load().Get(opts.Strategy)
+	if err != nil {
+		return req, fmt.Errorf("%w: %v", ErrInvalidRecommendationOptions, err)
+	}
+	req.strategy = strategy
+
+	req.limit = config.DefaultLimit
+	if opts.Limit != nil {
+		if *opts.Limit < 1 || *opts.Limit > config.MaxLimit {
+			return req, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRecommendationOptions, config.MaxLimit)
+		}
+		req.limit = *opts.Limit
+	}
+
+	req.lookbackDays = config.DefaultLookbackDays
+	if opts.LookbackDays != nil {
+		if *opts.LookbackDays < 1 || *opts.LookbackDays > config.MaxLookbackDays {
+			return req, fmt.Errorf("%w: lookback_days must be between 1 and %d", ErrInvalidRecommendationOptions, config.MaxLookbackDays)
+		}
+		req.lookbackDays = *opts.LookbackDays
+	}
+
+	req.minScore = config.MinScore
+	if opts.MinScore != nil {
+		if *opts.MinScore < 0 || *opts.MinScore > 100 {
+			return req, fmt.Errorf("%w: min_score must be between 0 and 100", ErrInvalidRecommendationOptions)
+		}
+		req.minScore = *opts.MinScore
+	}
+
+	if opts.Constraints.MaxPerBrokerage < 0 || opts.Constraints.MaxPerSector < 0 {
+		return req, fmt.Errorf("%w: max_per_brokerage and max_per_sector must not be negative", ErrInvalidRecommendationOptions)
 	}
 
-	return response, nil
+	// Un as_of futuro se limita a la hora actual
+	req.now = s.clock.Now()
+	req.endDate = req.now
+	if opts.AsOf != nil && opts.AsOf.Before(req.now) {
+		req.endDate = *opts.AsOf
+	}
+	req.startDate = req.endDate.AddDate(0, 0, -req.lookbackDays)
+
+	return req, nil
+}
+
+// rank obtiene los eventos de la ventana de la solicitud, los evalúa con rankFn y
+// aplica el score mínimo, las restricciones de diversificación y el límite
+func (s *RecommendationService) rank(ctx context.Context, req recommendationRequest, rankFn func(recommendation.Input, int) []recommendation.RecommendationResult) (ranking, error) {
+	var ranked ranking
+
+	// Obtiene stocks recientes para análisis dentro de la ventana solicitada. Con
+	// as_of solo se usan los eventos ya ingeridos en esa fecha, para que la respuesta
+	// no cambie al ingerir después eventos más antiguos
+	var events []models.Stock
+	var err error
+	if req.past() {
+		events, err = s.repo.GetRatingEventsAsOf(ctx, req.startDate, req.endDate)
+	} else {
+		events, err = s.repo.GetRatingEventsByDateRange(ctx, req.startDate, req.endDate)
+	}
+	if err != nil {
+		return ranked, err
+	}
+
+	// Los resultados vienen ordenados por score, se descartan los que no alcanzan el mínimo
+	results := rankFn(recommendation.NewInput(events, req.startDate, req.endDate), 0)
+	filtered := results[:0]
+	for _, result := range results {
+		if result.Score >= req.minScore {
+			filtered = append(filtered, result)
+		}
+	}
+	results = filtered
+	if req.omitRationale {
+		for i := range results {
+			results[i].Rationale = ""
+		}
+	}
+
+	// Aún no hay datos de sector, max_per_sector no descarta candidatos
+	if req.constraints.MaxPerSector > 0 {
+		ranked.warnings = append(ranked.warnings, "max_per_sector was not applied: sector data is not available")
+	}
+	ranked.results, ranked.dropped = recommendation.Diversify(results, req.constraints, nil, req.limit)
+
+	return ranked, nil
 }
 
 // generateResponseMessage genera un mensaje para la respuesta
-func (s *RecommendationService) generateResponseMessage(count int) string {
+func (s *RecommendationService) generateResponseMessage(count int, past bool) string {
+	if past {
+		if count == 1 {
+			return "Se encontró 1 recomendación de inversión para la fecha solicitada."
+		}
+		return fmt.Sprintf("Se encontraron %d recomendaciones de inversión para la fecha solicitada.", count)
+	}
+
 	if count == 0 {
 		return "No se encontraron recomendaciones para hoy. Intente más tarde cuando haya nuevas actualizaciones."
 	} else if count == 1 {
@@ -64,3 +411,13 @@ func (s *RecommendationService) generateResponseMessage(count int) string {
 		return fmt.Sprintf("Se encontraron %d recomendaciones de inversión para hoy.", count)
 	}
 }
+
+// generateAvoidMessage genera el mensaje de la lista de acciones a evitar
+func generateAvoidMessage(count int) string {
+	if count == 0 {
+		return "No se encontraron señales negativas en el período analizado."
+	} else if count == 1 {
+		return "Se encontró 1 acción con señales negativas."
+	}
+	return fmt.Sprintf("Se encontraron %d acciones con señales negativas.", count)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/cache"
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// testNow es la hora del reloj fijo de las pruebas de recomendaciones
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// newTestRecommendationService crea un servicio sobre un repositorio en memoria con
+// los stocks dados, cada uno ingerido al publicarse, y un reloj fijo en testNow
+func newTestRecommendationService(t *testing.T, stocks ...models.Stock) *RecommendationService {
+	t.Helper()
+
+	repo := memory.NewStockRepository()
+	for _, stock := range stocks {
+		saveIngested(t, repo, stock.Time, stock)
+	}
+
+	s := NewRecommendationService(repo)
+	s.SetClock(recommendation.FixedClock(testNow))
+	return s
+}
+
+// saveIngested guarda los stocks en repo como ingeridos en la fecha dada
+func saveIngested(t *testing.T, repo *memory.StockRepository, ingested time.Time, stocks ...models.Stock) {
+	t.Helper()
+
+	taxonomy := models.DefaultRatingTaxonomy()
+	for i := range stocks {
+		stocks[i].ParseTargets()
+		stocks[i].ClassifyRatings(taxonomy)
+	}
+
+	repo.SetClock(recommendation.FixedClock(ingested))
+	if _, err := repo.SaveStocks(context.Background(), stocks); err != nil {
+		t.Fatalf("SaveStocks() error: %v", err)
+	}
+}
+
+// rating construye un evento de rating con precios objetivo en dólares
+func rating(ticker, brokerage, from, to, targetFrom, targetTo string, at time.Time) models.Stock {
+	return models.Stock{
+		Ticker: ticker, Company: ticker + " Inc.", Brokerage: brokerage,
+		RatingFrom: from, RatingTo: to, TargetFrom: targetFrom, TargetTo: targetTo, Time: at,
+	}
+}
+
+func intOpt(v int) *int { return &v }
+
+func floatOpt(v float64) *float64 { return &v }
+
+func TestSetScoringConfig(t *testing.T) {
+	s := newTestRecommendationService(t,
+		rating("AAPL", "Barclays", "Sell", "Strong Buy", "$100", "$110", testNow.AddDate(0, 0, -1)),
+		rating("MSFT", "Barclays", "Buy", "Buy", "$100", "$130", testNow.AddDate(0, 0, -1)),
+	)
+	responses := cache.NewLRU(10, time.Hour)
+	s.SetCache(responses)
+
+	// Con los pesos por defecto pesa más la mejora de rating de AAPL
+	response, err := s.GetRecommendations(context.Background(), RecommendationOptions{})
+	if err != nil {
+		t.Fatalf("GetRecommendations() error: %v", err)
+	}
+	if response.Recommendations[0].Stock.Ticker != "AAPL" {
+		t.Fatalf("first recommendation = %s, want AAPL", response.Recommendations[0].Stock.Ticker)
+	}
+	if responses.Len() != 1 {
+		t.Fatalf("cache has %d entries, want 1", responses.Len())
+	}
+
+	invalid := recommendation.DefaultScoringConfig()
+	invalid.Weights.Price = 0
+	if err := s.SetScoringConfig(invalid); !errors.Is(err, recommendation.ErrInvalidConfig) {
+		t.Fatalf("SetScoringConfig() error = %v, want ErrInvalidConfig", err)
+	}
+	// La configuración inválida no reemplaza la vigente ni vacía el cache
+	if s.ScoringConfig().Weights.Price != 0.4 || responses.Len() != 1 {
+		t.Errorf("invalid config was applied: weights %+v, %d cached responses", s.ScoringConfig().Weights, responses.Len())
+	}
+
+	// Con todo el peso en el precio objetivo MSFT (+30%) supera a AAPL (+10%)
+	config := recommendation.DefaultScoringConfig()
+	config.Weights = recommendation.Weights{Price: 1}
+	config.MaxLimit = 1
+	config.DefaultLimit = 1
+	config.DefaultStrategy = recommendation.TargetMomentumStrategyName
+	if err := s.SetScoringConfig(config); err != nil {
+		t.Fatalf("SetScoringConfig() error: %v", err)
+	}
+	if responses.Len() != 0 {
+		t.Errorf("cache has %d entries after reloading the config, want 0", responses.Len())
+	}
+
+	tests := []struct {
+		name       string
+		opts       RecommendationOptions
+		wantTicker string
+		wantErr    error
+	}{
+		{"new default strategy", RecommendationOptions{}, "MSFT", nil},
+		{"new weights", RecommendationOptions{Strategy: recommendation.DefaultStrategyName}, "MSFT", nil},
+		{"new max limit", RecommendationOptions{Limit: intOpt(2)}, "", ErrInvalidRecommendationOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.GetRecommendations(context.Background(), tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetRecommendations() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRecommendations() error: %v", err)
+			}
+			if response.Count != 1 || response.Recommendations[0].Stock.Ticker != tt.wantTicker {
+				t.Errorf("recommendations = %+v, want only %s", response.Recommendations, tt.wantTicker)
+			}
+		})
+	}
+}
+
+func TestGetRecommendationsStrategy(t *testing.T) {
+	s := newTestRecommendationService(t,
+		rating("AAPL", "Barclays", "Hold", "Buy", "$100", "$110", testNow.AddDate(0, 0, -1)),
+		rating("AAPL", "JPMorgan", "Buy", "Strong Buy", "$100", "$120", testNow.AddDate(0, 0, -2)),
+	)
+
+	tests := []struct {
+		strategy string
+		want     string
+		wantErr  bool
+	}{
+		{"", recommendation.DefaultStrategyName, false},
+		{recommendation.ConsensusStrategyName, recommendation.ConsensusStrategyName, false},
+		{recommendation.TargetMomentumStrategyName, recommendation.TargetMomentumStrategyName, false},
+		{"magic", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			response, err := s.GetRecommendations(context.Background(), RecommendationOptions{Strategy: tt.strategy})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecommendationOptions) || !errors.Is(err, models.ErrInvalidArgument) {
+					t.Fatalf("GetRecommendations() error = %v, want ErrInvalidRecommendationOptions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRecommendations() error: %v", err)
+			}
+			if response.Strategy != tt.want || response.Count != 1 {
+				t.Errorf("strategy %s with %d recommendations, want %s with 1", response.Strategy, response.Count, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecommendationsOmitRationale(t *testing.T) {
+	s := newTestRecommendationService(t,
+		rating("AAPL", "Barclays", "Hold", "Buy", "$100", "$110", testNow.AddDate(0, 0, -1)),
+	)
+	s.SetCache(cache.NewLRU(10, time.Hour))
+
+	for _, omit := range []bool{true, false} {
+		response, err := s.GetRecommendations(context.Background(), RecommendationOptions{OmitRationale: omit})
+		if err != nil {
+			t.Fatalf("GetRecommendations() error: %v", err)
+		}
+
+		// La explicación estructurada se incluye siempre; la respuesta sin prosa no se
+		// reutiliza para la solicitud que sí la pide
+		result := response.Recommendations[0]
+		if (result.Rationale == "") != omit || result.Breakdown == nil || len(result.Reasons) == 0 {
+			t.Errorf("omit_rationale=%t: rationale %q, breakdown %v, reasons %v", omit, result.Rationale, result.Breakdown, result.Reasons)
+		}
+	}
+}
+
+func TestGetRecommendationsAsOf(t *testing.T) {
+	asOf := testNow.AddDate(0, 0, -5)
+	s := newTestRecommendationService(t,
+		rating("AAPL", "Barclays", "Hold", "Buy", "$100", "$110", asOf.AddDate(0, 0, -2)),
+		rating("MSFT", "Barclays", "Hold", "Buy", "$100", "$110", asOf.Add(time.Hour)),
+	)
+
+	recommend := func(opts RecommendationOptions) []string {
+		t.Helper()
+		response, err := s.GetRecommendations(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("GetRecommendations() error: %v", err)
+		}
+		tickers := []string{}
+		for _, result := range response.Recommendations {
+			tickers = append(tickers, result.Stock.Ticker)
+		}
+		return tickers
+	}
+
+	before := recommend(RecommendationOptions{AsOf: &asOf})
+	if len(before) != 1 || before[0] != "AAPL" {
+		t.Fatalf("as_of recommendations = %v, want [AAPL]", before)
+	}
+
+	// Un evento publicado antes de as_of pero ingerido después no cambia la respuesta
+	repo := s.repo.(*memory.StockRepository)
+	saveIngested(t, repo, testNow, rating("TSLA", "Barclays", "Sell", "Strong Buy", "$100", "$150", asOf.AddDate(0, 0, -1)))
+
+	if after := recommend(RecommendationOptions{AsOf: &asOf}); len(after) != 1 || after[0] != "AAPL" {
+		t.Errorf("as_of recommendations after a late ingestion = %v, want [AAPL]", after)
+	}
+
+	// Sin as_of se usan todos los eventos publicados hasta ahora
+	if latest := recommend(RecommendationOptions{}); len(latest) != 3 || latest[0] != "TSLA" {
+		t.Errorf("latest recommendations = %v, want TSLA first among 3", latest)
+	}
+}
+
+func TestGetAvoidList(t *testing.T) {
+	s := newTestRecommendationService(t,
+		rating("AAPL", "Barclays", "Buy", "Sell", "$100", "$70", testNow.AddDate(0, 0, -1)),
+		rating("MSFT", "Barclays", "Buy", "Buy", "$100", "$95", testNow.AddDate(0, 0, -20)),
+		rating("TSLA", "Barclays", "Hold", "Buy", "$100", "$120", testNow.AddDate(0, 0, -1)),
+	)
+	s.SetCache(cache.NewLRU(10, time.Hour))
+
+	tests := []struct {
+		name    string
+		opts    RecommendationOptions
+		tickers []string
+		wantErr bool
+	}{
+		{"default strategy", RecommendationOptions{}, []string{"AAPL", "MSFT"}, false},
+		{"limit", RecommendationOptions{Limit: intOpt(1)}, []string{"AAPL"}, false},
+		{"min score", RecommendationOptions{MinScore: floatOpt(60)}, []string{"AAPL"}, false},
+		{"strategy without avoid list", RecommendationOptions{Strategy: recommendation.ConsensusStrategyName}, nil, true},
+		{"unknown strategy", RecommendationOptions{Strategy: "magic"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.GetAvoidList(context.Background(), tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecommendationOptions) || !errors.Is(err, models.ErrInvalidArgument) {
+					t.Fatalf("GetAvoidList() error = %v, want ErrInvalidRecommendationOptions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAvoidList() error: %v", err)
+			}
+
+			tickers := []string{}
+			for _, result := range response.Recommendations {
+				tickers = append(tickers, result.Stock.Ticker)
+			}
+			if !reflect.DeepEqual(tickers, tt.tickers) || response.Count != len(tt.tickers) {
+				t.Errorf("avoid list = %v (count %d), want %v", tickers, response.Count, tt.tickers)
+			}
+		})
+	}
+
+	// La lista de acciones a evitar no comparte cache con las recomendaciones
+	response, err := s.GetRecommendations(context.Background(), RecommendationOptions{})
+	if err != nil || response.Count != 1 || response.Recommendations[0].Stock.Ticker != "TSLA" {
+		t.Errorf("GetRecommendations() = %+v, %v; want only TSLA", response, err)
+	}
+}
+
+func TestGetRecommendationsConstraints(t *testing.T) {
+	s := newTestRecommendationService(t,
+		rating("GOOGL", "Barclays", "Hold", "Strong Buy", "$100", "$130", testNow.AddDate(0, 0, -1)),
+		rating("MSFT", "Barclays", "Hold", "Buy", "$100", "$120", testNow.AddDate(0, 0, -1)),
+		rating("AAPL", "JPMorgan", "Hold", "Buy", "$100", "$110", testNow.AddDate(0, 0, -1)),
+	)
+
+	tests := []struct {
+		name        string
+		constraints recommendation.Constraints
+		tickers     []string
+		dropped     []string
+		warnings    int
+		wantErr     bool
+	}{
+		{"no constraints", recommendation.Constraints{}, []string{"GOOGL", "MSFT", "AAPL"}, nil, 0, false},
+		{"brokerage limit", recommendation.Constraints{MaxPerBrokerage: 1}, []string{"GOOGL", "AAPL"}, []string{"MSFT"}, 0, false},
+		// Sin datos de sector el límite no descarta candidatos y se avisa en la respuesta
+		{"sector limit", recommendation.Constraints{MaxPerSector: 1}, []string{"GOOGL", "MSFT", "AAPL"}, nil, 1, false},
+		{"negative limit", recommendation.Constraints{MaxPerBrokerage: -1}, nil, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.GetRecommendations(context.Background(), RecommendationOptions{Constraints: tt.constraints})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecommendationOptions) {
+					t.Fatalf("GetRecommendations() error = %v, want ErrInvalidRecommendationOptions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRecommendations() error: %v", err)
+			}
+
+			tickers := []string{}
+			for _, result := range response.Recommendations {
+				tickers = append(tickers, result.Stock.Ticker)
+			}
+			var dropped []string
+			for _, candidate := range response.Dropped {
+				dropped = append(dropped, candidate.Ticker)
+			}
+			if !reflect.DeepEqual(tickers, tt.tickers) || !reflect.DeepEqual(dropped, tt.dropped) {
+				t.Errorf("recommendations %v, dropped %v; want %v, %v", tickers, dropped, tt.tickers, tt.dropped)
+			}
+			if len(response.Warnings) != tt.warnings {
+				t.Errorf("warnings = %v, want %d", response.Warnings, tt.warnings)
+			}
+			if (response.Constraints == nil) != tt.constraints.IsZero() {
+				t.Errorf("constraints = %v, want them only when requested", response.Constraints)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// SnapshotService guarda el ranking diario de recomendaciones de cada estrategia
+// y permite consultarlo y compararlo entre días
+type SnapshotService struct {
+	recommendations *RecommendationService
+	repo            ports.SnapshotRepository
+}
+
+// NewSnapshotService crea una nueva instancia del servicio de snapshots
+func NewSnapshotService(recommendations *RecommendationService, repo ports.SnapshotRepository) *SnapshotService {
+	return &SnapshotService{
+		recommendations: recommendations,
+		repo:            repo,
+	}
+}
+
+// TakeSnapshots guarda el ranking actual de cada estrategia registrada, con los
+// parámetros por defecto. Reemplaza los snapshots ya tomados en el día
+func (s *SnapshotService) TakeSnapshots(ctx context.Context) error {
+	var errs []error
+
+	for _, info := range s.recommendations.Strategies() {
+		// Sin cache: el snapshot debe reflejar el ranking del momento en que se toma
+		response, err := s.recommendations.getRecommendations(ctx, RecommendationOptions{Strategy: info.Name}, false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error generating %s snapshot: %w", info.Name, err))
+			continue
+		}
+
+		snapshot := recommendation.Snapshot{
+			Date:            response.GeneratedAt.UTC().Format(recommendation.SnapshotDateLayout),
+			Strategy:        response.Strategy,
+			TakenAt:         response.GeneratedAt,
+			AsOf:            response.AsOf,
+			Recommendations: response.Recommendations,
+		}
+		if snapshot.Recommendations == nil {
+			snapshot.Recommendations = []recommendation.RecommendationResult{}
+		}
+
+		if err := s.repo.SaveSnapshot(ctx, snapshot); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run toma los snapshots del día si aún no existen y vuelve a revisarlo cada
+// interval, hasta que se cancele el contexto
+func (s *SnapshotService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.takeDailySnapshots(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// takeDailySnapshots toma los snapshots si la estrategia por defecto no tiene el del día
+func (s *SnapshotService) takeDailySnapshots(ctx context.Context) {
+	today := s.recommendations.clock.Now().UTC().Format(recommendation.SnapshotDateLayout)
+	strategy, err := s.strategyName("")
+	if err != nil {
+		log.Printf("Error al resolver la estrategia por defecto: %v", err)
+		return
+	}
+
+	_, err = s.repo.GetSnapshot(ctx, today, strategy)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, ports.ErrSnapshotNotFound) {
+		log.Printf("Error al consultar el snapshot de recomendaciones del %s: %v", today, err)
+		return
+	}
+
+	if err := s.TakeSnapshots(ctx); err != nil {
+		log.Printf("Error al tomar los snapshots de recomendaciones: %v", err)
+		return
+	}
+	log.Printf("Snapshots de recomendaciones del %s guardados", today)
+}
+
+// GetSnapshot obtiene el snapshot de un día (YYYY-MM-DD) o, si date está vacío,
+// el más reciente. Una estrategia vacía es la estrategia por defecto
+func (s *SnapshotService) GetSnapshot(ctx context.Context, date, strategy string) (recommendation.Snapshot, error) {
+	name, err := s.strategyName(strategy)
+	if err != nil {
+		return recommendation.Snapshot{}, err
+	}
+
+	if date == "" {
+		return s.repo.GetLatestSnapshot(ctx, name)
+	}
+	return s.repo.GetSnapshot(ctx, date, name)
+}
+
+// Diff compara los snapshots de dos días de la misma estrategia
+func (s *SnapshotService) Diff(ctx context.Context, from, to, strategy string) (recommendation.SnapshotDiff, error) {
+	name, err := s.strategyName(strategy)
+	if err != nil {
+		return recommendation.SnapshotDiff{}, err
+	}
+
+	fromSnapshot, err := s.repo.GetSnapshot(ctx, from, name)
+	if err != nil {
+		return recommendation.SnapshotDiff{}, err
+	}
+	toSnapshot, err := s.repo.GetSnapshot(ctx, to, name)
+	if err != nil {
+		return recommendation.SnapshotDiff{}, err
+	}
+
+	return recommendation.DiffSnapshots(fromSnapshot, toSnapshot), nil
+}
+
+// strategyName resuelve el nombre de una estrategia registrada
+func (s *SnapshotService) strategyName(name string) (string, error) {
+	strategy, err := s.recommendations.Strategy(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRecommendationOptions, err)
+	}
+	return strategy.Name(), nil
+}
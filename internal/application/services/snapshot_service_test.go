@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+func TestSnapshotService(t *testing.T) {
+	ctx := context.Background()
+	yesterday := testNow.AddDate(0, 0, -1)
+
+	recommendations := newTestRecommendationService(t,
+		rating("AAPL", "Barclays", "Hold", "Buy", "$100", "$110", yesterday.AddDate(0, 0, -1)),
+		rating("MSFT", "Barclays", "Hold", "Buy", "$100", "$120", yesterday.AddDate(0, 0, -1)),
+	)
+	s := NewSnapshotService(recommendations, memory.NewSnapshotRepository())
+
+	// Snapshots de ayer y de hoy, con un evento nuevo ingerido entre ambos
+	recommendations.SetClock(recommendation.FixedClock(yesterday))
+	if err := s.TakeSnapshots(ctx); err != nil {
+		t.Fatalf("TakeSnapshots() error: %v", err)
+	}
+	saveIngested(t, recommendations.repo.(*memory.StockRepository), testNow,
+		rating("TSLA", "Barclays", "Sell", "Strong Buy", "$100", "$150", testNow.Add(-1)))
+	recommendations.SetClock(recommendation.FixedClock(testNow))
+	s.takeDailySnapshots(ctx)
+
+	// Cada estrategia registrada tiene su snapshot del día
+	for _, info := range recommendations.Strategies() {
+		snapshot, err := s.GetSnapshot(ctx, "2025-03-10", info.Name)
+		if err != nil {
+			t.Fatalf("GetSnapshot(%s) error: %v", info.Name, err)
+		}
+		if snapshot.Strategy != info.Name || !snapshot.TakenAt.Equal(testNow) || snapshot.Recommendations == nil {
+			t.Errorf("%s snapshot = %+v, want one taken at %v", info.Name, snapshot, testNow)
+		}
+	}
+
+	latest, err := s.GetSnapshot(ctx, "", "")
+	if err != nil {
+		t.Fatalf("GetSnapshot() error: %v", err)
+	}
+	if latest.Date != "2025-03-10" || latest.Strategy != recommendation.DefaultStrategyName || len(latest.Recommendations) != 3 {
+		t.Errorf("latest snapshot = %s (%s) with %d recommendations, want 2025-03-10 (%s) with 3",
+			latest.Date, latest.Strategy, len(latest.Recommendations), recommendation.DefaultStrategyName)
+	}
+
+	diff, err := s.Diff(ctx, "2025-03-09", "2025-03-10", "")
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	if len(diff.Entries) != 1 || diff.Entries[0].Ticker != "TSLA" || len(diff.Exits) != 0 {
+		t.Errorf("diff entries %+v, exits %+v; want only TSLA entering", diff.Entries, diff.Exits)
+	}
+
+	// Un snapshot guardado no cambia al ingerir eventos, y el diff se puede repetir
+	saveIngested(t, recommendations.repo.(*memory.StockRepository), testNow,
+		rating("NVDA", "Barclays", "Sell", "Strong Buy", "$100", "$200", yesterday.Add(-1)))
+	s.takeDailySnapshots(ctx)
+	again, err := s.Diff(ctx, "2025-03-09", "2025-03-10", "")
+	if err != nil || len(again.Entries) != 1 || again.Unchanged != diff.Unchanged || len(again.Moves) != len(diff.Moves) {
+		t.Errorf("repeated Diff() = %+v, %v; want %+v", again, err, diff)
+	}
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantErr  error
+		category error
+	}{
+		{"unknown date", func() error { _, err := s.GetSnapshot(ctx, "2025-01-01", ""); return err }, ports.ErrSnapshotNotFound, models.ErrNotFound},
+		{"unknown strategy", func() error { _, err := s.GetSnapshot(ctx, "", "magic"); return err }, ErrInvalidRecommendationOptions, models.ErrInvalidArgument},
+		{"diff without a snapshot", func() error { _, err := s.Diff(ctx, "2025-03-01", "2025-03-10", ""); return err }, ports.ErrSnapshotNotFound, models.ErrNotFound},
+		{"diff of an unknown strategy", func() error { _, err := s.Diff(ctx, "2025-03-09", "2025-03-10", "magic"); return err }, ErrInvalidRecommendationOptions, models.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.wantErr) || !errors.Is(err, tt.category) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
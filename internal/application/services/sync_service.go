@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/application/ports"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrSyncInProgress se retorna cuando ya hay una sincronización en curso
+var ErrSyncInProgress = models.NewError(models.ErrConflict, "sync already in progress")
+
+// errReachedCheckpoint detiene la paginación incremental al llegar a datos ya ingeridos
+var errReachedCheckpoint = errors.New("reached sync checkpoint")
+
+// errSyncLeaseLost interrumpe una sincronización cuyo lease tomó otro trabajo
+var errSyncLeaseLost = errors.New("sync lease lost")
+
+// SyncService ejecuta y registra los trabajos de sincronización con la API externa
+type SyncService struct {
+	repo     ports.StockRepository
+	jobs     ports.SyncJobRepository
+	provider ports.StockProvider
+	taxonomy *models.RatingTaxonomy
+	timeout  time.Duration
+
+	// leaseTTL es la vigencia del lease de sincronización, que se renueva mientras el
+	// trabajo sigue en curso. Si la réplica cae, otra puede sincronizar al expirar
+	leaseTTL time.Duration
+
+	// baseCtx se cancela con Close para interrumpir las sincronizaciones en segundo plano
+	baseCtx context.Context
+	cancel  context.CancelFunc
+
+	mu          sync.Mutex
+	activeJobID string
+
+	// successHooks se ejecutan al terminar cada sincronización exitosa
+	successHooks []func(ctx context.Context, job models.SyncJob)
+}
+
+// NewSyncService crea una nueva instancia del servicio de sincronización
+func NewSyncService(repo ports.StockRepository, jobs ports.SyncJobRepository, provider ports.StockProvider) *SyncService {
+	baseCtx, cancel := context.WithCancel(context.Background())
+
+	return &SyncService{
+		repo:     repo,
+		jobs:     jobs,
+		provider: provider,
+		taxonomy: models.DefaultRatingTaxonomy(),
+		timeout:  10 * time.Minute,
+		leaseTTL: 2 * time.Minute,
+		baseCtx:  baseCtx,
+		cancel:   cancel,
+	}
+}
+
+// Close cancela las sincronizaciones en segundo plano que estén en curso
+func (s *SyncService) Close() {
+	s.cancel()
+}
+
+// OnSuccess registra una función que se ejecuta después de cada sincronización exitosa,
+// por ejemplo para recalcular datos derivados de los stocks
+func (s *SyncService) OnSuccess(hook func(ctx context.Context, job models.SyncJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.successHooks = append(s.successHooks, hook)
+}
+
+// StartSync registra un trabajo nuevo y lo ejecuta en segundo plano.
+// Si ya hay un trabajo en curso retorna ese trabajo junto con ErrSyncInProgress
+func (s *SyncService) StartSync(ctx context.Context, mode models.SyncMode) (models.SyncJob, error) {
+	job, err := s.reserve(ctx, mode)
+	if err != nil {
+		return job, err
+	}
+
+	go func() {
+		runCtx, cancel := context.WithTimeout(s.baseCtx, s.timeout)
+		defer cancel()
+
+		s.run(runCtx, job)
+	}()
+
+	return job, nil
+}
+
+// RunSync registra un trabajo y lo ejecuta de forma síncrona. Si falla retorna el
+// error original, que conserva su categoría
+func (s *SyncService) RunSync(ctx context.Context, mode models.SyncMode) (models.SyncJob, error) {
+	job, err := s.reserve(ctx, mode)
+	if err != nil {
+		return job, err
+	}
+
+	return s.run(ctx, job)
+}
+
+// GetJob obtiene un trabajo por su ID
+func (s *SyncService) GetJob(ctx context.Context, id string) (models.SyncJob, error) {
+	return s.jobs.GetSyncJob(ctx, id)
+}
+
+// ListJobs lista los trabajos más recientes
+func (s *SyncService) ListJobs(ctx context.Context, limit int) ([]models.SyncJob, error) {
+	return s.jobs.ListSyncJobs(ctx, limit)
+}
+
+// reserve crea un trabajo en estado queued y lo marca como activo,
+// garantizando que no se ejecuten dos sincronizaciones a la vez. Además del
+// trabajo activo del proceso se toma el lease compartido del repositorio, que
+// excluye a las demás réplicas
+func (s *SyncService) reserve(ctx context.Context, mode models.SyncMode) (models.SyncJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeJobID != "" {
+		return s.activeJob(ctx, s.activeJobID), ErrSyncInProgress
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return models.SyncJob{}, err
+	}
+
+	holder, acquired, err := s.jobs.AcquireSyncLease(ctx, id, s.leaseTTL)
+	if err != nil {
+		return models.SyncJob{}, err
+	}
+	if !acquired {
+		return s.activeJob(ctx, holder), ErrSyncInProgress
+	}
+
+	job := models.SyncJob{
+		ID:        id,
+		State:     models.SyncJobQueued,
+		Mode:      mode,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.jobs.CreateSyncJob(ctx, job); err != nil {
+		s.releaseLease(job.ID)
+		return models.SyncJob{}, err
+	}
+
+	s.activeJobID = job.ID
+	return job, nil
+}
+
+// activeJob obtiene el trabajo en curso para informarlo al llamador
+func (s *SyncService) activeJob(ctx context.Context, id string) models.SyncJob {
+	active, err := s.jobs.GetSyncJob(ctx, id)
+	if err != nil {
+		return models.SyncJob{ID: id}
+	}
+	return active
+}
+
+// keepLease renueva el lease del trabajo hasta que se cancele el contexto. Si otro
+// trabajo tomó el lease cancela la sincronización con errSyncLeaseLost
+func (s *SyncService) keepLease(ctx context.Context, cancel context.CancelCauseFunc, jobID string) {
+	ticker := time.NewTicker(s.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		held, err := s.jobs.RenewSyncLease(ctx, jobID, s.leaseTTL)
+		if err != nil {
+			// El lease sigue vigente hasta expirar, se reintenta en el siguiente ciclo
+			log.Printf("Sincronización %s: error al renovar el lease: %v", jobID, err)
+			continue
+		}
+		if !held {
+			cancel(errSyncLeaseLost)
+			return
+		}
+	}
+}
+
+// releaseLease libera el lease aunque el contexto de ejecución haya terminado
+func (s *SyncService) releaseLease(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.jobs.ReleaseSyncLease(ctx, jobID); err != nil {
+		log.Printf("Sincronización %s: error al liberar el lease: %v", jobID, err)
+	}
+}
+
+// run ejecuta la sincronización actualizando el trabajo a medida que avanza
+func (s *SyncService) run(parent context.Context, job models.SyncJob) (models.SyncJob, error) {
+	ctx, cancel := context.WithCancelCause(parent)
+	go s.keepLease(ctx, cancel, job.ID)
+
+	defer func() {
+		cancel(nil)
+		s.releaseLease(job.ID)
+
+		s.mu.Lock()
+		s.activeJobID = ""
+		s.mu.Unlock()
+	}()
+
+	startedAt := time.Now().UTC()
+	job.State = models.SyncJobRunning
+	job.StartedAt = &startedAt
+	s.saveProgress(ctx, job)
+
+	// En modo incremental solo interesan los eventos posteriores al checkpoint
+	checkpoint, hasCheckpoint, err := s.jobs.GetSyncCheckpoint(ctx)
+	if err != nil {
+		return s.finish(job, fmt.Errorf("error al obtener el checkpoint de sincronización: %w", err))
+	}
+	incremental := job.Mode == models.SyncModeIncremental && hasCheckpoint
+
+	newest := checkpoint.NewestEventTime
+
+	// Cada página se escribe como un lote independiente, sin acumular el dataset en memoria
+	err = s.provider.FetchPages(ctx, func(page []models.Stock, _ string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		job.Pages++
+		job.ItemsFetched += len(page)
+
+		fresh := page
+		if incremental {
+			fresh = newerThan(page, checkpoint.NewestEventTime)
+		}
+		job.ItemsSkipped += len(page) - len(fresh)
+
+		// Interpretar los precios objetivo y clasificar las calificaciones antes de guardar,
+		// reportando los precios inválidos
+		for i := range fresh {
+			fresh[i].ClassifyRatings(s.taxonomy)
+
+			for _, parseErr := range fresh[i].ParseTargets() {
+				job.TargetParseErrors++
+				log.Printf("Sincronización %s: precio objetivo no interpretable para %s (%s): %v",
+					job.ID, fresh[i].Ticker, fresh[i].Brokerage, parseErr)
+			}
+		}
+
+		if len(fresh) > 0 {
+			result, err := s.repo.SaveStocks(ctx, fresh)
+			if err != nil {
+				return fmt.Errorf("error al guardar la página %d en la base de datos: %w", job.Pages, err)
+			}
+
+			job.ItemsInserted += result.Inserted
+			job.ItemsUpdated += result.Updated
+
+			log.Printf("Sincronización %s: página %d guardada (%d eventos nuevos, %d tickers actualizados)",
+				job.ID, job.Pages, result.Inserted, result.Updated)
+		}
+
+		for _, stock := range fresh {
+			if stock.Time.After(newest) {
+				newest = stock.Time
+			}
+		}
+		s.saveProgress(ctx, job)
+
+		// Una página sin eventos desde el checkpoint indica que el resto ya fue ingerido
+		if incremental && len(page) > 0 && len(fresh) == 0 {
+			return errReachedCheckpoint
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errReachedCheckpoint) {
+		if cause := context.Cause(ctx); errors.Is(cause, errSyncLeaseLost) {
+			err = cause
+		}
+		return s.finish(job, fmt.Errorf("error durante la sincronización: %w", err))
+	}
+
+	if !newest.IsZero() {
+		checkpoint = models.SyncCheckpoint{
+			NewestEventTime: newest,
+			UpdatedAt:       time.Now().UTC(),
+		}
+		if err := s.jobs.SaveSyncCheckpoint(ctx, checkpoint); err != nil {
+			return s.finish(job, fmt.Errorf("error al guardar el checkpoint de sincronización: %w", err))
+		}
+	}
+
+	log.Printf("Sincronización %s (%s) completada: %d stocks obtenidos, %d insertados, %d actualizados, %d omitidos",
+		job.ID, job.Mode, job.ItemsFetched, job.ItemsInserted, job.ItemsUpdated, job.ItemsSkipped)
+
+	return s.finish(job, nil)
+}
+
+// finish registra el estado final del trabajo y retorna el error recibido
+func (s *SyncService) finish(job models.SyncJob, err error) (models.SyncJob, error) {
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+
+	if err != nil {
+		// El error original puede incluir detalles de la base de datos o de la API
+		// externa: solo se registra en los logs y el trabajo guarda un código
+		log.Printf("Sincronización %s fallida: %v", job.ID, err)
+		job.State = models.SyncJobFailed
+		job.Error = syncJobError(err)
+	} else {
+		job.State = models.SyncJobSucceeded
+	}
+
+	// El contexto de ejecución puede haber expirado, el estado final se guarda igualmente
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.saveProgress(ctx, job)
+
+	if job.State == models.SyncJobSucceeded {
+		s.runSuccessHooks(job)
+	}
+
+	return job, err
+}
+
+// syncJobError describe una falla para los clientes de la API según su categoría
+func syncJobError(err error) *models.SyncJobError {
+	switch {
+	case errors.Is(err, errSyncLeaseLost):
+		return &models.SyncJobError{Code: models.SyncErrorLeaseLost, Message: "Otra réplica tomó el lease de sincronización"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &models.SyncJobError{Code: models.SyncErrorTimeout, Message: "La sincronización superó el tiempo máximo"}
+	case errors.Is(err, context.Canceled):
+		return &models.SyncJobError{Code: models.SyncErrorCanceled, Message: "La sincronización fue cancelada"}
+	case errors.Is(err, models.ErrMisconfigured):
+		return &models.SyncJobError{Code: models.SyncErrorMisconfigured, Message: "Error de configuración de la API externa"}
+	case errors.Is(err, models.ErrUnavailable):
+		return &models.SyncJobError{Code: models.SyncErrorUpstreamUnavailable, Message: "La API externa no está disponible"}
+	default:
+		return &models.SyncJobError{Code: models.SyncErrorInternal, Message: "Error interno durante la sincronización"}
+	}
+}
+
+// runSuccessHooks ejecuta los hooks de sincronización exitosa con su propio plazo
+func (s *SyncService) runSuccessHooks(job models.SyncJob) {
+	s.mu.Lock()
+	hooks := append([]func(context.Context, models.SyncJob){}, s.successHooks...)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, hook := range hooks {
+		hook(ctx, job)
+	}
+}
+
+// saveProgress persiste el trabajo sin interrumpir la sincronización si falla
+func (s *SyncService) saveProgress(ctx context.Context, job models.SyncJob) {
+	if err := s.jobs.UpdateSyncJob(ctx, job); err != nil {
+		log.Printf("Error al actualizar el trabajo de sincronización %s: %v", job.ID, err)
+	}
+}
+
+// newerThan filtra los stocks publicados desde la fecha indicada, inclusive. Los eventos
+// con la misma fecha del checkpoint pueden ser de otros tickers o casas de bolsa, por lo
+// que se conservan y el repositorio descarta los que ya estaban guardados
+func newerThan(stocks []models.Stock, since time.Time) []models.Stock {
+	fresh := make([]models.Stock, 0, len(stocks))
+	for _, stock := range stocks {
+		if !stock.Time.Before(since) {
+			fresh = append(fresh, stock)
+		}
+	}
+	return fresh
+}
+
+// newJobID genera un identificador aleatorio para un trabajo
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating sync job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/adapters/secondary/memory"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// fakeProvider entrega páginas fijas. Si wait no es nil se ejecuta antes de la
+// primera página, para simular una sincronización lenta
+type fakeProvider struct {
+	pages [][]models.Stock
+	err   error
+	wait  func(ctx context.Context) error
+}
+
+func (p *fakeProvider) FetchPages(ctx context.Context, handle func(stocks []models.Stock, nextPage string) error) error {
+	if p.wait != nil {
+		if err := p.wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	for i, page := range p.pages {
+		nextPage := ""
+		if i < len(p.pages)-1 {
+			nextPage = strconv.Itoa(i + 2)
+		}
+		// El servicio modifica los stocks de la página, como con una respuesta nueva
+		if err := handle(append([]models.Stock(nil), page...), nextPage); err != nil {
+			return err
+		}
+	}
+	return p.err
+}
+
+func newTestSyncService(provider *fakeProvider, jobs *memory.SyncJobRepository) *SyncService {
+	return NewSyncService(memory.NewStockRepository(), jobs, provider)
+}
+
+func event(ticker string, at time.Time) models.Stock {
+	return models.Stock{Ticker: ticker, Brokerage: "Barclays", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: "$10", TargetTo: "$12", Time: at}
+}
+
+// waitForState espera a que el trabajo llegue al estado indicado
+func waitForState(t *testing.T, s *SyncService, id string, state models.SyncJobState) models.SyncJob {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := s.GetJob(context.Background(), id)
+		if err == nil && job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s state = %s, want %s", id, job.State, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunSyncIncremental(t *testing.T) {
+	t1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t0, t2, t3 := t1.Add(-time.Hour), t1.Add(time.Hour), t1.Add(2*time.Hour)
+
+	// La sincronización previa dejó el checkpoint en t2
+	initial := [][]models.Stock{{event("AAA", t2)}, {event("BBB", t1)}}
+	latest := [][]models.Stock{{event("CCC", t3), event("DDD", t2)}, {event("BBB", t1)}, {event("EEE", t0)}}
+
+	tests := []struct {
+		mode     models.SyncMode
+		pages    int
+		fetched  int
+		inserted int
+		skipped  int
+	}{
+		// El evento de DDD tiene la fecha del checkpoint y se conserva; la segunda
+		// página es anterior al checkpoint y detiene la sincronización
+		{models.SyncModeIncremental, 2, 3, 2, 1},
+		{models.SyncModeFull, 3, 4, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			stocks, jobs := memory.NewStockRepository(), memory.NewSyncJobRepository()
+			if _, err := NewSyncService(stocks, jobs, &fakeProvider{pages: initial}).RunSync(context.Background(), models.SyncModeIncremental); err != nil {
+				t.Fatalf("initial RunSync() error: %v", err)
+			}
+
+			s := NewSyncService(stocks, jobs, &fakeProvider{pages: latest})
+
+			var hooks atomic.Int32
+			s.OnSuccess(func(ctx context.Context, job models.SyncJob) { hooks.Add(1) })
+
+			job, err := s.RunSync(context.Background(), tt.mode)
+			if err != nil {
+				t.Fatalf("RunSync() error: %v", err)
+			}
+			if job.State != models.SyncJobSucceeded || job.Error != nil {
+				t.Errorf("job = %s with error %v, want succeeded", job.State, job.Error)
+			}
+			if job.Pages != tt.pages || job.ItemsFetched != tt.fetched || job.ItemsInserted != tt.inserted || job.ItemsSkipped != tt.skipped {
+				t.Errorf("pages %d, fetched %d, inserted %d, skipped %d; want %d, %d, %d, %d",
+					job.Pages, job.ItemsFetched, job.ItemsInserted, job.ItemsSkipped, tt.pages, tt.fetched, tt.inserted, tt.skipped)
+			}
+			if hooks.Load() != 1 {
+				t.Errorf("success hooks ran %d times, want 1", hooks.Load())
+			}
+
+			checkpoint, ok, _ := jobs.GetSyncCheckpoint(context.Background())
+			if !ok || !checkpoint.NewestEventTime.Equal(t3) {
+				t.Errorf("checkpoint = %v, want %v", checkpoint.NewestEventTime, t3)
+			}
+		})
+	}
+}
+
+func TestRunSyncFailureIsSanitized(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"database", errors.New("pq: password authentication failed for user root"), models.SyncErrorInternal},
+		{"upstream", models.NewError(models.ErrUnavailable, "GET https://internal.example:8443/stocks: 503"), models.SyncErrorUpstreamUnavailable},
+		{"configuration", models.NewError(models.ErrMisconfigured, "STOCK_API_AUTH_TOKEN=secret rejected"), models.SyncErrorMisconfigured},
+		{"timeout", context.DeadlineExceeded, models.SyncErrorTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := memory.NewSyncJobRepository()
+			s := newTestSyncService(&fakeProvider{err: tt.err}, jobs)
+
+			var hooks atomic.Int32
+			s.OnSuccess(func(ctx context.Context, job models.SyncJob) { hooks.Add(1) })
+
+			job, err := s.RunSync(context.Background(), models.SyncModeFull)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("RunSync() error = %v, want the original error", err)
+			}
+			if hooks.Load() != 0 {
+				t.Error("success hooks ran for a failed sync")
+			}
+
+			stored, _ := s.GetJob(context.Background(), job.ID)
+			if stored.State != models.SyncJobFailed || stored.Error == nil {
+				t.Fatalf("stored job = %s with error %v, want failed with an error", stored.State, stored.Error)
+			}
+			if stored.Error.Code != tt.code {
+				t.Errorf("error code = %q, want %q", stored.Error.Code, tt.code)
+			}
+			if strings.Contains(stored.Error.Message, tt.err.Error()) {
+				t.Errorf("error message %q exposes the original error", stored.Error.Message)
+			}
+
+			// El lease se libera al fallar
+			if _, err := s.RunSync(context.Background(), models.SyncModeFull); errors.Is(err, ErrSyncInProgress) {
+				t.Error("lease was not released after a failed sync")
+			}
+		})
+	}
+}
+
+func TestStartSyncRejectsOverlappingRuns(t *testing.T) {
+	release := make(chan struct{})
+	provider := &fakeProvider{wait: func(ctx context.Context) error {
+		<-release
+		return nil
+	}}
+
+	jobs := memory.NewSyncJobRepository()
+	s := newTestSyncService(provider, jobs)
+	replica := newTestSyncService(provider, jobs)
+
+	first, err := s.StartSync(context.Background(), models.SyncModeFull)
+	if err != nil {
+		t.Fatalf("StartSync() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		service *SyncService
+	}{
+		{"same process", s},
+		{"another replica", replica},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := tt.service.StartSync(context.Background(), models.SyncModeFull)
+			if !errors.Is(err, ErrSyncInProgress) || !errors.Is(err, models.ErrConflict) {
+				t.Fatalf("StartSync() error = %v, want ErrSyncInProgress", err)
+			}
+			if active.ID != first.ID {
+				t.Errorf("active job = %q, want %q", active.ID, first.ID)
+			}
+		})
+	}
+
+	close(release)
+	waitForState(t, s, first.ID, models.SyncJobSucceeded)
+
+	// El lease se libera después de guardar el estado final
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := replica.RunSync(context.Background(), models.SyncModeFull)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrSyncInProgress) || time.Now().After(deadline) {
+			t.Fatalf("RunSync() after the first sync error: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestKeepLeaseRenewsWhileRunning(t *testing.T) {
+	checked := make(chan error, 1)
+	jobs := memory.NewSyncJobRepository()
+	replica := newTestSyncService(&fakeProvider{}, jobs)
+
+	// La sincronización dura varias veces el TTL del lease; otra réplica no debe
+	// poder tomarlo mientras se renueva
+	s := newTestSyncService(&fakeProvider{wait: func(ctx context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		_, err := replica.RunSync(ctx, models.SyncModeFull)
+		checked <- err
+		return nil
+	}}, jobs)
+	s.leaseTTL = 50 * time.Millisecond
+
+	if _, err := s.RunSync(context.Background(), models.SyncModeFull); err != nil {
+		t.Fatalf("RunSync() error: %v", err)
+	}
+	if err := <-checked; !errors.Is(err, ErrSyncInProgress) {
+		t.Errorf("replica RunSync() error = %v, want ErrSyncInProgress", err)
+	}
+}
+
+func TestKeepLeaseCancelsWhenLeaseIsLost(t *testing.T) {
+	jobs := memory.NewSyncJobRepository()
+	started := make(chan struct{})
+
+	s := newTestSyncService(&fakeProvider{wait: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}}, jobs)
+	s.leaseTTL = 30 * time.Millisecond
+
+	type result struct {
+		job models.SyncJob
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		job, err := s.RunSync(context.Background(), models.SyncModeFull)
+		done <- result{job, err}
+	}()
+
+	// Otra réplica toma el lease, como si el de este trabajo hubiera expirado
+	<-started
+	ctx := context.Background()
+	holder, _, _ := jobs.AcquireSyncLease(ctx, "probe", time.Minute)
+	jobs.ReleaseSyncLease(ctx, holder)
+	if _, acquired, _ := jobs.AcquireSyncLease(ctx, "other", time.Minute); !acquired {
+		t.Fatal("could not take over the lease")
+	}
+
+	select {
+	case r := <-done:
+		if !errors.Is(r.err, errSyncLeaseLost) {
+			t.Errorf("RunSync() error = %v, want errSyncLeaseLost", r.err)
+		}
+		if r.job.Error == nil || r.job.Error.Code != models.SyncErrorLeaseLost {
+			t.Errorf("job error = %+v, want code %q", r.job.Error, models.SyncErrorLeaseLost)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync did not stop after losing the lease")
+	}
+
+	// El trabajo no libera un lease que ya no le pertenece
+	if holder, acquired, _ := jobs.AcquireSyncLease(ctx, "probe", time.Minute); acquired || holder != "other" {
+		t.Errorf("lease holder = %q, want other", holder)
+	}
+}
+
+func TestNewerThan(t *testing.T) {
+	since := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stocks := []models.Stock{
+		event("OLD", since.Add(-time.Second)),
+		event("SAME", since),
+		event("NEW", since.Add(time.Second)),
+	}
+
+	var tickers []string
+	for _, stock := range newerThan(stocks, since) {
+		tickers = append(tickers, stock.Ticker)
+	}
+	if strings.Join(tickers, ",") != "SAME,NEW" {
+		t.Errorf("newerThan() = %v, want SAME and NEW", tickers)
+	}
+}
@@ -0,0 +1,250 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// ErrInvalidParams se retorna cuando los parámetros del backtest no son válidos
+var ErrInvalidParams = models.NewError(models.ErrInvalidArgument, "invalid backtest params")
+
+// DefaultHorizons son los plazos, en días de mercado, de los retornos evaluados
+var DefaultHorizons = []int{5, 20, 60}
+
+// Params define un backtest: la estrategia, el rango de fechas de las señales y
+// cómo se eligen las recomendaciones de cada día
+type Params struct {
+	Strategy string    `json:"strategy"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+
+	// LookbackDays es la ventana de eventos que recibe la estrategia cada día
+	LookbackDays int `json:"lookback_days"`
+	// Limit es la cantidad de recomendaciones tomadas cada día
+	Limit    int     `json:"limit"`
+	MinScore float64 `json:"min_score"`
+
+	// Horizons son los plazos de los retornos, en días de mercado
+	Horizons []int `json:"horizons"`
+	// RebalanceDays es cada cuántos días calendario se generan señales
+	RebalanceDays int `json:"rebalance_days"`
+}
+
+// WithDefaults completa los parámetros omitidos
+func (p Params) WithDefaults() Params {
+	if p.Strategy == "" {
+		p.Strategy = recommendation.DefaultStrategyName
+	}
+	if p.LookbackDays == 0 {
+		p.LookbackDays = 30
+	}
+	if p.Limit == 0 {
+		p.Limit = 10
+	}
+	if len(p.Horizons) == 0 {
+		p.Horizons = append([]int(nil), DefaultHorizons...)
+	}
+	if p.RebalanceDays == 0 {
+		p.RebalanceDays = 1
+	}
+	p.From, p.To = truncateDay(p.From), truncateDay(p.To)
+	return p
+}
+
+// Validate verifica los parámetros, que ya deben tener los valores por defecto
+func (p Params) Validate() error {
+	var problems []string
+
+	if p.From.IsZero() || p.To.IsZero() {
+		problems = append(problems, "from and to are required")
+	} else if p.From.After(p.To) {
+		problems = append(problems, "from must not be after to")
+	} else if p.To.Sub(p.From) > 5*366*24*time.Hour {
+		problems = append(problems, "the date range must not exceed 5 years")
+	}
+	if p.LookbackDays < 1 || p.LookbackDays > 365 {
+		problems = append(problems, "lookback_days must be between 1 and 365")
+	}
+	if p.Limit < 1 || p.Limit > 100 {
+		problems = append(problems, "limit must be between 1 and 100")
+	}
+	if p.MinScore < 0 || p.MinScore > 100 {
+		problems = append(problems, "min_score must be between 0 and 100")
+	}
+	for _, horizon := range p.Horizons {
+		if horizon < 1 || horizon > 260 {
+			problems = append(problems, "horizons must be between 1 and 260 market days")
+			break
+		}
+	}
+	if p.RebalanceDays < 1 || p.RebalanceDays > 90 {
+		problems = append(problems, "rebalance_days must be between 1 and 90")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidParams, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// HorizonResult resume los retornos a un plazo de las recomendaciones y de la referencia
+type HorizonResult struct {
+	Days int `json:"days"`
+
+	// Recomendaciones con precio de entrada y de salida disponibles
+	Trades       int      `json:"trades"`
+	HitRate      *float64 `json:"hit_rate"`
+	AvgReturnPct *float64 `json:"avg_return_pct"`
+
+	// Referencia: comprar en partes iguales todos los tickers con precios cada día de señal
+	BaselineTrades       int      `json:"baseline_trades"`
+	BaselineHitRate      *float64 `json:"baseline_hit_rate"`
+	BaselineAvgReturnPct *float64 `json:"baseline_avg_return_pct"`
+
+	// ExcessReturnPct es AvgReturnPct menos BaselineAvgReturnPct
+	ExcessReturnPct *float64 `json:"excess_return_pct"`
+}
+
+// Result es el resultado de un backtest
+type Result struct {
+	SignalDays int `json:"signal_days"`
+	Picks      int `json:"picks"`
+	Tickers    int `json:"tickers"`
+	// PicksWithoutPrices son recomendaciones sin precios suficientes para ningún plazo
+	PicksWithoutPrices int             `json:"picks_without_prices"`
+	Horizons           []HorizonResult `json:"horizons"`
+}
+
+// Report es un backtest ejecutado y persistido
+type Report struct {
+	ID           string    `json:"id"`
+	Params       Params    `json:"params"`
+	PricesSource string    `json:"prices_source"`
+	Result       Result    `json:"result"`
+	CreatedAt    time.Time `json:"created_at"`
+	DurationMs   int64     `json:"duration_ms"`
+}
+
+// accumulator suma retornos de un plazo
+type accumulator struct {
+	trades int
+	hits   int
+	sum    float64
+}
+
+func (a *accumulator) add(returnPct float64) {
+	a.trades++
+	a.sum += returnPct
+	if returnPct > 0 {
+		a.hits++
+	}
+}
+
+func (a accumulator) hitRate() *float64 {
+	if a.trades == 0 {
+		return nil
+	}
+	rate := float64(a.hits) / float64(a.trades)
+	return &rate
+}
+
+func (a accumulator) average() *float64 {
+	if a.trades == 0 {
+		return nil
+	}
+	avg := a.sum / float64(a.trades)
+	return &avg
+}
+
+// Run reproduce los eventos día por día con la fecha de referencia de cada día,
+// de modo que la estrategia solo ve eventos ya publicados, y mide los retornos
+// posteriores de sus recomendaciones
+func Run(strategy recommendation.Strategy, events []models.Stock, prices *PriceHistory, params Params) Result {
+	prices.sort()
+
+	sorted := make([]models.Stock, len(events))
+	copy(sorted, events)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	universe := prices.Tickers()
+	picks := make([]accumulator, len(params.Horizons))
+	baseline := make([]accumulator, len(params.Horizons))
+	tickers := make(map[string]bool)
+
+	var result Result
+
+	for day := params.From; !day.After(params.To); day = day.AddDate(0, 0, params.RebalanceDays) {
+		asOf := day.Add(24*time.Hour - time.Nanosecond)
+		windowStart := asOf.AddDate(0, 0, -params.LookbackDays)
+
+		// Eventos publicados dentro de la ventana al cierre del día
+		first := sort.Search(len(sorted), func(i int) bool { return !sorted[i].Time.Before(windowStart) })
+		last := sort.Search(len(sorted), func(i int) bool { return sorted[i].Time.After(asOf) })
+		window := sorted[first:last]
+
+		result.SignalDays++
+
+		if len(window) > 0 {
+			recommendations := strategy.Recommend(recommendation.NewInput(window, windowStart, asOf), 0)
+
+			taken := 0
+			for _, rec := range recommendations {
+				if taken == params.Limit {
+					break
+				}
+				if rec.Score < params.MinScore {
+					continue
+				}
+				taken++
+
+				result.Picks++
+				tickers[rec.Stock.Ticker] = true
+
+				priced := false
+				for i, horizon := range params.Horizons {
+					if r, ok := prices.forwardReturn(rec.Stock.Ticker, day, horizon); ok {
+						picks[i].add(r)
+						priced = true
+					}
+				}
+				if !priced {
+					result.PicksWithoutPrices++
+				}
+			}
+		}
+
+		for _, ticker := range universe {
+			for i, horizon := range params.Horizons {
+				if r, ok := prices.forwardReturn(ticker, day, horizon); ok {
+					baseline[i].add(r)
+				}
+			}
+		}
+	}
+
+	result.Tickers = len(tickers)
+
+	for i, horizon := range params.Horizons {
+		h := HorizonResult{
+			Days:                 horizon,
+			Trades:               picks[i].trades,
+			HitRate:              picks[i].hitRate(),
+			AvgReturnPct:         picks[i].average(),
+			BaselineTrades:       baseline[i].trades,
+			BaselineHitRate:      baseline[i].hitRate(),
+			BaselineAvgReturnPct: baseline[i].average(),
+		}
+		if h.AvgReturnPct != nil && h.BaselineAvgReturnPct != nil {
+			excess := *h.AvgReturnPct - *h.BaselineAvgReturnPct
+			h.ExcessReturnPct = &excess
+		}
+		result.Horizons = append(result.Horizons, h)
+	}
+
+	return result
+}
@@ -0,0 +1,249 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// recordingStrategy recomienda cada ticker de la ventana con un score fijo y
+// registra los eventos publicados después de la fecha de referencia
+type recordingStrategy struct {
+	scores    map[string]float64
+	lookahead []string
+}
+
+func (s *recordingStrategy) Name() string                           { return "recording" }
+func (s *recordingStrategy) Description() string                    { return "" }
+func (s *recordingStrategy) Parameters() []recommendation.Parameter { return nil }
+func (s *recordingStrategy) Recommend(input recommendation.Input, limit int) []recommendation.RecommendationResult {
+	seen := make(map[string]bool)
+	var results []recommendation.RecommendationResult
+	for _, event := range input.Events {
+		if event.Time.After(input.AsOf) || event.Time.Before(input.From) {
+			s.lookahead = append(s.lookahead, event.Ticker+"@"+input.AsOf.Format(time.RFC3339))
+		}
+		if seen[event.Ticker] {
+			continue
+		}
+		seen[event.Ticker] = true
+		results = append(results, recommendation.RecommendationResult{Stock: event, Score: s.scores[event.Ticker]})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+// mean retorna el promedio de los valores
+func mean(values ...float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// pct retorna el retorno porcentual entre dos cierres
+func pct(entry, exit float64) float64 {
+	return (exit/entry - 1) * 100
+}
+
+func TestRun(t *testing.T) {
+	prices := NewPriceHistory()
+	for d := 1; d <= 10; d++ {
+		prices.Add("AAA", day(d), 100+float64(d))
+		prices.Add("BBB", day(d), 50)
+	}
+
+	events := []models.Stock{
+		// Publicado durante el día 2: la señal de ese día lo ve, la del día 1 no
+		{Ticker: "AAA", Brokerage: "Barclays", Time: day(2).Add(10 * time.Hour)},
+		{Ticker: "BBB", Brokerage: "Barclays", Time: day(3).Add(23 * time.Hour)},
+		{Ticker: "NOPRICES", Brokerage: "Barclays", Time: day(3)},
+		{Ticker: "LATER", Brokerage: "Barclays", Time: day(20)},
+	}
+	scores := map[string]float64{"AAA": 80, "BBB": 60, "NOPRICES": 40, "LATER": 100}
+
+	base := Params{From: day(1).Add(15 * time.Hour), To: day(3), Limit: 10, Horizons: []int{1, 2}}.WithDefaults()
+
+	tests := []struct {
+		name           string
+		modify         func(p *Params)
+		picks          int
+		tickers        int
+		withoutPrices  int
+		signalDays     int
+		horizon1       []float64
+		horizon2       []float64
+		baselineTrades int
+	}{
+		{
+			name: "daily",
+			// Día 2: AAA entra el día 3. Día 3: AAA y BBB entran el día 4; NOPRICES no tiene precios
+			picks: 4, tickers: 3, withoutPrices: 1, signalDays: 3,
+			horizon1:       []float64{pct(103, 104), pct(104, 105), 0},
+			horizon2:       []float64{pct(103, 105), pct(104, 106), 0},
+			baselineTrades: 6,
+		},
+		{
+			name:   "limit",
+			modify: func(p *Params) { p.Limit = 1 },
+			picks:  2, tickers: 1, withoutPrices: 0, signalDays: 3,
+			horizon1:       []float64{pct(103, 104), pct(104, 105)},
+			horizon2:       []float64{pct(103, 105), pct(104, 106)},
+			baselineTrades: 6,
+		},
+		{
+			name:   "min score",
+			modify: func(p *Params) { p.MinScore = 70 },
+			picks:  2, tickers: 1, withoutPrices: 0, signalDays: 3,
+			horizon1:       []float64{pct(103, 104), pct(104, 105)},
+			horizon2:       []float64{pct(103, 105), pct(104, 106)},
+			baselineTrades: 6,
+		},
+		{
+			name:   "rebalance",
+			modify: func(p *Params) { p.RebalanceDays = 2 },
+			// Señales los días 1 y 3
+			picks: 3, tickers: 3, withoutPrices: 1, signalDays: 2,
+			horizon1:       []float64{pct(104, 105), 0},
+			horizon2:       []float64{pct(104, 106), 0},
+			baselineTrades: 4,
+		},
+		{
+			name:   "lookback",
+			modify: func(p *Params) { p.From, p.To, p.LookbackDays = day(5), day(5), 1 },
+			// Al cierre del día 5 la ventana empieza el día 4 y no incluye ningún evento
+			picks: 0, tickers: 0, withoutPrices: 0, signalDays: 1,
+			baselineTrades: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := base
+			if tt.modify != nil {
+				tt.modify(&params)
+			}
+			if err := params.Validate(); err != nil {
+				t.Fatalf("Validate() error: %v", err)
+			}
+
+			strategy := &recordingStrategy{scores: scores}
+			result := Run(strategy, events, prices, params)
+
+			if len(strategy.lookahead) > 0 {
+				t.Errorf("strategy received events outside its window: %v", strategy.lookahead)
+			}
+			if result.SignalDays != tt.signalDays || result.Picks != tt.picks || result.Tickers != tt.tickers || result.PicksWithoutPrices != tt.withoutPrices {
+				t.Errorf("signal days %d, picks %d, tickers %d, without prices %d; want %d, %d, %d, %d",
+					result.SignalDays, result.Picks, result.Tickers, result.PicksWithoutPrices, tt.signalDays, tt.picks, tt.tickers, tt.withoutPrices)
+			}
+			if len(result.Horizons) != 2 {
+				t.Fatalf("horizons = %+v, want 2", result.Horizons)
+			}
+
+			for i, returns := range [][]float64{tt.horizon1, tt.horizon2} {
+				h := result.Horizons[i]
+				if h.Days != params.Horizons[i] || h.Trades != len(returns) || h.BaselineTrades != tt.baselineTrades {
+					t.Errorf("horizon %d: days %d, trades %d, baseline trades %d; want %d, %d, %d",
+						i, h.Days, h.Trades, h.BaselineTrades, params.Horizons[i], len(returns), tt.baselineTrades)
+				}
+				if len(returns) == 0 {
+					if h.AvgReturnPct != nil || h.HitRate != nil || h.ExcessReturnPct != nil {
+						t.Errorf("horizon %d without trades has statistics: %+v", i, h)
+					}
+					continue
+				}
+
+				hits := 0
+				for _, r := range returns {
+					if r > 0 {
+						hits++
+					}
+				}
+				if h.AvgReturnPct == nil || math.Abs(*h.AvgReturnPct-mean(returns...)) > 1e-9 {
+					t.Errorf("horizon %d avg_return_pct = %v, want %v", i, h.AvgReturnPct, mean(returns...))
+				}
+				if h.HitRate == nil || *h.HitRate != float64(hits)/float64(len(returns)) {
+					t.Errorf("horizon %d hit_rate = %v, want %d/%d", i, h.HitRate, hits, len(returns))
+				}
+				if h.ExcessReturnPct == nil || math.Abs(*h.ExcessReturnPct-(*h.AvgReturnPct-*h.BaselineAvgReturnPct)) > 1e-9 {
+					t.Errorf("horizon %d excess_return_pct = %v, want avg minus baseline", i, h.ExcessReturnPct)
+				}
+			}
+		})
+	}
+}
+
+func TestRunBaseline(t *testing.T) {
+	prices := NewPriceHistory()
+	for d := 1; d <= 5; d++ {
+		prices.Add("AAA", day(d), 100+float64(d))
+		prices.Add("BBB", day(d), 50)
+	}
+
+	params := Params{From: day(1), To: day(2), Horizons: []int{1}}.WithDefaults()
+	result := Run(&recordingStrategy{}, nil, prices, params)
+
+	// Cada día de señal compra todos los tickers con precios al cierre del día siguiente
+	h := result.Horizons[0]
+	want := mean(pct(102, 103), 0, pct(103, 104), 0)
+	if h.BaselineTrades != 4 || h.BaselineAvgReturnPct == nil || math.Abs(*h.BaselineAvgReturnPct-want) > 1e-9 {
+		t.Errorf("baseline trades %d, avg %v; want 4, %v", h.BaselineTrades, h.BaselineAvgReturnPct, want)
+	}
+	if h.BaselineHitRate == nil || *h.BaselineHitRate != 0.5 {
+		t.Errorf("baseline hit_rate = %v, want 0.5", h.BaselineHitRate)
+	}
+	if h.Trades != 0 || h.ExcessReturnPct != nil {
+		t.Errorf("trades %d, excess %v; want no picks", h.Trades, h.ExcessReturnPct)
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   []string
+	}{
+		{"defaults", Params{From: day(1), To: day(31)}, nil},
+		{"single day", Params{From: day(1).Add(10 * time.Hour), To: day(1)}, nil},
+		{"missing dates", Params{}, []string{"from and to are required"}},
+		{"reversed dates", Params{From: day(2), To: day(1)}, []string{"from must not be after to"}},
+		{"range too long", Params{From: day(1), To: day(1).AddDate(6, 0, 0)}, []string{"must not exceed 5 years"}},
+		{"out of range", Params{From: day(1), To: day(2), LookbackDays: 400, Limit: 101, MinScore: -1, Horizons: []int{5, 0}, RebalanceDays: 91},
+			[]string{"lookback_days", "limit", "min_score", "horizons", "rebalance_days"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.WithDefaults().Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidParams) || !errors.Is(err, models.ErrInvalidArgument) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidParams", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	params := Params{From: day(1).Add(15 * time.Hour), To: day(2)}.WithDefaults()
+	if params.Strategy != recommendation.DefaultStrategyName || params.LookbackDays != 30 || params.Limit != 10 ||
+		len(params.Horizons) != len(DefaultHorizons) || params.RebalanceDays != 1 || !params.From.Equal(day(1)) {
+		t.Errorf("WithDefaults() = %+v", params)
+	}
+}
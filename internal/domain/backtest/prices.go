@@ -0,0 +1,102 @@
+package backtest
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// DailyPrice es el precio de cierre de un ticker en un día de mercado
+type DailyPrice struct {
+	Date  time.Time
+	Close float64
+}
+
+// PriceHistory guarda los precios de cierre diarios por ticker, ordenados por fecha
+type PriceHistory struct {
+	series map[string][]DailyPrice
+	sorted bool
+}
+
+// NewPriceHistory crea un historial de precios vacío
+func NewPriceHistory() *PriceHistory {
+	return &PriceHistory{series: make(map[string][]DailyPrice)}
+}
+
+// Add agrega el cierre de un ticker en una fecha. Un cierre repetido para el mismo
+// día reemplaza al anterior
+func (h *PriceHistory) Add(ticker string, date time.Time, close float64) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	h.series[ticker] = append(h.series[ticker], DailyPrice{Date: truncateDay(date), Close: close})
+	h.sorted = false
+}
+
+// Tickers retorna los tickers con precios, en orden alfabético
+func (h *PriceHistory) Tickers() []string {
+	tickers := make([]string, 0, len(h.series))
+	for ticker := range h.series {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// Len retorna la cantidad total de precios
+func (h *PriceHistory) Len() int {
+	n := 0
+	for _, series := range h.series {
+		n += len(series)
+	}
+	return n
+}
+
+// ForwardReturn calcula el retorno porcentual de comprar al cierre del primer día de
+// mercado posterior a signal y vender days días de mercado después. Entrar al día
+// siguiente evita usar el cierre del mismo día en que se conoce la señal
+func (h *PriceHistory) ForwardReturn(ticker string, signal time.Time, days int) (float64, bool) {
+	h.sort()
+	return h.forwardReturn(ticker, signal, days)
+}
+
+// forwardReturn es ForwardReturn sobre series ya ordenadas
+func (h *PriceHistory) forwardReturn(ticker string, signal time.Time, days int) (float64, bool) {
+	series := h.series[strings.ToUpper(ticker)]
+	day := truncateDay(signal)
+
+	entry := sort.Search(len(series), func(i int) bool { return series[i].Date.After(day) })
+	exit := entry + days
+	if entry >= len(series) || exit >= len(series) || series[entry].Close <= 0 {
+		return 0, false
+	}
+
+	return (series[exit].Close/series[entry].Close - 1) * 100, true
+}
+
+// sort ordena cada serie por fecha y elimina días repetidos conservando el último valor agregado
+func (h *PriceHistory) sort() {
+	if h.sorted {
+		return
+	}
+
+	for ticker, series := range h.series {
+		sort.SliceStable(series, func(i, j int) bool { return series[i].Date.Before(series[j].Date) })
+
+		unique := series[:0]
+		for _, price := range series {
+			if n := len(unique); n > 0 && unique[n-1].Date.Equal(price.Date) {
+				unique[n-1] = price
+				continue
+			}
+			unique = append(unique, price)
+		}
+		h.series[ticker] = unique
+	}
+
+	h.sorted = true
+}
+
+// truncateDay lleva una fecha a la medianoche UTC de su día
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
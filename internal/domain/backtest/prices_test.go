@@ -0,0 +1,63 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// day retorna la fecha del día dado de marzo de 2025
+func day(d int) time.Time {
+	return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestForwardReturn(t *testing.T) {
+	prices := NewPriceHistory()
+	// Días de mercado del 3 al 7 y del 10 al 12 de marzo; el 8 y el 9 son fin de semana
+	for d, close := range map[int]float64{3: 100, 4: 102, 5: 104, 6: 100, 7: 110, 10: 121, 11: 99, 12: 100} {
+		prices.Add("aaa ", day(d), close)
+	}
+	// Un cierre repetido reemplaza al anterior
+	prices.Add("AAA", day(5).Add(20*time.Hour), 105)
+	prices.Add("ZERO", day(3), 0)
+	prices.Add("ZERO", day(4), 10)
+	prices.Add("ZERO", day(5), 20)
+
+	tests := []struct {
+		name   string
+		ticker string
+		signal time.Time
+		days   int
+		want   float64
+		ok     bool
+	}{
+		// La señal del 3 entra al cierre del 4, no al del mismo día
+		{"next day entry", "AAA", day(3), 1, (105.0/102 - 1) * 100, true},
+		{"signal during the day", "AAA", day(3).Add(15 * time.Hour), 2, (100.0/102 - 1) * 100, true},
+		{"lowercase ticker", "aaa", day(3), 1, (105.0/102 - 1) * 100, true},
+		// Una señal del sábado entra el lunes y sale dos días de mercado después
+		{"weekend signal", "AAA", day(8), 2, (100.0/121 - 1) * 100, true},
+		{"signal before history", "AAA", day(1), 1, (102.0/100 - 1) * 100, true},
+		{"exit after history", "AAA", day(10), 2, 0, false},
+		{"entry after history", "AAA", day(12), 1, 0, false},
+		{"unknown ticker", "BBB", day(3), 1, 0, false},
+		{"entry without a price", "ZERO", day(2), 1, 0, false},
+		{"next day with a price", "ZERO", day(3), 1, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := prices.ForwardReturn(tt.ticker, tt.signal, tt.days)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ForwardReturn(%s, %s, %d) = %v, %t; want %v, %t", tt.ticker, tt.signal.Format(time.RFC3339), tt.days, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	if prices.Len() != 11 {
+		t.Errorf("Len() = %d, want 11 after removing the repeated day", prices.Len())
+	}
+	if tickers := prices.Tickers(); len(tickers) != 2 || tickers[0] != "AAA" || tickers[1] != "ZERO" {
+		t.Errorf("Tickers() = %v, want [AAA ZERO]", tickers)
+	}
+}
@@ -0,0 +1,247 @@
+package consensus
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// bucketScores ubica cada categoría en una escala de -2 (strong_sell) a 2 (strong_buy)
+var bucketScores = map[models.RatingBucket]float64{
+	models.RatingStrongBuy:  2,
+	models.RatingBuy:        1,
+	models.RatingHold:       0,
+	models.RatingSell:       -1,
+	models.RatingStrongSell: -2,
+}
+
+// Opinion es la calificación vigente de una casa de bolsa dentro de la ventana
+type Opinion struct {
+	Brokerage string              `json:"brokerage"`
+	Rating    string              `json:"rating"`
+	Bucket    models.RatingBucket `json:"bucket,omitempty"`
+	Target    *models.Price       `json:"target,omitempty"`
+	// Cambio porcentual del precio objetivo en la última revisión de la casa de bolsa
+	TargetChangePct *float64  `json:"target_change_pct,omitempty"`
+	Time            time.Time `json:"time"`
+}
+
+// Consensus resume las opiniones de todas las casas de bolsa sobre un ticker en una ventana
+type Consensus struct {
+	Ticker  string    `json:"ticker"`
+	Company string    `json:"company"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+
+	Events     int `json:"events"`
+	Brokerages int `json:"brokerages"`
+
+	// Opiniones vigentes por categoría; strong_buy cuenta como buy y strong_sell como sell
+	BuyCount      int `json:"buy_count"`
+	HoldCount     int `json:"hold_count"`
+	SellCount     int `json:"sell_count"`
+	UnmappedCount int `json:"unmapped_count"`
+
+	Upgrades   int `json:"upgrades"`
+	Downgrades int `json:"downgrades"`
+
+	// Score promedio de las opiniones vigentes, de -2 (strong_sell) a 2 (strong_buy),
+	// el de las opiniones previas a la ventana y su diferencia
+	Score         *float64 `json:"score"`
+	PreviousScore *float64 `json:"previous_score"`
+	NetChange     *float64 `json:"net_change"`
+
+	// Estadísticas de los precios objetivo vigentes en la moneda más frecuente
+	TargetCurrency      string   `json:"target_currency,omitempty"`
+	MeanTarget          *float64 `json:"mean_target"`
+	MedianTarget        *float64 `json:"median_target"`
+	TargetStdDev        *float64 `json:"target_std_dev"`
+	TargetDispersionPct *float64 `json:"target_dispersion_pct"`
+
+	Opinions []Opinion `json:"opinions"`
+}
+
+// Build calcula el consenso de un ticker con los eventos en [from, to]. La opinión
+// vigente de cada casa de bolsa es su último evento; la previa, el rating de
+// origen de su primer evento en la ventana
+func Build(ticker string, events []models.Stock, from, to time.Time) Consensus {
+	c := Consensus{
+		Ticker:   ticker,
+		From:     from,
+		To:       to,
+		Opinions: []Opinion{},
+	}
+
+	first := make(map[string]models.Stock)
+	latest := make(map[string]models.Stock)
+	var lastSeen time.Time
+
+	for _, event := range events {
+		if event.Ticker != ticker || event.Time.Before(from) || event.Time.After(to) {
+			continue
+		}
+		c.Events++
+
+		if c.Company == "" || event.Time.After(lastSeen) {
+			c.Company, lastSeen = event.Company, event.Time
+		}
+		if existing, ok := first[event.Brokerage]; !ok || event.Time.Before(existing.Time) {
+			first[event.Brokerage] = event
+		}
+		if existing, ok := latest[event.Brokerage]; !ok || event.Time.After(existing.Time) {
+			latest[event.Brokerage] = event
+		}
+
+		fromScore, fromOK := bucketScores[event.RatingFromBucket]
+		toScore, toOK := bucketScores[event.RatingToBucket]
+		if fromOK && toOK {
+			if toScore > fromScore {
+				c.Upgrades++
+			} else if toScore < fromScore {
+				c.Downgrades++
+			}
+		}
+	}
+
+	c.Brokerages = len(latest)
+
+	var current, previous []float64
+	for brokerage, event := range latest {
+		event = withParsedTargets(event)
+		opinion := Opinion{
+			Brokerage:       brokerage,
+			Rating:          event.RatingTo,
+			Bucket:          event.RatingToBucket,
+			Target:          event.TargetToPrice,
+			TargetChangePct: event.TargetChangePct,
+			Time:            event.Time,
+		}
+		c.Opinions = append(c.Opinions, opinion)
+
+		switch opinion.Bucket {
+		case models.RatingStrongBuy, models.RatingBuy:
+			c.BuyCount++
+		case models.RatingHold:
+			c.HoldCount++
+		case models.RatingSell, models.RatingStrongSell:
+			c.SellCount++
+		default:
+			c.UnmappedCount++
+		}
+
+		if score, ok := bucketScores[opinion.Bucket]; ok {
+			current = append(current, score)
+		}
+		if score, ok := bucketScores[first[brokerage].RatingFromBucket]; ok {
+			previous = append(previous, score)
+		}
+	}
+
+	sort.Slice(c.Opinions, func(i, j int) bool {
+		if !c.Opinions[i].Time.Equal(c.Opinions[j].Time) {
+			return c.Opinions[i].Time.After(c.Opinions[j].Time)
+		}
+		return c.Opinions[i].Brokerage < c.Opinions[j].Brokerage
+	})
+
+	c.Score = mean(current)
+	c.PreviousScore = mean(previous)
+	if c.Score != nil && c.PreviousScore != nil {
+		change := *c.Score - *c.PreviousScore
+		c.NetChange = &change
+	}
+
+	c.setTargetStats()
+
+	return c
+}
+
+// BuildAll calcula el consenso de cada ticker presente en los eventos
+func BuildAll(events []models.Stock, from, to time.Time) map[string]Consensus {
+	byTicker := make(map[string][]models.Stock)
+	for _, event := range events {
+		byTicker[event.Ticker] = append(byTicker[event.Ticker], event)
+	}
+
+	consensus := make(map[string]Consensus, len(byTicker))
+	for ticker, tickerEvents := range byTicker {
+		consensus[ticker] = Build(ticker, tickerEvents, from, to)
+	}
+
+	return consensus
+}
+
+// NormalizedScore lleva el score de consenso a una escala de 0 a 100
+func (c Consensus) NormalizedScore() (float64, bool) {
+	if c.Score == nil {
+		return 0, false
+	}
+	return (*c.Score + 2) / 4 * 100, true
+}
+
+// setTargetStats calcula media, mediana y dispersión de los precios objetivo
+// vigentes, usando solo los de la moneda más frecuente
+func (c *Consensus) setTargetStats() {
+	byCurrency := make(map[string][]float64)
+	for _, opinion := range c.Opinions {
+		if opinion.Target != nil {
+			byCurrency[opinion.Target.Currency] = append(byCurrency[opinion.Target.Currency], opinion.Target.Amount)
+		}
+	}
+
+	for currency, amounts := range byCurrency {
+		if len(amounts) > len(byCurrency[c.TargetCurrency]) ||
+			(len(amounts) == len(byCurrency[c.TargetCurrency]) && currency < c.TargetCurrency) {
+			c.TargetCurrency = currency
+		}
+	}
+
+	targets := byCurrency[c.TargetCurrency]
+	if len(targets) == 0 {
+		return
+	}
+
+	sort.Float64s(targets)
+	c.MeanTarget = mean(targets)
+
+	median := targets[len(targets)/2]
+	if len(targets)%2 == 0 {
+		median = (targets[len(targets)/2-1] + targets[len(targets)/2]) / 2
+	}
+	c.MedianTarget = &median
+
+	variance := 0.0
+	for _, target := range targets {
+		variance += (target - *c.MeanTarget) * (target - *c.MeanTarget)
+	}
+	stdDev := math.Sqrt(variance / float64(len(targets)))
+	c.TargetStdDev = &stdDev
+
+	if *c.MeanTarget > 0 {
+		dispersion := stdDev / *c.MeanTarget * 100
+		c.TargetDispersionPct = &dispersion
+	}
+}
+
+// withParsedTargets interpreta los precios objetivo si el evento aún no los tiene
+func withParsedTargets(event models.Stock) models.Stock {
+	if event.TargetFromPrice == nil && event.TargetToPrice == nil {
+		event.ParseTargets()
+	}
+	return event
+}
+
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	m := sum / float64(len(values))
+	return &m
+}
@@ -0,0 +1,180 @@
+package consensus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+var (
+	from = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to   = time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// day retorna la fecha del día dado de marzo de 2025
+func day(d int) time.Time {
+	return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+// testEvent construye un evento con las calificaciones clasificadas; los precios
+// objetivo se interpretan en Build
+func testEvent(ticker, brokerage, ratingFrom, ratingTo, targetFrom, targetTo string, at time.Time) models.Stock {
+	stock := models.Stock{
+		Ticker: ticker, Company: ticker + " Inc.", Brokerage: brokerage,
+		RatingFrom: ratingFrom, RatingTo: ratingTo, TargetFrom: targetFrom, TargetTo: targetTo, Time: at,
+	}
+	stock.ClassifyRatings(models.DefaultRatingTaxonomy())
+	return stock
+}
+
+func approx(t *testing.T, name string, got *float64, want float64) {
+	t.Helper()
+	if got == nil || math.Abs(*got-want) > 1e-6 {
+		t.Errorf("%s = %v, want %v", name, deref(got), want)
+	}
+}
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestBuild(t *testing.T) {
+	events := []models.Stock{
+		// Barclays sube dos veces: la opinión vigente es strong_buy y la previa hold
+		testEvent("AAA", "Barclays", "Hold", "Buy", "$100", "$110", day(1)),
+		testEvent("AAA", "Barclays", "Buy", "Strong Buy", "$110", "$130", day(3)),
+		testEvent("AAA", "JPMorgan", "Buy", "Hold", "$120", "$100", day(2)),
+		testEvent("AAA", "Citigroup", "Buy", "Sell", "$90", "$80", day(4)),
+		// Sin categoría y en otra moneda: no cuenta para el score ni para los precios objetivo
+		testEvent("AAA", "Deutsche Bank", "Speculative", "Speculative", "€140", "€150", day(2)),
+		// Fuera de la ventana o de otro ticker
+		testEvent("AAA", "Goldman Sachs", "Hold", "Strong Buy", "$100", "$200", from.Add(-time.Second)),
+		testEvent("BBB", "Barclays", "Hold", "Buy", "$10", "$20", day(2)),
+	}
+
+	c := Build("AAA", events, from, to)
+
+	if c.Company != "AAA Inc." || c.Events != 5 || c.Brokerages != 4 {
+		t.Errorf("company %q, events %d, brokerages %d; want AAA Inc., 5, 4", c.Company, c.Events, c.Brokerages)
+	}
+	if c.BuyCount != 1 || c.HoldCount != 1 || c.SellCount != 1 || c.UnmappedCount != 1 {
+		t.Errorf("buy %d, hold %d, sell %d, unmapped %d; want 1 each", c.BuyCount, c.HoldCount, c.SellCount, c.UnmappedCount)
+	}
+	if c.Upgrades != 2 || c.Downgrades != 2 {
+		t.Errorf("upgrades %d, downgrades %d; want 2, 2", c.Upgrades, c.Downgrades)
+	}
+
+	// Vigente: strong_buy (2), hold (0), sell (-1). Previa: hold (0), buy (1), buy (1)
+	approx(t, "score", c.Score, 1.0/3)
+	approx(t, "previous_score", c.PreviousScore, 2.0/3)
+	approx(t, "net_change", c.NetChange, -1.0/3)
+
+	// Precios objetivo vigentes en USD: 80, 100, 130
+	if c.TargetCurrency != "USD" {
+		t.Errorf("target_currency = %q, want USD", c.TargetCurrency)
+	}
+	approx(t, "mean_target", c.MeanTarget, 310.0/3)
+	approx(t, "median_target", c.MedianTarget, 100)
+	stdDev := math.Sqrt((math.Pow(80-310.0/3, 2) + math.Pow(100-310.0/3, 2) + math.Pow(130-310.0/3, 2)) / 3)
+	approx(t, "target_std_dev", c.TargetStdDev, stdDev)
+	approx(t, "target_dispersion_pct", c.TargetDispersionPct, stdDev/(310.0/3)*100)
+
+	// Las opiniones se ordenan de la más reciente a la más antigua
+	wantOpinions := []string{"Citigroup", "Barclays", "Deutsche Bank", "JPMorgan"}
+	if len(c.Opinions) != len(wantOpinions) {
+		t.Fatalf("opinions = %+v, want %v", c.Opinions, wantOpinions)
+	}
+	for i, opinion := range c.Opinions {
+		if opinion.Brokerage != wantOpinions[i] {
+			t.Errorf("opinions[%d] = %s, want %s", i, opinion.Brokerage, wantOpinions[i])
+		}
+	}
+	barclays := c.Opinions[1]
+	if barclays.Rating != "Strong Buy" || barclays.Bucket != models.RatingStrongBuy || barclays.Target.Amount != 130 {
+		t.Errorf("Barclays opinion = %+v, want its latest event", barclays)
+	}
+	approx(t, "Barclays target_change_pct", barclays.TargetChangePct, (130.0-110)/110*100)
+
+	if normalized, ok := c.NormalizedScore(); !ok || math.Abs(normalized-(1.0/3+2)/4*100) > 1e-6 {
+		t.Errorf("NormalizedScore() = %v, %t", normalized, ok)
+	}
+}
+
+func TestBuildTargetStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    []string
+		currency   string
+		median     float64
+		dispersion float64
+	}{
+		{"odd count", []string{"$90", "$100", "$140"}, "USD", 100, math.Sqrt(1400.0/3) / 110 * 100},
+		{"even count", []string{"$100", "$120", "$90", "$130"}, "USD", 110, math.Sqrt(250) / 110 * 100},
+		{"same targets", []string{"$100", "$100"}, "USD", 100, 0},
+		{"most frequent currency", []string{"€50", "$100", "€70"}, "EUR", 60, 10.0 / 60 * 100},
+		{"currency tie", []string{"$100", "€50"}, "EUR", 50, 0},
+	}
+
+	brokerages := []string{"Barclays", "JPMorgan", "Citigroup", "Goldman Sachs"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []models.Stock
+			for i, target := range tt.targets {
+				events = append(events, testEvent("AAA", brokerages[i], "Buy", "Buy", "", target, day(i+1)))
+			}
+
+			c := Build("AAA", events, from, to)
+			if c.TargetCurrency != tt.currency {
+				t.Errorf("target_currency = %q, want %q", c.TargetCurrency, tt.currency)
+			}
+			approx(t, "median_target", c.MedianTarget, tt.median)
+			approx(t, "target_dispersion_pct", c.TargetDispersionPct, tt.dispersion)
+		})
+	}
+}
+
+func TestBuildWithoutData(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []models.Stock
+		count  int
+	}{
+		{"no events", nil, 0},
+		{"only unmapped ratings without targets", []models.Stock{testEvent("AAA", "Barclays", "Speculative", "Speculative", "", "", day(1))}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Build("AAA", tt.events, from, to)
+			if c.Events != tt.count || c.Opinions == nil {
+				t.Errorf("events %d, opinions %v; want %d and a non-nil list", c.Events, c.Opinions, tt.count)
+			}
+			if c.Score != nil || c.PreviousScore != nil || c.NetChange != nil || c.MedianTarget != nil || c.TargetDispersionPct != nil {
+				t.Errorf("consensus without data has statistics: %+v", c)
+			}
+			if _, ok := c.NormalizedScore(); ok {
+				t.Error("NormalizedScore() is available without a score")
+			}
+		})
+	}
+}
+
+func TestBuildAll(t *testing.T) {
+	events := []models.Stock{
+		testEvent("AAA", "Barclays", "Hold", "Buy", "", "", day(1)),
+		testEvent("BBB", "Barclays", "Hold", "Sell", "", "", day(2)),
+		testEvent("BBB", "JPMorgan", "Hold", "Sell", "", "", day(3)),
+	}
+
+	all := BuildAll(events, from, to)
+	if len(all) != 2 || all["AAA"].Brokerages != 1 || all["BBB"].Brokerages != 2 {
+		t.Errorf("BuildAll() = %+v, want AAA with 1 brokerage and BBB with 2", all)
+	}
+	approx(t, "BBB score", all["BBB"].Score, -1)
+}
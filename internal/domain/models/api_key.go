@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Role define los permisos de una API key
+type Role string
+
+const (
+	// RoleReader permite consultar los datos
+	RoleReader Role = "reader"
+
+	// RoleAdmin permite además las operaciones de escritura y administrar las API keys
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole valida el nombre de un rol
+func ParseRole(value string) (Role, error) {
+	switch role := Role(value); role {
+	case RoleReader, RoleAdmin:
+		return role, nil
+	default:
+		return "", fmt.Errorf("invalid role: %q (use %s or %s)", value, RoleReader, RoleAdmin)
+	}
+}
+
+// Allows indica si el rol incluye los permisos de required. admin incluye reader
+func (r Role) Allows(required Role) bool {
+	if r == RoleAdmin {
+		return true
+	}
+	return r == required
+}
+
+// APIKey describe una API key. Solo se guarda el hash de la clave; Prefix son
+// sus primeros caracteres, para reconocerla sin exponerla. DailyQuota limita las
+// solicitudes por día UTC; 0 usa la cuota por defecto del servicio
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Role       Role       `json:"role"`
+	DailyQuota int        `json:"daily_quota"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Revoked indica si la API key fue revocada
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
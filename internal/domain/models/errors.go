@@ -0,0 +1,46 @@
+package models
+
+import "errors"
+
+// Categorías de error del dominio. Los errores específicos pertenecen a una
+// categoría, de modo que los adaptadores pueden tratarlos con errors.Is sin
+// conocer cada error concreto
+var (
+	// ErrNotFound indica que el recurso solicitado no existe
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidArgument indica un parámetro inválido en la solicitud
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrConflict indica que la operación choca con el estado actual
+	ErrConflict = errors.New("conflict")
+
+	// ErrUnavailable indica que una dependencia externa no está disponible
+	ErrUnavailable = errors.New("unavailable")
+
+	// ErrMisconfigured indica que el servicio está mal configurado, por ejemplo con
+	// credenciales rechazadas por una dependencia externa
+	ErrMisconfigured = errors.New("misconfigured")
+
+	// ErrUnauthenticated indica que la solicitud no tiene credenciales válidas
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrRateLimited indica que el cliente superó su límite de solicitudes
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// categorizedError es un error con mensaje propio que pertenece a una categoría
+type categorizedError struct {
+	msg      string
+	category error
+}
+
+func (e *categorizedError) Error() string { return e.msg }
+
+func (e *categorizedError) Unwrap() error { return e.category }
+
+// NewError crea un error con el mensaje dado que pertenece a la categoría indicada,
+// por ejemplo NewError(ErrNotFound, "sync job not found")
+func NewError(category error, msg string) error {
+	return &categorizedError{msg: msg, category: category}
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidPrice se retorna cuando un precio objetivo no puede interpretarse
+var ErrInvalidPrice = errors.New("invalid price")
+
+// Price representa un precio objetivo interpretado
+type Price struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// currencySymbols asocia prefijos y sufijos conocidos con su código ISO 4217.
+// Los símbolos compuestos van primero para que "R$" no se interprete como "$"
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"US$", "USD"},
+	{"C$", "CAD"},
+	{"CA$", "CAD"},
+	{"A$", "AUD"},
+	{"AU$", "AUD"},
+	{"R$", "BRL"},
+	{"HK$", "HKD"},
+	{"$", "USD"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+	{"₹", "INR"},
+	{"CHF", "CHF"},
+	{"GBX", "GBX"},
+	{"GBp", "GBX"},
+	{"p", "GBX"},
+}
+
+// ParsePrice interpreta precios como "$12.50", "$1,234.50", "€12,50", "12.50 USD" o "GBX 250".
+// Retorna ErrInvalidPrice si el valor está vacío o no es un precio reconocible
+func ParsePrice(raw string) (Price, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return Price{}, fmt.Errorf("%w: empty value", ErrInvalidPrice)
+	}
+
+	currency := ""
+
+	// Código ISO de tres letras como prefijo o sufijo ("USD 12.50", "12.50 EUR")
+	if fields := strings.Fields(value); len(fields) == 2 {
+		if isCurrencyCode(fields[0]) {
+			currency, value = fields[0], fields[1]
+		} else if isCurrencyCode(fields[1]) {
+			currency, value = fields[1], fields[0]
+		}
+	}
+
+	if currency == "" {
+		for _, cs := range currencySymbols {
+			if strings.HasPrefix(value, cs.symbol) {
+				currency, value = cs.code, strings.TrimSpace(strings.TrimPrefix(value, cs.symbol))
+				break
+			}
+			if strings.HasSuffix(value, cs.symbol) {
+				currency, value = cs.code, strings.TrimSpace(strings.TrimSuffix(value, cs.symbol))
+				break
+			}
+		}
+	}
+
+	if currency == "" {
+		return Price{}, fmt.Errorf("%w: unknown currency in %q", ErrInvalidPrice, raw)
+	}
+
+	amount, err := parseAmount(value)
+	if err != nil {
+		return Price{}, fmt.Errorf("%w: %q", ErrInvalidPrice, raw)
+	}
+
+	return Price{Amount: amount, Currency: currency}, nil
+}
+
+// parseAmount interpreta separadores de miles y decimales en formato inglés o europeo
+func parseAmount(value string) (float64, error) {
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// El último separador es el decimal: "1,234.50" o "1.234,50"
+		if lastComma > lastDot {
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case lastComma >= 0:
+		// Solo comas: miles si todos los grupos tienen tres dígitos ("1,234"), decimal en otro caso ("12,50")
+		if strings.Count(value, ",") > 1 || len(value)-lastComma-1 == 3 {
+			value = strings.ReplaceAll(value, ",", "")
+		} else {
+			value = strings.Replace(value, ",", ".", 1)
+		}
+	}
+
+	for _, r := range value {
+		if !unicode.IsDigit(r) && r != '.' {
+			return 0, ErrInvalidPrice
+		}
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) {
+		return 0, ErrInvalidPrice
+	}
+
+	return amount, nil
+}
+
+// currencyCodes son los códigos ISO 4217 aceptados como prefijo o sufijo, más GBX
+// (peniques) que usan las casas de bolsa británicas
+var currencyCodes = map[string]bool{
+	"USD": true, "EUR": true, "GBP": true, "GBX": true, "JPY": true, "CHF": true,
+	"CAD": true, "AUD": true, "NZD": true, "HKD": true, "SGD": true, "CNY": true,
+	"INR": true, "BRL": true, "MXN": true, "KRW": true, "TWD": true, "ZAR": true,
+	"SEK": true, "NOK": true, "DKK": true, "PLN": true, "ILS": true,
+}
+
+// isCurrencyCode indica si el texto es un código de moneda conocido. Se exigen
+// mayúsculas, ya que "GBp" son peniques (GBX) y no libras
+func isCurrencyCode(s string) bool {
+	return currencyCodes[s]
+}
+
+// PercentChange calcula el cambio porcentual entre dos precios de la misma moneda
+func PercentChange(from, to Price) (float64, bool) {
+	if from.Amount <= 0 || from.Currency != to.Currency {
+		return 0, false
+	}
+	return ((to.Amount - from.Amount) / from.Amount) * 100, true
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		raw      string
+		amount   float64
+		currency string
+	}{
+		{"$12.50", 12.50, "USD"},
+		{" $12.50 ", 12.50, "USD"},
+		{"$1,234.50", 1234.50, "USD"},
+		{"$1,234", 1234, "USD"},
+		{"€12,50", 12.50, "EUR"},
+		{"€1.234,50", 1234.50, "EUR"},
+		{"12,50 €", 12.50, "EUR"},
+		{"£8", 8, "GBP"},
+		{"12.50 USD", 12.50, "USD"},
+		{"EUR 12.50", 12.50, "EUR"},
+		{"GBX 250", 250, "GBX"},
+		{"GBp 250", 250, "GBX"},
+		{"250p", 250, "GBX"},
+		{"R$ 10.00", 10, "BRL"},
+		{"C$15", 15, "CAD"},
+		{"US$7", 7, "USD"},
+		{"HK$45.10", 45.10, "HKD"},
+		{"CHF 90", 90, "CHF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			price, err := ParsePrice(tt.raw)
+			if err != nil {
+				t.Fatalf("ParsePrice(%q) error: %v", tt.raw, err)
+			}
+			if math.Abs(price.Amount-tt.amount) > 1e-9 || price.Currency != tt.currency {
+				t.Errorf("ParsePrice(%q) = %v %s, want %v %s", tt.raw, price.Amount, price.Currency, tt.amount, tt.currency)
+			}
+		})
+	}
+}
+
+func TestParsePriceInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"N/A",
+		"12.50",
+		"12.50 abc",
+		"ABC 12.50",
+		"$",
+		"$-5",
+		"$12.5x",
+		"$1e3",
+		"12.50 USD EUR",
+	}
+
+	for _, raw := range tests {
+		t.Run(raw, func(t *testing.T) {
+			if price, err := ParsePrice(raw); !errors.Is(err, ErrInvalidPrice) {
+				t.Errorf("ParsePrice(%q) = %v, %v; want ErrInvalidPrice", raw, price, err)
+			}
+		})
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Price
+		change   float64
+		ok       bool
+	}{
+		{"increase", Price{10, "USD"}, Price{15, "USD"}, 50, true},
+		{"decrease", Price{20, "USD"}, Price{15, "USD"}, -25, true},
+		{"different currency", Price{10, "USD"}, Price{15, "EUR"}, 0, false},
+		{"zero base", Price{0, "USD"}, Price{15, "USD"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, ok := PercentChange(tt.from, tt.to)
+			if ok != tt.ok || math.Abs(change-tt.change) > 1e-9 {
+				t.Errorf("PercentChange(%v, %v) = %v, %t; want %v, %t", tt.from, tt.to, change, ok, tt.change, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+)
+
+// RatingBucket es la categoría canónica de una calificación de analista
+type RatingBucket string
+
+const (
+	RatingStrongBuy  RatingBucket = "strong_buy"
+	RatingBuy        RatingBucket = "buy"
+	RatingHold       RatingBucket = "hold"
+	RatingSell       RatingBucket = "sell"
+	RatingStrongSell RatingBucket = "strong_sell"
+)
+
+// RatingBuckets lista las categorías canónicas de mayor a menor
+var RatingBuckets = []RatingBucket{RatingStrongBuy, RatingBuy, RatingHold, RatingSell, RatingStrongSell}
+
+// IsValid indica si la categoría es una de las canónicas
+func (b RatingBucket) IsValid() bool {
+	for _, bucket := range RatingBuckets {
+		if b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmappedRating es una calificación presente en los datos que no corresponde a ninguna categoría
+type UnmappedRating struct {
+	Rating     string   `json:"rating"`
+	Count      int      `json:"count"`
+	Brokerages []string `json:"brokerages"`
+}
+
+// RatingTaxonomy asocia las calificaciones publicadas por las casas de bolsa con
+// categorías canónicas. La comparación ignora mayúsculas, espacios repetidos y guiones
+type RatingTaxonomy struct {
+	aliases    map[string]RatingBucket
+	brokerages map[string]map[string]RatingBucket
+}
+
+// NewRatingTaxonomy crea una taxonomía con alias globales y alias por casa de bolsa.
+// Los alias de una casa de bolsa tienen prioridad sobre los globales
+func NewRatingTaxonomy(aliases map[string]RatingBucket, brokerages map[string]map[string]RatingBucket) *RatingTaxonomy {
+	t := &RatingTaxonomy{
+		aliases:    make(map[string]RatingBucket, len(aliases)),
+		brokerages: make(map[string]map[string]RatingBucket, len(brokerages)),
+	}
+
+	for alias, bucket := range aliases {
+		t.aliases[normalizeRating(alias)] = bucket
+	}
+	for brokerage, brokerageAliases := range brokerages {
+		normalized := make(map[string]RatingBucket, len(brokerageAliases))
+		for alias, bucket := range brokerageAliases {
+			normalized[normalizeRating(alias)] = bucket
+		}
+		t.brokerages[normalizeRating(brokerage)] = normalized
+	}
+
+	return t
+}
+
+// Classify retorna la categoría de una calificación publicada por una casa de bolsa.
+// También acepta directamente el nombre de una categoría ("strong_buy", "Strong Buy")
+func (t *RatingTaxonomy) Classify(brokerage, rating string) (RatingBucket, bool) {
+	key := normalizeRating(rating)
+	if key == "" {
+		return "", false
+	}
+
+	if brokerageAliases, ok := t.brokerages[normalizeRating(brokerage)]; ok {
+		if bucket, ok := brokerageAliases[key]; ok {
+			return bucket, true
+		}
+	}
+	if bucket, ok := t.aliases[key]; ok {
+		return bucket, true
+	}
+	if bucket := RatingBucket(strings.ReplaceAll(key, " ", "_")); bucket.IsValid() {
+		return bucket, true
+	}
+
+	return "", false
+}
+
+// normalizeRating pasa a minúsculas, trata guiones y guiones bajos como espacios y colapsa los espacios
+func normalizeRating(value string) string {
+	value = strings.ToLower(value)
+	value = strings.NewReplacer("-", " ", "_", " ").Replace(value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// DefaultRatingTaxonomy retorna la taxonomía con las calificaciones conocidas de la API
+func DefaultRatingTaxonomy() *RatingTaxonomy {
+	return NewRatingTaxonomy(
+		map[string]RatingBucket{
+			"Strong Buy":          RatingStrongBuy,
+			"Conviction Buy":      RatingStrongBuy,
+			"Top Pick":            RatingStrongBuy,
+			"Strong-Buy":          RatingStrongBuy,
+			"Buy":                 RatingBuy,
+			"Outperform":          RatingBuy,
+			"Outperformer":        RatingBuy,
+			"Overweight":          RatingBuy,
+			"Positive":            RatingBuy,
+			"Accumulate":          RatingBuy,
+			"Add":                 RatingBuy,
+			"Speculative Buy":     RatingBuy,
+			"Moderate Buy":        RatingBuy,
+			"Sector Outperform":   RatingBuy,
+			"Market Outperform":   RatingBuy,
+			"Industry Outperform": RatingBuy,
+			"Hold":                RatingHold,
+			"Neutral":             RatingHold,
+			"Equal Weight":        RatingHold,
+			"Market Perform":      RatingHold,
+			"Sector Perform":      RatingHold,
+			"Peer Perform":        RatingHold,
+			"In-Line":             RatingHold,
+			"Inline":              RatingHold,
+			"Sector Weight":       RatingHold,
+			"Market Weight":       RatingHold,
+			"Mixed":               RatingHold,
+			"Fair Value":          RatingHold,
+			"Underperform":        RatingSell,
+			"Underweight":         RatingSell,
+			"Negative":            RatingSell,
+			"Reduce":              RatingSell,
+			"Sector Underperform": RatingSell,
+			"Market Underperform": RatingSell,
+			"Underperformer":      RatingSell,
+			"Moderate Sell":       RatingSell,
+			"Sell":                RatingSell,
+			"Strong Sell":         RatingStrongSell,
+		},
+		map[string]map[string]RatingBucket{
+			// Calificaciones que solo tienen sentido en la escala propia de cada casa de bolsa
+			"Oppenheimer":    {"Perform": RatingHold},
+			"Wolfe Research": {"Peer Outperform": RatingBuy, "Peer Underperform": RatingSell},
+		},
+	)
+}
@@ -0,0 +1,88 @@
+package models
+
+import "testing"
+
+func TestRatingTaxonomyClassify(t *testing.T) {
+	taxonomy := DefaultRatingTaxonomy()
+
+	tests := []struct {
+		brokerage string
+		rating    string
+		want      RatingBucket
+		ok        bool
+	}{
+		{"", "Buy", RatingBuy, true},
+		{"", "  STRONG   buy ", RatingStrongBuy, true},
+		{"", "Strong-Buy", RatingStrongBuy, true},
+		{"", "strong_sell", RatingStrongSell, true},
+		{"", "Outperform", RatingBuy, true},
+		{"", "Equal-Weight", RatingHold, true},
+		{"", "In-Line", RatingHold, true},
+		{"", "inline", RatingHold, true},
+		{"", "Market Underperform", RatingSell, true},
+		{"", "Sell", RatingSell, true},
+		{"Barclays", "Top Pick", RatingStrongBuy, true},
+		// Los alias de una casa de bolsa solo aplican a esa casa de bolsa
+		{"Oppenheimer", "Perform", RatingHold, true},
+		{"oppenheimer", "PERFORM", RatingHold, true},
+		{"Barclays", "Perform", "", false},
+		{"Wolfe Research", "Peer Outperform", RatingBuy, true},
+		{"Wolfe Research", "Peer Underperform", RatingSell, true},
+		{"", "Speculative", "", false},
+		{"", "", "", false},
+		{"", "   ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.brokerage+"/"+tt.rating, func(t *testing.T) {
+			got, ok := taxonomy.Classify(tt.brokerage, tt.rating)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Classify(%q, %q) = %q, %t; want %q, %t", tt.brokerage, tt.rating, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRatingTaxonomyBrokerageAliasesTakePriority(t *testing.T) {
+	taxonomy := NewRatingTaxonomy(
+		map[string]RatingBucket{"Outperform": RatingBuy},
+		map[string]map[string]RatingBucket{"Acme Capital": {"outperform": RatingStrongBuy}},
+	)
+
+	tests := []struct {
+		brokerage string
+		want      RatingBucket
+	}{
+		{"Acme Capital", RatingStrongBuy},
+		{"ACME-capital", RatingStrongBuy},
+		{"Barclays", RatingBuy},
+	}
+
+	for _, tt := range tests {
+		if got, _ := taxonomy.Classify(tt.brokerage, "Outperform"); got != tt.want {
+			t.Errorf("Classify(%q, Outperform) = %q, want %q", tt.brokerage, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyRatings(t *testing.T) {
+	stock := Stock{Brokerage: "Oppenheimer", RatingFrom: "Perform", RatingTo: "Speculative"}
+	stock.ClassifyRatings(DefaultRatingTaxonomy())
+
+	if stock.RatingFromBucket != RatingHold || stock.RatingToBucket != "" {
+		t.Errorf("buckets = %q -> %q, want hold -> none", stock.RatingFromBucket, stock.RatingToBucket)
+	}
+}
+
+func TestRatingBucketIsValid(t *testing.T) {
+	for _, bucket := range RatingBuckets {
+		if !bucket.IsValid() {
+			t.Errorf("%q.IsValid() = false", bucket)
+		}
+	}
+	for _, bucket := range []RatingBucket{"", "Buy", "strong buy", "outperform"} {
+		if bucket.IsValid() {
+			t.Errorf("%q.IsValid() = true", bucket)
+		}
+	}
+}
@@ -15,4 +15,52 @@ type Stock struct {
 	RatingFrom string    `json:"rating_from"`
 	RatingTo   string    `json:"rating_to"`
 	Time       time.Time `json:"time"`
+
+	// Precios objetivo interpretados al ingerir los datos, nil si no pudieron interpretarse
+	TargetFromPrice *Price   `json:"target_from_price,omitempty"`
+	TargetToPrice   *Price   `json:"target_to_price,omitempty"`
+	TargetChangePct *float64 `json:"target_change_pct,omitempty"`
+
+	// Categorías canónicas de RatingFrom y RatingTo, vacías si la calificación no está en la taxonomía
+	RatingFromBucket RatingBucket `json:"rating_from_bucket,omitempty"`
+	RatingToBucket   RatingBucket `json:"rating_to_bucket,omitempty"`
+}
+
+// ParseTargets interpreta TargetFrom y TargetTo y calcula el cambio porcentual
+// cuando ambos precios están en la misma moneda. Retorna los errores de los
+// valores no vacíos que no pudieron interpretarse
+func (s *Stock) ParseTargets() []error {
+	var errs []error
+
+	s.TargetFromPrice, s.TargetToPrice, s.TargetChangePct = nil, nil, nil
+
+	if s.TargetFrom != "" {
+		if price, err := ParsePrice(s.TargetFrom); err == nil {
+			s.TargetFromPrice = &price
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.TargetTo != "" {
+		if price, err := ParsePrice(s.TargetTo); err == nil {
+			s.TargetToPrice = &price
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.TargetFromPrice != nil && s.TargetToPrice != nil {
+		if change, ok := PercentChange(*s.TargetFromPrice, *s.TargetToPrice); ok {
+			s.TargetChangePct = &change
+		}
+	}
+
+	return errs
+}
+
+// ClassifyRatings asigna las categorías canónicas de RatingFrom y RatingTo
+func (s *Stock) ClassifyRatings(taxonomy *RatingTaxonomy) {
+	s.RatingFromBucket, _ = taxonomy.Classify(s.Brokerage, s.RatingFrom)
+	s.RatingToBucket, _ = taxonomy.Classify(s.Brokerage, s.RatingTo)
 }
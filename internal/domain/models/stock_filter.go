@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// StockFilter agrupa los filtros opcionales para listar stocks. Todos los
+// filtros se combinan con AND; los valores dentro de un mismo campo con OR.
+// Un campo vacío o nil no filtra
+type StockFilter struct {
+	// Coincidencia parcial del ticker, sin distinguir mayúsculas
+	Tickers []string
+
+	// Casas de bolsa exactas
+	Brokerages []string
+
+	// Categorías canónicas que aparecen como rating_from o rating_to
+	RatingBuckets []RatingBucket
+
+	// Ratings sin categoría que aparecen como rating_from o rating_to, sin
+	// distinguir mayúsculas. Se combinan con OR con RatingBuckets
+	Ratings []string
+
+	// Ratings de origen y de destino
+	RatingsFrom []string
+	RatingsTo   []string
+
+	// Acciones exactas, por ejemplo "upgraded by"
+	Actions []string
+
+	// Coincidencia parcial del nombre de la compañía, sin distinguir mayúsculas
+	Company string
+
+	// Rango de fechas de la actualización, ambos extremos inclusive
+	From *time.Time
+	To   *time.Time
+
+	// Rango del precio objetivo final (target_to)
+	MinTarget *float64
+	MaxTarget *float64
+
+	// Rango del cambio porcentual entre target_from y target_to
+	MinTargetChange *float64
+	MaxTargetChange *float64
+}
+
+// StockCursor identifica una posición dentro de un listado de stocks ordenado
+// por OrderBy y SortOrder, usando el ticker como desempate
+type StockCursor struct {
+	OrderBy   string
+	SortOrder string
+
+	// Value es el valor de la columna de ordenamiento en la posición del cursor,
+	// serializado como texto. Null indica que la columna era NULL
+	Value string
+	Null  bool
+
+	Ticker string
+
+	// Backward recorre el listado hacia atrás (página anterior)
+	Backward bool
+}
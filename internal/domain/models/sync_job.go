@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+)
+
+// SyncJobState representa el estado de un trabajo de sincronización
+type SyncJobState string
+
+const (
+	SyncJobQueued    SyncJobState = "queued"
+	SyncJobRunning   SyncJobState = "running"
+	SyncJobSucceeded SyncJobState = "succeeded"
+	SyncJobFailed    SyncJobState = "failed"
+)
+
+// SyncMode indica si una sincronización recorre todo el dataset o solo los datos nuevos
+type SyncMode string
+
+const (
+	SyncModeIncremental SyncMode = "incremental"
+	SyncModeFull        SyncMode = "full"
+)
+
+// SyncJob registra una ejecución de sincronización con la API externa
+type SyncJob struct {
+	ID            string       `json:"id"`
+	State         SyncJobState `json:"state"`
+	Mode          SyncMode     `json:"mode"`
+	Pages         int          `json:"pages"`
+	ItemsFetched  int          `json:"items_fetched"`
+	ItemsInserted int          `json:"items_inserted"`
+	ItemsUpdated  int          `json:"items_updated"`
+	ItemsSkipped  int          `json:"items_skipped"`
+
+	// TargetParseErrors cuenta los precios objetivo que no pudieron interpretarse
+	TargetParseErrors int `json:"target_parse_errors"`
+
+	// Error describe la falla de un trabajo fallido, sin detalles internos
+	Error *SyncJobError `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Códigos de error de los trabajos de sincronización
+const (
+	SyncErrorUpstreamUnavailable = "upstream_unavailable"
+	SyncErrorMisconfigured       = "misconfigured"
+	SyncErrorLeaseLost           = "lease_lost"
+	SyncErrorTimeout             = "timeout"
+	SyncErrorCanceled            = "canceled"
+	SyncErrorInternal            = "internal_error"
+)
+
+// SyncJobError describe la falla de un trabajo con el mismo formato que los errores
+// de la API. El error original solo se registra en los logs, ya que puede incluir
+// detalles de la base de datos o de la API externa
+type SyncJobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SaveResult resume el efecto de guardar un lote de stocks.
+// Inserted cuenta los eventos nuevos agregados al historial y Updated
+// los tickers cuyo estado más reciente cambió
+type SaveResult struct {
+	Inserted int
+	Updated  int
+}
+
+// SyncCheckpoint guarda hasta dónde llegó la última sincronización exitosa. La API
+// externa entrega primero los eventos más recientes, por lo que la sincronización
+// incremental recorre las páginas desde el principio hasta llegar al checkpoint
+type SyncCheckpoint struct {
+	NewestEventTime time.Time `json:"newest_event_time"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
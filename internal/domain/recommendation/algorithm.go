@@ -3,57 +3,79 @@ package recommendation
 import (
 	"fmt"
 	"math"
-	"sort"
-	"strings"
-	"time"
 
 	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
 )
 
-// RecommendationResult representa el resultado de una recomendación
+// RecommendationResult representa el resultado de una recomendación. Breakdown y
+// Reasons explican el score de forma estructurada; Rationale es la versión en prosa
 type RecommendationResult struct {
-	Stock           models.Stock `json:"stock"`
-	Score           float64      `json:"score"`
-	Rationale       string       `json:"rationale"`
-	PotentialReturn string       `json:"potential_return"`
+	Stock           models.Stock    `json:"stock"`
+	Score           float64         `json:"score"`
+	Breakdown       *ScoreBreakdown `json:"breakdown"`
+	Reasons         []Reason        `json:"reasons"`
+	Rationale       string          `json:"rationale,omitempty"`
+	PotentialReturn string          `json:"potential_return"`
 }
 
 // StockRecommender implementa el algoritmo de recomendación
 type StockRecommender struct {
-	ratingValues map[string]float64
+	config ScoringConfig
+	scale  ratingScale
+	clock  Clock
 }
 
-// NewStockRecommender crea una nueva instancia del recomendador
+// NewStockRecommender crea una nueva instancia del recomendador con la configuración por defecto
 func NewStockRecommender() *StockRecommender {
-	return &StockRecommender{
-		ratingValues: map[string]float64{
-			"Strong Buy":     5.0,
-			"Buy":            4.0,
-			"Outperform":     4.0,
-			"Overweight":     3.5,
-			"Neutral":        3.0,
-			"Hold":           3.0,
-			"Equal-Weight":   3.0,
-			"Market Perform": 3.0,
-			"Underperform":   2.0,
-			"Underweight":    2.0,
-			"Sell":           1.0,
-			"Strong Sell":    0.5,
-		},
-	}
-}
-
-// GenerateRecommendations genera recomendaciones basadas en los stocks más recientes
-func (r *StockRecommender) GenerateRecommendations(stocks []models.Stock, limit int) []RecommendationResult {
-	// Paso 1: Agrupar stocks por ticker y quedarnos con la actualización más reciente
-	latestStocks := make(map[string]models.Stock)
+	return newStockRecommender(DefaultScoringConfig())
+}
 
-	for _, stock := range stocks {
-		existing, exists := latestStocks[stock.Ticker]
-		if !exists || stock.Time.After(existing.Time) {
-			latestStocks[stock.Ticker] = stock
-		}
+func newStockRecommender(config ScoringConfig) *StockRecommender {
+	return &StockRecommender{config: config, scale: newRatingScale(config), clock: SystemClock}
+}
+
+// WithClock retorna una copia del recomendador que mide la antigüedad con el reloj dado
+func (r *StockRecommender) WithClock(clock Clock) *StockRecommender {
+	clone := *r
+	clone.clock = clock
+	return &clone
+}
+
+// Config retorna la configuración de scoring en uso
+func (r *StockRecommender) Config() ScoringConfig {
+	return r.config
+}
+
+// Name identifica la estrategia en el registro
+func (r *StockRecommender) Name() string {
+	return DefaultStrategyName
+}
+
+// Description resume el algoritmo
+func (r *StockRecommender) Description() string {
+	return "Combina el cambio de rating, el cambio del precio objetivo y la antigüedad de la última actualización de cada ticker"
+}
+
+// Parameters describe los parámetros vigentes del algoritmo
+func (r *StockRecommender) Parameters() []Parameter {
+	return []Parameter{
+		{Name: "weights", Description: "Peso del rating, del precio objetivo, de la antigüedad y del consenso de casas de bolsa", Value: r.config.Weights},
+		{Name: "rating_change_range", Description: "Cambio de rating que equivale a un score de 0 o 100", Value: r.config.RatingChangeRange},
+		{Name: "price_band_pct", Description: "Variación del precio objetivo (%) que equivale a un score de 0 o 100", Value: r.config.PriceBandPct},
+		{Name: "recency_decay_days", Description: "Constante del decaimiento por antigüedad, en días", Value: r.config.RecencyDecayDays},
 	}
+}
+
+// GenerateRecommendations genera recomendaciones basadas en los stocks más recientes,
+// sin consenso, con la hora del reloj del recomendador como fecha de referencia
+func (r *StockRecommender) GenerateRecommendations(stocks []models.Stock, limit int) []RecommendationResult {
+	return r.Recommend(Input{Events: stocks, AsOf: r.clock.Now()}, limit)
+}
+
+// Recommend implementa Strategy
+func (r *StockRecommender) Recommend(input Input, limit int) []RecommendationResult {
+	// Paso 1: Agrupar stocks por ticker y quedarnos con la actualización más reciente
+	latestStocks := latestPerTicker(input.Events)
 
 	// Paso 2: Calcular puntuación para cada stock
 	var results []RecommendationResult
@@ -65,8 +87,8 @@ func (r *StockRecommender) GenerateRecommendations(stocks []models.Stock, limit
 		// 3. Lo reciente que es la actualización
 
 		// Obtener valores de rating
-		fromValue, fromExists := r.ratingValues[stock.RatingFrom]
-		toValue, toExists := r.ratingValues[stock.RatingTo]
+		fromValue, fromExists := r.scale.value(stock.RatingFrom, stock.RatingFromBucket)
+		toValue, toExists := r.scale.value(stock.RatingTo, stock.RatingToBucket)
 
 		if !fromExists || !toExists {
 			// Si no podemos evaluar el rating, saltamos este stock
@@ -75,33 +97,35 @@ func (r *StockRecommender) GenerateRecommendations(stocks []models.Stock, limit
 
 		// Calcular cambio en rating (de 0 a 100)
 		ratingChange := toValue - fromValue
-		ratingScore := ((ratingChange + 4) / 8) * 100 // Normalizar a escala 0-100
-		ratingScore = math.Max(0, math.Min(100, ratingScore))
+		ratingScore := r.ratingScore(ratingChange)
 
 		// Calcular cambio en precio objetivo
-		fromPrice := r.extractPrice(stock.TargetFrom)
-		toPrice := r.extractPrice(stock.TargetTo)
-
-		var priceScore float64
-		if fromPrice > 0 && toPrice > 0 {
-			percentChange := ((toPrice - fromPrice) / fromPrice) * 100
-			priceScore = ((percentChange + 20) / 40) * 100 // Normalizar rango -20% a +20%
-			priceScore = math.Max(0, math.Min(100, priceScore))
-		} else {
-			priceScore = 50
-		}
+		fromPrice, toPrice := r.targetPrices(stock)
+		priceScore := r.priceScore(fromPrice, toPrice)
 
 		// Calcular score (máximo para actualizaciones del último día)
-		daysAgo := time.Since(stock.Time).Hours() / 24
-		recencyScore := 100 * math.Exp(-daysAgo/7) // Decaimiento exponencial (una semana -> 36%)
+		daysAgo := input.daysSince(stock.Time)
+		recencyScore := r.recencyScore(daysAgo)
 
-		finalScore := (ratingScore * 0.4) + (priceScore * 0.4) + (recencyScore * 0.2)
+		// Consenso de todas las casas de bolsa en la ventana, neutral si no está disponible
+		consensusScore := input.consensusScore(stock.Ticker)
+
+		weights := r.config.Weights
+		finalScore := (ratingScore * weights.Rating) + (priceScore * weights.Price) +
+			(recencyScore * weights.Recency) + (consensusScore * weights.Consensus)
 
 		// Solo incluir stocks con mejoras positivas
 		if ratingChange > 0 || (toPrice > fromPrice && fromPrice > 0) {
 			result := RecommendationResult{
-				Stock:           stock,
-				Score:           finalScore,
+				Stock: stock,
+				Score: finalScore,
+				Breakdown: newBreakdown(stock,
+					component("rating", ratingScore, weights.Rating),
+					component("price", priceScore, weights.Price),
+					component("recency", recencyScore, weights.Recency),
+					component("consensus", consensusScore, weights.Consensus),
+				),
+				Reasons:         r.reasons(stock, ratingChange, daysAgo),
 				Rationale:       r.generateRationale(stock, ratingChange, fromPrice, toPrice, daysAgo),
 				PotentialReturn: r.calculatePotentialReturn(fromPrice, toPrice),
 			}
@@ -109,17 +133,59 @@ func (r *StockRecommender) GenerateRecommendations(stocks []models.Stock, limit
 		}
 	}
 
-	// Paso 3: Ordenar resultados por puntuación
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+	// Paso 3: Ordenar resultados por puntuación y limitar
+	return sortAndLimit(results, limit)
+}
 
-	// Paso 4: Limitar resultados
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
+// ratingScore normaliza el cambio de rating a 0-100, con 50 si no hubo cambio
+func (r *StockRecommender) ratingScore(ratingChange float64) float64 {
+	ratingRange := r.config.RatingChangeRange
+	score := ((ratingChange + ratingRange) / (2 * ratingRange)) * 100
+	return math.Max(0, math.Min(100, score))
+}
+
+// priceScore normaliza la variación del precio objetivo al rango ±PriceBandPct,
+// con 50 si los precios no están disponibles
+func (r *StockRecommender) priceScore(fromPrice, toPrice float64) float64 {
+	if fromPrice <= 0 || toPrice <= 0 {
+		return 50
+	}
+
+	percentChange := ((toPrice - fromPrice) / fromPrice) * 100
+	band := r.config.PriceBandPct
+	score := ((percentChange + band) / (2 * band)) * 100
+	return math.Max(0, math.Min(100, score))
+}
+
+// recencyScore decae exponencialmente con la antigüedad (una constante -> 36%)
+func (r *StockRecommender) recencyScore(daysAgo float64) float64 {
+	return 100 * math.Exp(-daysAgo/r.config.RecencyDecayDays)
+}
+
+// reasons lista los motivos estructurados del score, con el mismo criterio que generateRationale
+func (r *StockRecommender) reasons(stock models.Stock, ratingChange, daysAgo float64) []Reason {
+	var reasons []Reason
+
+	rating := map[string]interface{}{
+		"from":      stock.RatingFrom,
+		"to":        stock.RatingTo,
+		"brokerage": stock.Brokerage,
+	}
+	if ratingChange > 0 {
+		reasons = append(reasons, Reason{Code: ReasonRatingUpgrade, Params: rating})
+	} else if ratingChange < 0 {
+		reasons = append(reasons, Reason{Code: ReasonRatingDowngrade, Params: rating})
 	}
 
-	return results
+	reasons = append(reasons, targetReason(stock))
+
+	if daysAgo < 7 {
+		reasons = append(reasons, Reason{Code: ReasonRecentUpdate, Params: map[string]interface{}{
+			"days_ago": int(daysAgo),
+		}})
+	}
+
+	return reasons
 }
 
 // generateRationale genera una explicación de la recomendación
@@ -142,16 +208,29 @@ func (r *StockRecommender) generateRationale(stock models.Stock, ratingChange, f
 	}
 
 	// Razón 3: Actualización reciente
+	if phrase := recencyPhrase(daysAgo); phrase != "" {
+		reasons = append(reasons, phrase)
+	}
+
+	return joinRationale(stock, reasons, "Esta acción ha mostrado características positivas en nuestro análisis")
+}
+
+// recencyPhrase describe una actualización de la última semana, vacío si es más antigua
+func recencyPhrase(daysAgo float64) string {
 	if daysAgo < 1 {
-		reasons = append(reasons, "ha sido actualizada hoy")
+		return "ha sido actualizada hoy"
 	} else if daysAgo < 2 {
-		reasons = append(reasons, "ha sido actualizada ayer")
+		return "ha sido actualizada ayer"
 	} else if daysAgo < 7 {
-		reasons = append(reasons, "ha sido actualizada esta semana")
+		return "ha sido actualizada esta semana"
 	}
+	return ""
+}
 
+// joinRationale une los motivos en una oración sobre la acción, o retorna fallback si no hay motivos
+func joinRationale(stock models.Stock, reasons []string, fallback string) string {
 	if len(reasons) == 0 {
-		return "Esta acción ha mostrado características positivas en nuestro análisis"
+		return fallback
 	}
 
 	rationale := fmt.Sprintf("La acción %s (%s) ", stock.Company, stock.Ticker)
@@ -175,8 +254,11 @@ func (r *StockRecommender) calculatePotentialReturn(fromPrice, toPrice float64)
 		return "Indeterminado"
 	}
 
-	percentChange := ((toPrice - fromPrice) / fromPrice) * 100
+	return potentialReturnLabel(((toPrice - fromPrice) / fromPrice) * 100)
+}
 
+// potentialReturnLabel clasifica un cambio porcentual del precio objetivo
+func potentialReturnLabel(percentChange float64) string {
 	if percentChange > 20 {
 		return "Alto (>20%)"
 	} else if percentChange > 10 {
@@ -190,18 +272,17 @@ func (r *StockRecommender) calculatePotentialReturn(fromPrice, toPrice float64)
 	}
 }
 
-// extractPrice extrae el valor numérico de una cadena de precio
-func (r *StockRecommender) extractPrice(priceStr string) float64 {
-	priceStr = strings.TrimSpace(priceStr)
+// targetPrices retorna los precios objetivo de un stock, o 0 si no pueden
+// interpretarse o están en monedas distintas
+func (r *StockRecommender) targetPrices(stock models.Stock) (float64, float64) {
+	if stock.TargetFromPrice == nil && stock.TargetToPrice == nil {
+		stock.ParseTargets()
+	}
 
-	if len(priceStr) > 0 && priceStr[0] == '$' {
-		priceStr = priceStr[1:]
-		var price float64
-		_, err := fmt.Sscanf(priceStr, "%f", &price)
-		if err == nil {
-			return price
-		}
+	if stock.TargetFromPrice == nil || stock.TargetToPrice == nil ||
+		stock.TargetFromPrice.Currency != stock.TargetToPrice.Currency {
+		return 0, 0
 	}
 
-	return 0
+	return stock.TargetFromPrice.Amount, stock.TargetToPrice.Amount
 }
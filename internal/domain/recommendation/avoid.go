@@ -0,0 +1,88 @@
+package recommendation
+
+import (
+	"fmt"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// AvoidStrategy la implementan las estrategias que además de recomendar pueden
+// listar acciones a evitar, ordenadas por la severidad de sus señales negativas
+type AvoidStrategy interface {
+	Avoid(input Input, limit int) []RecommendationResult
+}
+
+var _ AvoidStrategy = (*StockRecommender)(nil)
+
+// Avoid es el reflejo de Recommend: solo considera rebajas de rating y recortes del
+// precio objetivo, y el score (0-100) crece con la severidad de la rebaja, el tamaño
+// del recorte, lo reciente del evento y un consenso negativo de las casas de bolsa
+func (r *StockRecommender) Avoid(input Input, limit int) []RecommendationResult {
+	var results []RecommendationResult
+
+	for _, stock := range latestPerTicker(input.Events) {
+		fromValue, fromExists := r.scale.value(stock.RatingFrom, stock.RatingFromBucket)
+		toValue, toExists := r.scale.value(stock.RatingTo, stock.RatingToBucket)
+
+		if !fromExists || !toExists {
+			continue
+		}
+
+		ratingChange := toValue - fromValue
+		fromPrice, toPrice := r.targetPrices(stock)
+
+		// Solo incluir stocks con señales negativas
+		if ratingChange >= 0 && !(fromPrice > 0 && toPrice > 0 && toPrice < fromPrice) {
+			continue
+		}
+
+		// Cada componente es el complemento del usado en Recommend
+		downgradeScore := 100 - r.ratingScore(ratingChange)
+		cutScore := 100 - r.priceScore(fromPrice, toPrice)
+		daysAgo := input.daysSince(stock.Time)
+		recencyScore := r.recencyScore(daysAgo)
+		consensusScore := 100 - input.consensusScore(stock.Ticker)
+
+		weights := r.config.Weights
+		finalScore := (downgradeScore * weights.Rating) + (cutScore * weights.Price) +
+			(recencyScore * weights.Recency) + (consensusScore * weights.Consensus)
+
+		results = append(results, RecommendationResult{
+			Stock: stock,
+			Score: finalScore,
+			Breakdown: newBreakdown(stock,
+				component("downgrade", downgradeScore, weights.Rating),
+				component("target_cut", cutScore, weights.Price),
+				component("recency", recencyScore, weights.Recency),
+				component("negative_consensus", consensusScore, weights.Consensus),
+			),
+			Reasons:         r.reasons(stock, ratingChange, daysAgo),
+			Rationale:       r.generateAvoidRationale(stock, ratingChange, fromPrice, toPrice, daysAgo),
+			PotentialReturn: r.calculatePotentialReturn(fromPrice, toPrice),
+		})
+	}
+
+	return sortAndLimit(results, limit)
+}
+
+// generateAvoidRationale explica por qué conviene evitar una acción
+func (r *StockRecommender) generateAvoidRationale(stock models.Stock, ratingChange, fromPrice, toPrice, daysAgo float64) string {
+	var reasons []string
+
+	if ratingChange < 0 {
+		reasons = append(reasons, fmt.Sprintf("ha sido rebajada de '%s' a '%s' por %s",
+			stock.RatingFrom, stock.RatingTo, stock.Brokerage))
+	}
+
+	if fromPrice > 0 && toPrice > 0 && toPrice < fromPrice {
+		percentChange := ((fromPrice - toPrice) / fromPrice) * 100
+		reasons = append(reasons, fmt.Sprintf("tiene un recorte de %.1f%% en su precio objetivo (de %s a %s)",
+			percentChange, stock.TargetFrom, stock.TargetTo))
+	}
+
+	if phrase := recencyPhrase(daysAgo); phrase != "" {
+		reasons = append(reasons, phrase)
+	}
+
+	return joinRationale(stock, reasons, "Esta acción ha mostrado señales negativas en nuestro análisis")
+}
@@ -0,0 +1,97 @@
+package recommendation
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestAvoid(t *testing.T) {
+	events := []models.Stock{
+		// downgrade 62.5, recorte 75, recencia 100, consenso negativo 50 (hold)
+		testEvent("AAA", "Barclays", "Buy", "Hold", "$100", "$90", asOf),
+		// downgrade 93.75, sin precios 50, recencia 100*e^-2, consenso negativo 100 (strong_sell)
+		testEvent("BBB", "Barclays", "Buy", "Strong Sell", "", "", daysBefore(14)),
+		// Sin cambio de rating: downgrade 50, recorte 100, recencia 100*e^(-1/7), consenso negativo 25 (buy)
+		testEvent("CCC", "Barclays", "Buy", "Buy", "$100", "$80", daysBefore(1)),
+		// Sin señales negativas o sin valor en la escala
+		testEvent("UP", "Barclays", "Hold", "Buy", "$100", "$110", asOf),
+		testEvent("FLAT", "Barclays", "Buy", "Buy", "$100", "$100", asOf),
+		testEvent("ODD", "Barclays", "Buy", "Speculative", "$100", "$80", asOf),
+		// Solo se evalúa el evento más reciente del ticker
+		testEvent("RECOVERED", "Barclays", "Buy", "Sell", "$100", "$60", daysBefore(5)),
+		testEvent("RECOVERED", "JPMorgan", "Sell", "Buy", "$60", "$100", daysBefore(1)),
+	}
+
+	config := DefaultScoringConfig()
+	config.Weights = Weights{Rating: 0.3, Price: 0.3, Recency: 0.2, Consensus: 0.2}
+	recommender := newStockRecommender(config)
+	input := NewInput(events, daysBefore(30), asOf)
+
+	wantScores := map[string]float64{
+		"AAA": 62.5*0.3 + 75*0.3 + 100*0.2 + 50*0.2,
+		"BBB": 93.75*0.3 + 50*0.3 + 100*math.Exp(-2)*0.2 + 100*0.2,
+		"CCC": 50*0.3 + 100*0.3 + 100*math.Exp(-1.0/7)*0.2 + 25*0.2,
+	}
+	assertRanking(t, recommender.Avoid(input, 0), []string{"AAA", "CCC", "BBB"},
+		[]float64{wantScores["AAA"], wantScores["CCC"], wantScores["BBB"]})
+	assertRanking(t, recommender.Avoid(input, 2), []string{"AAA", "CCC"}, []float64{wantScores["AAA"], wantScores["CCC"]})
+
+	tests := []struct {
+		ticker     string
+		components []string
+		reasons    []ReasonCode
+		rationale  []string
+	}{
+		{
+			ticker:     "AAA",
+			components: []string{"downgrade", "target_cut", "recency", "negative_consensus"},
+			reasons:    []ReasonCode{ReasonRatingDowngrade, ReasonTargetCut, ReasonRecentUpdate},
+			rationale:  []string{"rebajada de 'Buy' a 'Hold'", "recorte de 10.0%", "actualizada hoy"},
+		},
+		{
+			ticker:     "BBB",
+			components: []string{"downgrade", "target_cut", "recency", "negative_consensus"},
+			reasons:    []ReasonCode{ReasonRatingDowngrade, ReasonTargetUnavailable},
+			rationale:  []string{"rebajada de 'Buy' a 'Strong Sell'"},
+		},
+		{
+			ticker:     "CCC",
+			components: []string{"downgrade", "target_cut", "recency", "negative_consensus"},
+			reasons:    []ReasonCode{ReasonTargetCut, ReasonRecentUpdate},
+			rationale:  []string{"recorte de 20.0%", "actualizada ayer"},
+		},
+	}
+
+	results := make(map[string]RecommendationResult)
+	for _, result := range recommender.Avoid(input, 0) {
+		results[result.Stock.Ticker] = result
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ticker, func(t *testing.T) {
+			result := results[tt.ticker]
+
+			var names []string
+			sum := 0.0
+			for _, c := range result.Breakdown.Components {
+				names = append(names, c.Name)
+				sum += c.Contribution
+			}
+			if !reflect.DeepEqual(names, tt.components) || math.Abs(sum-result.Score) > 1e-9 {
+				t.Errorf("components %v add up to %v, want %v adding up to %v", names, sum, tt.components, result.Score)
+			}
+			if got := reasonCodes(result.Reasons); !reflect.DeepEqual(got, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", got, tt.reasons)
+			}
+			for _, want := range tt.rationale {
+				if !strings.Contains(result.Rationale, want) {
+					t.Errorf("rationale = %q, want it to mention %q", result.Rationale, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package recommendation
+
+import "time"
+
+// Clock provee la hora actual. Inyectarlo permite reproducir recomendaciones y
+// evaluarlas en una fecha pasada
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock usa la hora del sistema
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock es el reloj usado por defecto
+var SystemClock Clock = systemClock{}
+
+// FixedClock es un reloj detenido en una fecha
+type FixedClock time.Time
+
+// Now retorna siempre la fecha fijada
+func (c FixedClock) Now() time.Time { return time.Time(c) }
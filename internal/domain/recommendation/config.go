@@ -0,0 +1,220 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrInvalidConfig se retorna cuando la configuración de scoring no es válida
+var ErrInvalidConfig = models.NewError(models.ErrInvalidArgument, "invalid scoring config")
+
+// Weights define el peso de cada componente en el score final. Deben sumar 1
+type Weights struct {
+	Rating  float64 `json:"rating" yaml:"rating"`
+	Price   float64 `json:"price" yaml:"price"`
+	Recency float64 `json:"recency" yaml:"recency"`
+	// Consensus pondera el consenso de todas las casas de bolsa en la ventana
+	Consensus float64 `json:"consensus" yaml:"consensus"`
+}
+
+// ConsensusConfig parametriza la estrategia basada en el consenso de casas de bolsa
+type ConsensusConfig struct {
+	// MinBrokerages es el mínimo de casas de bolsa con opinión en la ventana
+	MinBrokerages int `json:"min_brokerages" yaml:"min_brokerages"`
+	// CoverageTarget es el número de casas de bolsa que otorga la cobertura completa
+	CoverageTarget int `json:"coverage_target" yaml:"coverage_target"`
+	// MinMeanRating es el rating promedio mínimo para recomendar
+	MinMeanRating float64 `json:"min_mean_rating" yaml:"min_mean_rating"`
+	// CoverageWeight es el peso de la cobertura frente al rating promedio
+	CoverageWeight float64 `json:"coverage_weight" yaml:"coverage_weight"`
+}
+
+// MomentumConfig parametriza la estrategia basada en revisiones del precio objetivo
+type MomentumConfig struct {
+	// DecayDays es la constante de decaimiento de cada revisión por antigüedad
+	DecayDays float64 `json:"decay_days" yaml:"decay_days"`
+	// BandPct es el momentum (%) que corresponde a un score de 100
+	BandPct float64 `json:"band_pct" yaml:"band_pct"`
+	// MinRevisions es el mínimo de revisiones de precio objetivo en la ventana
+	MinRevisions int `json:"min_revisions" yaml:"min_revisions"`
+}
+
+// ScoringConfig agrupa los parámetros ajustables del modelo de recomendación
+type ScoringConfig struct {
+	// RatingValues asigna un valor numérico a calificaciones específicas, sin
+	// distinguir mayúsculas. Tiene prioridad sobre BucketValues
+	RatingValues map[string]float64 `json:"rating_values" yaml:"rating_values"`
+
+	// BucketValues asigna un valor a cada categoría canónica de la taxonomía de ratings
+	BucketValues map[models.RatingBucket]float64 `json:"bucket_values" yaml:"bucket_values"`
+
+	Weights Weights `json:"weights" yaml:"weights"`
+
+	// RatingChangeRange es el cambio de rating que corresponde a un score de 0 o 100
+	RatingChangeRange float64 `json:"rating_change_range" yaml:"rating_change_range"`
+
+	// PriceBandPct es la variación porcentual del precio objetivo que corresponde a un score de 0 o 100
+	PriceBandPct float64 `json:"price_band_pct" yaml:"price_band_pct"`
+
+	// RecencyDecayDays es la constante del decaimiento exponencial por antigüedad
+	RecencyDecayDays float64 `json:"recency_decay_days" yaml:"recency_decay_days"`
+
+	// Valores por defecto y máximos de los parámetros de la solicitud
+	DefaultLimit        int     `json:"default_limit" yaml:"default_limit"`
+	MaxLimit            int     `json:"max_limit" yaml:"max_limit"`
+	DefaultLookbackDays int     `json:"default_lookback_days" yaml:"default_lookback_days"`
+	MaxLookbackDays     int     `json:"max_lookback_days" yaml:"max_lookback_days"`
+	MinScore            float64 `json:"min_score" yaml:"min_score"`
+
+	// DefaultStrategy es la estrategia usada cuando la solicitud no indica una
+	DefaultStrategy string          `json:"default_strategy" yaml:"default_strategy"`
+	Consensus       ConsensusConfig `json:"consensus" yaml:"consensus"`
+	TargetMomentum  MomentumConfig  `json:"target_momentum" yaml:"target_momentum"`
+}
+
+// DefaultScoringConfig retorna la configuración histórica del recomendador
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		RatingValues: map[string]float64{
+			"Strong Buy":     5.0,
+			"Buy":            4.0,
+			"Outperform":     4.0,
+			"Overweight":     3.5,
+			"Neutral":        3.0,
+			"Hold":           3.0,
+			"Equal-Weight":   3.0,
+			"Market Perform": 3.0,
+			"Underperform":   2.0,
+			"Underweight":    2.0,
+			"Sell":           1.0,
+			"Strong Sell":    0.5,
+		},
+		BucketValues: map[models.RatingBucket]float64{
+			models.RatingStrongBuy:  5.0,
+			models.RatingBuy:        4.0,
+			models.RatingHold:       3.0,
+			models.RatingSell:       1.5,
+			models.RatingStrongSell: 0.5,
+		},
+		Weights: Weights{
+			Rating:  0.4,
+			Price:   0.4,
+			Recency: 0.2,
+		},
+		RatingChangeRange:   4,
+		PriceBandPct:        20,
+		RecencyDecayDays:    7,
+		DefaultLimit:        10,
+		MaxLimit:            100,
+		DefaultLookbackDays: 30,
+		MaxLookbackDays:     365,
+		MinScore:            0,
+		DefaultStrategy:     DefaultStrategyName,
+		Consensus: ConsensusConfig{
+			MinBrokerages:  2,
+			CoverageTarget: 5,
+			MinMeanRating:  3.5,
+			CoverageWeight: 0.3,
+		},
+		TargetMomentum: MomentumConfig{
+			DecayDays:    14,
+			BandPct:      20,
+			MinRevisions: 1,
+		},
+	}
+}
+
+// Validate verifica que la configuración sea utilizable, reportando todos los problemas
+func (c ScoringConfig) Validate() error {
+	var problems []string
+
+	if len(c.RatingValues) == 0 && len(c.BucketValues) == 0 {
+		problems = append(problems, "rating_values and bucket_values must not both be empty")
+	}
+	for rating, value := range c.RatingValues {
+		if strings.TrimSpace(rating) == "" {
+			problems = append(problems, "rating_values contains an empty rating")
+		}
+		if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			problems = append(problems, fmt.Sprintf("rating_values[%q] must be a non-negative number", rating))
+		}
+	}
+
+	for bucket, value := range c.BucketValues {
+		if !bucket.IsValid() {
+			problems = append(problems, fmt.Sprintf("bucket_values contains unknown bucket %q", bucket))
+		}
+		if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			problems = append(problems, fmt.Sprintf("bucket_values[%q] must be a non-negative number", bucket))
+		}
+	}
+
+	w := c.Weights
+	if w.Rating < 0 || w.Price < 0 || w.Recency < 0 || w.Consensus < 0 {
+		problems = append(problems, "weights must be non-negative")
+	}
+	if sum := w.Rating + w.Price + w.Recency + w.Consensus; math.Abs(sum-1) > 1e-6 {
+		problems = append(problems, fmt.Sprintf("weights must sum to 1 (got %g)", sum))
+	}
+
+	if c.RatingChangeRange <= 0 {
+		problems = append(problems, "rating_change_range must be positive")
+	}
+	if c.PriceBandPct <= 0 {
+		problems = append(problems, "price_band_pct must be positive")
+	}
+	if c.RecencyDecayDays <= 0 {
+		problems = append(problems, "recency_decay_days must be positive")
+	}
+
+	if c.MaxLimit < 1 {
+		problems = append(problems, "max_limit must be at least 1")
+	}
+	if c.DefaultLimit < 1 || c.DefaultLimit > c.MaxLimit {
+		problems = append(problems, "default_limit must be between 1 and max_limit")
+	}
+	if c.MaxLookbackDays < 1 {
+		problems = append(problems, "max_lookback_days must be at least 1")
+	}
+	if c.DefaultLookbackDays < 1 || c.DefaultLookbackDays > c.MaxLookbackDays {
+		problems = append(problems, "default_lookback_days must be between 1 and max_lookback_days")
+	}
+	if c.MinScore < 0 || c.MinScore > 100 {
+		problems = append(problems, "min_score must be between 0 and 100")
+	}
+
+	if _, ok := strategyFactories[c.DefaultStrategy]; !ok {
+		problems = append(problems, fmt.Sprintf("default_strategy %q is not a known strategy", c.DefaultStrategy))
+	}
+
+	if c.Consensus.MinBrokerages < 1 {
+		problems = append(problems, "consensus.min_brokerages must be at least 1")
+	}
+	if c.Consensus.CoverageTarget < c.Consensus.MinBrokerages {
+		problems = append(problems, "consensus.coverage_target must be at least consensus.min_brokerages")
+	}
+	if c.Consensus.MinMeanRating < 0 {
+		problems = append(problems, "consensus.min_mean_rating must be non-negative")
+	}
+	if c.Consensus.CoverageWeight < 0 || c.Consensus.CoverageWeight > 1 {
+		problems = append(problems, "consensus.coverage_weight must be between 0 and 1")
+	}
+
+	if c.TargetMomentum.DecayDays <= 0 {
+		problems = append(problems, "target_momentum.decay_days must be positive")
+	}
+	if c.TargetMomentum.BandPct <= 0 {
+		problems = append(problems, "target_momentum.band_pct must be positive")
+	}
+	if c.TargetMomentum.MinRevisions < 1 {
+		problems = append(problems, "target_momentum.min_revisions must be at least 1")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
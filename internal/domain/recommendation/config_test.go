@@ -0,0 +1,96 @@
+package recommendation
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestScoringConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *ScoringConfig)
+		want   []string
+	}{
+		{"default", func(c *ScoringConfig) {}, nil},
+		{"only bucket values", func(c *ScoringConfig) { c.RatingValues = nil }, nil},
+		{"empty scale", func(c *ScoringConfig) { c.RatingValues, c.BucketValues = nil, nil }, []string{"must not both be empty"}},
+		{"empty rating", func(c *ScoringConfig) { c.RatingValues[" "] = 1 }, []string{"empty rating"}},
+		{"negative rating value", func(c *ScoringConfig) { c.RatingValues["Buy"] = -1 }, []string{`rating_values["Buy"]`}},
+		{"NaN rating value", func(c *ScoringConfig) { c.RatingValues["Buy"] = math.NaN() }, []string{`rating_values["Buy"]`}},
+		{"unknown bucket", func(c *ScoringConfig) { c.BucketValues["maybe"] = 1 }, []string{`unknown bucket "maybe"`}},
+		{"negative bucket value", func(c *ScoringConfig) { c.BucketValues[models.RatingSell] = -1 }, []string{`bucket_values["sell"]`}},
+		{"weights do not sum to 1", func(c *ScoringConfig) { c.Weights.Rating = 0.5 }, []string{"sum to 1"}},
+		{"negative weight", func(c *ScoringConfig) { c.Weights = Weights{Rating: 1.2, Price: -0.2} }, []string{"non-negative"}},
+		{"consensus weight", func(c *ScoringConfig) { c.Weights = Weights{Rating: 0.3, Price: 0.3, Recency: 0.2, Consensus: 0.2} }, nil},
+		{"bands", func(c *ScoringConfig) { c.RatingChangeRange, c.PriceBandPct, c.RecencyDecayDays = 0, -1, 0 }, []string{"rating_change_range", "price_band_pct", "recency_decay_days"}},
+		{"default limit above max", func(c *ScoringConfig) { c.DefaultLimit = c.MaxLimit + 1 }, []string{"default_limit"}},
+		{"lookback", func(c *ScoringConfig) { c.MaxLookbackDays, c.DefaultLookbackDays = 0, 0 }, []string{"max_lookback_days", "default_lookback_days"}},
+		{"min score", func(c *ScoringConfig) { c.MinScore = 101 }, []string{"min_score"}},
+		{"unknown default strategy", func(c *ScoringConfig) { c.DefaultStrategy = "magic" }, []string{`default_strategy "magic"`}},
+		{"consensus", func(c *ScoringConfig) {
+			c.Consensus = ConsensusConfig{MinBrokerages: 3, CoverageTarget: 2, CoverageWeight: 2}
+		}, []string{"consensus.coverage_target", "consensus.coverage_weight"}},
+		{"momentum", func(c *ScoringConfig) { c.TargetMomentum = MomentumConfig{} }, []string{"target_momentum.decay_days", "target_momentum.band_pct", "target_momentum.min_revisions"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultScoringConfig()
+			tt.modify(&config)
+
+			err := config.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidConfig) || !errors.Is(err, models.ErrInvalidArgument) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidConfig", err)
+			}
+			// Se reportan todos los problemas, no solo el primero
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRatingScale(t *testing.T) {
+	config := DefaultScoringConfig()
+	config.RatingValues = map[string]float64{" Top Pick ": 6, "Buy": 4}
+	scale := newRatingScale(config)
+
+	tests := []struct {
+		rating string
+		bucket models.RatingBucket
+		want   float64
+		ok     bool
+	}{
+		{"top pick", "", 6, true},
+		{"BUY", models.RatingStrongBuy, 4, true}, // el valor por calificación tiene prioridad
+		{"Accumulate", models.RatingBuy, 4, true},
+		{"Strong Sell", models.RatingStrongSell, 0.5, true},
+		{"Unknown", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rating, func(t *testing.T) {
+			got, ok := scale.value(tt.rating, tt.bucket)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("value(%q, %q) = %v, %t; want %v, %t", tt.rating, tt.bucket, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	if got := scale.max(); got != 6 {
+		t.Errorf("max() = %v, want 6", got)
+	}
+}
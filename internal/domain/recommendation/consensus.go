@@ -0,0 +1,133 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/consensus"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// consensusStrategy recomienda tickers con un rating promedio alto entre varias casas de bolsa
+type consensusStrategy struct {
+	scale     ratingScale
+	maxRating float64
+	config    ConsensusConfig
+}
+
+func newConsensusStrategy(config ScoringConfig) *consensusStrategy {
+	scale := newRatingScale(config)
+
+	return &consensusStrategy{
+		scale:     scale,
+		maxRating: scale.max(),
+		config:    config.Consensus,
+	}
+}
+
+func (s *consensusStrategy) Name() string {
+	return ConsensusStrategyName
+}
+
+func (s *consensusStrategy) Description() string {
+	return "Promedia el rating vigente de cada casa de bolsa en la ventana y favorece los tickers con mayor cobertura"
+}
+
+func (s *consensusStrategy) Parameters() []Parameter {
+	return []Parameter{
+		{Name: "min_brokerages", Description: "Mínimo de casas de bolsa con opinión en la ventana", Value: s.config.MinBrokerages},
+		{Name: "coverage_target", Description: "Casas de bolsa necesarias para la cobertura completa", Value: s.config.CoverageTarget},
+		{Name: "min_mean_rating", Description: "Rating promedio mínimo para recomendar", Value: s.config.MinMeanRating},
+		{Name: "coverage_weight", Description: "Peso de la cobertura frente al rating promedio", Value: s.config.CoverageWeight},
+	}
+}
+
+func (s *consensusStrategy) Recommend(input Input, limit int) []RecommendationResult {
+	latest := latestPerTicker(input.Events)
+
+	var results []RecommendationResult
+
+	for ticker, c := range input.Consensus {
+		var ratingSum, changeSum float64
+		var rated, changes int
+
+		for _, opinion := range c.Opinions {
+			value, ok := s.scale.value(opinion.Rating, opinion.Bucket)
+			if !ok {
+				continue
+			}
+			ratingSum += value
+			rated++
+
+			if opinion.TargetChangePct != nil {
+				changeSum += *opinion.TargetChangePct
+				changes++
+			}
+		}
+
+		if rated < s.config.MinBrokerages {
+			continue
+		}
+
+		meanRating := ratingSum / float64(rated)
+		if meanRating < s.config.MinMeanRating {
+			continue
+		}
+
+		ratingScore := 0.0
+		if s.maxRating > 0 {
+			ratingScore = 100 * meanRating / s.maxRating
+		}
+		coverageScore := 100 * math.Min(1, float64(rated)/float64(s.config.CoverageTarget))
+		score := ratingScore*(1-s.config.CoverageWeight) + coverageScore*s.config.CoverageWeight
+
+		potentialReturn := "Indeterminado"
+		if changes > 0 {
+			potentialReturn = potentialReturnLabel(changeSum / float64(changes))
+		}
+
+		stock := latest[ticker]
+		results = append(results, RecommendationResult{
+			Stock: stock,
+			Score: score,
+			Breakdown: newBreakdown(stock,
+				component("rating", ratingScore, 1-s.config.CoverageWeight),
+				component("coverage", coverageScore, s.config.CoverageWeight),
+			),
+			Reasons: []Reason{
+				consensusReason(c, meanRating, rated),
+				targetReason(stock),
+			},
+			Rationale: fmt.Sprintf("La acción %s (%s) tiene un rating promedio de %.1f entre %d casas de bolsa.",
+				stock.Company, stock.Ticker, meanRating, rated),
+			PotentialReturn: potentialReturn,
+		})
+	}
+
+	return sortAndLimit(results, limit)
+}
+
+// consensusReason describe el consenso de las casas de bolsa sobre un ticker
+func consensusReason(c consensus.Consensus, meanRating float64, rated int) Reason {
+	params := map[string]interface{}{
+		"mean_rating": meanRating,
+		"brokerages":  rated,
+		"buy":         c.BuyCount,
+		"hold":        c.HoldCount,
+		"sell":        c.SellCount,
+	}
+	if c.NetChange != nil {
+		params["net_change"] = *c.NetChange
+	}
+
+	return Reason{Code: ReasonBrokerageConsensus, Params: params}
+}
+
+// targetChangePct retorna el cambio porcentual del precio objetivo de un evento
+func targetChangePct(stock models.Stock) (float64, bool) {
+	stock = withParsedTargets(stock)
+	if stock.TargetChangePct == nil {
+		return 0, false
+	}
+	return *stock.TargetChangePct, true
+}
@@ -0,0 +1,127 @@
+package recommendation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DropReason indica por qué una restricción de diversificación descartó un candidato
+type DropReason string
+
+const (
+	// DropBrokerageLimit: la casa de bolsa del evento ya alcanzó MaxPerBrokerage
+	DropBrokerageLimit DropReason = "BROKERAGE_LIMIT"
+	// DropSectorLimit: el sector del ticker ya alcanzó MaxPerSector
+	DropSectorLimit DropReason = "SECTOR_LIMIT"
+	// DropDuplicateCompany: otra clase de acción de la misma empresa tiene mejor score
+	DropDuplicateCompany DropReason = "DUPLICATE_COMPANY"
+)
+
+// Constraints son las restricciones opcionales de diversificación del ranking.
+// Los límites en 0 no se aplican
+type Constraints struct {
+	MaxPerBrokerage int  `json:"max_per_brokerage,omitempty"`
+	MaxPerSector    int  `json:"max_per_sector,omitempty"`
+	DedupeCompanies bool `json:"dedupe_companies,omitempty"`
+}
+
+// IsZero indica si no hay ninguna restricción activa
+func (c Constraints) IsZero() bool {
+	return c.MaxPerBrokerage == 0 && c.MaxPerSector == 0 && !c.DedupeCompanies
+}
+
+// DroppedCandidate es un candidato que habría entrado al ranking sin las restricciones
+type DroppedCandidate struct {
+	Ticker    string     `json:"ticker"`
+	Company   string     `json:"company"`
+	Brokerage string     `json:"brokerage"`
+	Score     float64    `json:"score"`
+	Reason    DropReason `json:"reason"`
+	// Sector del ticker cuando el motivo es SECTOR_LIMIT
+	Sector string `json:"sector,omitempty"`
+	// DuplicateOf es el ticker conservado cuando el motivo es DUPLICATE_COMPANY
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+}
+
+// Diversify recorre los resultados ordenados por score y conserva hasta limit
+// (0 sin límite) que cumplan las restricciones. Retorna también los candidatos
+// descartados mientras la lista no estaba completa. sectors asocia tickers a
+// sectores; los tickers sin sector no cuentan para MaxPerSector
+func Diversify(results []RecommendationResult, constraints Constraints, sectors map[string]string, limit int) ([]RecommendationResult, []DroppedCandidate) {
+	kept := make([]RecommendationResult, 0, len(results))
+	var dropped []DroppedCandidate
+
+	perBrokerage := make(map[string]int)
+	perSector := make(map[string]int)
+	companies := make(map[string]string)
+
+	for _, result := range results {
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+
+		stock := result.Stock
+		drop := DroppedCandidate{
+			Ticker:    stock.Ticker,
+			Company:   stock.Company,
+			Brokerage: stock.Brokerage,
+			Score:     result.Score,
+		}
+
+		companyKey := companyKey(stock.Company, stock.Ticker)
+		sector, hasSector := sectors[stock.Ticker]
+
+		switch {
+		case constraints.DedupeCompanies && companies[companyKey] != "":
+			drop.Reason = DropDuplicateCompany
+			drop.DuplicateOf = companies[companyKey]
+		case constraints.MaxPerBrokerage > 0 && perBrokerage[stock.Brokerage] >= constraints.MaxPerBrokerage:
+			drop.Reason = DropBrokerageLimit
+		case constraints.MaxPerSector > 0 && hasSector && perSector[sector] >= constraints.MaxPerSector:
+			drop.Reason = DropSectorLimit
+			drop.Sector = sector
+		default:
+			kept = append(kept, result)
+			companies[companyKey] = stock.Ticker
+			perBrokerage[stock.Brokerage]++
+			if hasSector {
+				perSector[sector]++
+			}
+			continue
+		}
+
+		dropped = append(dropped, drop)
+	}
+
+	return kept, dropped
+}
+
+// companySuffixes son palabras de forma societaria o clase de acción que no
+// distinguen a la empresa
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true,
+	"co": true, "company": true, "ltd": true, "limited": true, "plc": true,
+	"llc": true, "lp": true, "sa": true, "nv": true, "ag": true, "se": true,
+	"holdings": true, "group": true, "the": true,
+	"class": true, "cl": true, "a": true, "b": true, "c": true,
+	"ordinary": true, "common": true, "shares": true, "stock": true,
+}
+
+// companyKey normaliza el nombre de la empresa para detectar clases de acción
+// de la misma empresa (Alphabet Inc. Class A y Alphabet Inc. Class C). Sin
+// nombre se usa el ticker
+func companyKey(company, ticker string) string {
+	words := strings.FieldsFunc(strings.ToLower(company), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	// Solo se quitan sufijos al final para no vaciar nombres como "The Trade Desk"
+	for len(words) > 1 && companySuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+
+	if len(words) == 0 {
+		return "ticker:" + strings.ToUpper(ticker)
+	}
+	return strings.Join(words, " ")
+}
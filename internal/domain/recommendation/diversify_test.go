@@ -0,0 +1,129 @@
+package recommendation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+func TestDiversify(t *testing.T) {
+	// Resultados ordenados por score descendente
+	result := func(ticker, company, brokerage string, score float64) RecommendationResult {
+		return RecommendationResult{Stock: models.Stock{Ticker: ticker, Company: company, Brokerage: brokerage}, Score: score}
+	}
+	results := []RecommendationResult{
+		result("GOOGL", "Alphabet Inc. Class A", "Barclays", 90),
+		result("GOOG", "Alphabet Inc. Class C", "JPMorgan", 85),
+		result("MSFT", "Microsoft Corporation", "Barclays", 80),
+		result("XOM", "Exxon Mobil Corp", "Citigroup", 75),
+		result("CVX", "Chevron Corp", "Citigroup", 70),
+		result("AAPL", "Apple Inc.", "Barclays", 65),
+	}
+	sectors := map[string]string{"GOOGL": "tech", "GOOG": "tech", "MSFT": "tech", "XOM": "energy", "CVX": "energy"}
+
+	tests := []struct {
+		name        string
+		constraints Constraints
+		sectors     map[string]string
+		limit       int
+		kept        []string
+		dropped     []DroppedCandidate
+	}{
+		{
+			name:  "no constraints",
+			limit: 3,
+			kept:  []string{"GOOGL", "GOOG", "MSFT"},
+		},
+		{
+			name:        "brokerage limit",
+			constraints: Constraints{MaxPerBrokerage: 1},
+			kept:        []string{"GOOGL", "GOOG", "XOM"},
+			dropped: []DroppedCandidate{
+				{Ticker: "MSFT", Company: "Microsoft Corporation", Brokerage: "Barclays", Score: 80, Reason: DropBrokerageLimit},
+				{Ticker: "CVX", Company: "Chevron Corp", Brokerage: "Citigroup", Score: 70, Reason: DropBrokerageLimit},
+				{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "Barclays", Score: 65, Reason: DropBrokerageLimit},
+			},
+		},
+		{
+			// AAPL no tiene sector y no cuenta para el límite
+			name:        "sector limit",
+			constraints: Constraints{MaxPerSector: 1},
+			sectors:     sectors,
+			kept:        []string{"GOOGL", "XOM", "AAPL"},
+			dropped: []DroppedCandidate{
+				{Ticker: "GOOG", Company: "Alphabet Inc. Class C", Brokerage: "JPMorgan", Score: 85, Reason: DropSectorLimit, Sector: "tech"},
+				{Ticker: "MSFT", Company: "Microsoft Corporation", Brokerage: "Barclays", Score: 80, Reason: DropSectorLimit, Sector: "tech"},
+				{Ticker: "CVX", Company: "Chevron Corp", Brokerage: "Citigroup", Score: 70, Reason: DropSectorLimit, Sector: "energy"},
+			},
+		},
+		{
+			name:        "sector limit without sector data",
+			constraints: Constraints{MaxPerSector: 1},
+			limit:       2,
+			kept:        []string{"GOOGL", "GOOG"},
+		},
+		{
+			name:        "duplicate company",
+			constraints: Constraints{DedupeCompanies: true},
+			limit:       2,
+			kept:        []string{"GOOGL", "MSFT"},
+			dropped: []DroppedCandidate{
+				{Ticker: "GOOG", Company: "Alphabet Inc. Class C", Brokerage: "JPMorgan", Score: 85, Reason: DropDuplicateCompany, DuplicateOf: "GOOGL"},
+			},
+		},
+		{
+			// La empresa duplicada se reporta antes que el límite de casa de bolsa, y
+			// los candidatos posteriores al límite no se evalúan
+			name:        "combined constraints stop at the limit",
+			constraints: Constraints{MaxPerBrokerage: 1, DedupeCompanies: true},
+			limit:       2,
+			kept:        []string{"GOOGL", "XOM"},
+			dropped: []DroppedCandidate{
+				{Ticker: "GOOG", Company: "Alphabet Inc. Class C", Brokerage: "JPMorgan", Score: 85, Reason: DropDuplicateCompany, DuplicateOf: "GOOGL"},
+				{Ticker: "MSFT", Company: "Microsoft Corporation", Brokerage: "Barclays", Score: 80, Reason: DropBrokerageLimit},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := Diversify(results, tt.constraints, tt.sectors, tt.limit)
+
+			tickers := []string{}
+			for _, result := range kept {
+				tickers = append(tickers, result.Stock.Ticker)
+			}
+			if !reflect.DeepEqual(tickers, tt.kept) {
+				t.Errorf("kept = %v, want %v", tickers, tt.kept)
+			}
+			if !reflect.DeepEqual(dropped, tt.dropped) {
+				t.Errorf("dropped = %+v, want %+v", dropped, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestCompanyKey(t *testing.T) {
+	tests := []struct {
+		company string
+		ticker  string
+		want    string
+	}{
+		{"Alphabet Inc. Class A", "GOOGL", "alphabet"},
+		{"ALPHABET INC - CL C", "GOOG", "alphabet"},
+		{"Berkshire Hathaway Inc. Class B", "BRK.B", "berkshire hathaway"},
+		{"The Trade Desk, Inc.", "TTD", "the trade desk"},
+		{"Group", "GRP", "group"},
+		{"", "abc", "ticker:ABC"},
+		{" ... ", "abc", "ticker:ABC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.company, func(t *testing.T) {
+			if got := companyKey(tt.company, tt.ticker); got != tt.want {
+				t.Errorf("companyKey(%q, %q) = %q, want %q", tt.company, tt.ticker, got, tt.want)
+			}
+		})
+	}
+}
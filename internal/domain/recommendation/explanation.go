@@ -0,0 +1,99 @@
+package recommendation
+
+import (
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ReasonCode identifica de forma estable un motivo de la recomendación, para que
+// los clientes puedan presentarlo y traducirlo
+type ReasonCode string
+
+const (
+	// La calificación mejoró (params: from, to, brokerage)
+	ReasonRatingUpgrade ReasonCode = "RATING_UPGRADE"
+	// La calificación empeoró (params: from, to, brokerage)
+	ReasonRatingDowngrade ReasonCode = "RATING_DOWNGRADE"
+	// El precio objetivo subió (params: change_pct, from, to)
+	ReasonTargetRaised ReasonCode = "TARGET_RAISED"
+	// El precio objetivo bajó (params: change_pct, from, to)
+	ReasonTargetCut ReasonCode = "TARGET_CUT"
+	// Los precios objetivo no pudieron interpretarse o están en monedas distintas
+	ReasonTargetUnavailable ReasonCode = "TARGET_UNAVAILABLE"
+	// La última actualización es reciente (params: days_ago)
+	ReasonRecentUpdate ReasonCode = "RECENT_UPDATE"
+	// Varias casas de bolsa coinciden en un rating alto (params: mean_rating, brokerages)
+	ReasonBrokerageConsensus ReasonCode = "BROKERAGE_CONSENSUS"
+	// Las revisiones del precio objetivo son al alza (params: momentum_pct, raises, cuts)
+	ReasonTargetMomentum ReasonCode = "TARGET_MOMENTUM"
+)
+
+// Reason es un motivo estructurado con los valores necesarios para describirlo
+type Reason struct {
+	Code   ReasonCode             `json:"code"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// ScoreComponent es el aporte de un componente al score final
+type ScoreComponent struct {
+	Name         string  `json:"name"`
+	Score        float64 `json:"score"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// ScoreBreakdown desglosa el score final en sus componentes y los datos usados
+type ScoreBreakdown struct {
+	Components      []ScoreComponent `json:"components"`
+	TargetChangePct *float64         `json:"target_change_pct"`
+	TargetFromPrice *models.Price    `json:"target_from_price"`
+	TargetToPrice   *models.Price    `json:"target_to_price"`
+}
+
+// component construye un ScoreComponent calculando su aporte
+func component(name string, score, weight float64) ScoreComponent {
+	return ScoreComponent{
+		Name:         name,
+		Score:        score,
+		Weight:       weight,
+		Contribution: score * weight,
+	}
+}
+
+// newBreakdown crea un desglose con los precios objetivo interpretados del stock
+func newBreakdown(stock models.Stock, components ...ScoreComponent) *ScoreBreakdown {
+	stock = withParsedTargets(stock)
+
+	return &ScoreBreakdown{
+		Components:      components,
+		TargetChangePct: stock.TargetChangePct,
+		TargetFromPrice: stock.TargetFromPrice,
+		TargetToPrice:   stock.TargetToPrice,
+	}
+}
+
+// targetReason describe el cambio del precio objetivo de un stock
+func targetReason(stock models.Stock) Reason {
+	stock = withParsedTargets(stock)
+	if stock.TargetChangePct == nil {
+		return Reason{Code: ReasonTargetUnavailable}
+	}
+
+	code := ReasonTargetRaised
+	if *stock.TargetChangePct < 0 {
+		code = ReasonTargetCut
+	}
+
+	return Reason{Code: code, Params: map[string]interface{}{
+		"change_pct": *stock.TargetChangePct,
+		"from":       stock.TargetFrom,
+		"to":         stock.TargetTo,
+	}}
+}
+
+// withParsedTargets interpreta los precios objetivo si el stock aún no los tiene
+func withParsedTargets(stock models.Stock) models.Stock {
+	if stock.TargetFromPrice == nil && stock.TargetToPrice == nil {
+		stock.ParseTargets()
+	}
+	return stock
+}
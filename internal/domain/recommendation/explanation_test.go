@@ -0,0 +1,142 @@
+package recommendation
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// reasonCodes retorna los códigos de los motivos en orden
+func reasonCodes(reasons []Reason) []ReasonCode {
+	codes := make([]ReasonCode, len(reasons))
+	for i, reason := range reasons {
+		codes[i] = reason.Code
+	}
+	return codes
+}
+
+func TestRecommendBreakdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      models.Stock
+		components map[string]float64
+		reasons    []ReasonCode
+		targetPct  *float64
+	}{
+		{
+			name:  "upgrade with target raise",
+			event: testEvent("AAA", "Barclays", "Hold", "Buy", "$100", "$110", asOf),
+			// rating: (1+4)/8, precio: (10+20)/40, sin consenso de otras casas de bolsa
+			components: map[string]float64{"rating": 62.5, "price": 75, "recency": 100, "consensus": 75},
+			reasons:    []ReasonCode{ReasonRatingUpgrade, ReasonTargetRaised, ReasonRecentUpdate},
+			targetPct:  floatPtr(10),
+		},
+		{
+			name:       "upgrade without targets",
+			event:      testEvent("BBB", "Barclays", "Sell", "Buy", "", "", daysBefore(7)),
+			components: map[string]float64{"rating": 87.5, "price": 50, "recency": 100 * math.Exp(-1), "consensus": 75},
+			reasons:    []ReasonCode{ReasonRatingUpgrade, ReasonTargetUnavailable},
+		},
+		{
+			name:       "target raise with downgrade",
+			event:      testEvent("CCC", "Barclays", "Buy", "Hold", "$100", "$150", daysBefore(1.5)),
+			components: map[string]float64{"rating": 37.5, "price": 100, "recency": 100 * math.Exp(-1.5/7), "consensus": 50},
+			reasons:    []ReasonCode{ReasonRatingDowngrade, ReasonTargetRaised, ReasonRecentUpdate},
+			targetPct:  floatPtr(50),
+		},
+		{
+			name:       "targets in different currencies",
+			event:      testEvent("DDD", "Barclays", "Hold", "Buy", "$100", "€110", daysBefore(3)),
+			components: map[string]float64{"rating": 62.5, "price": 50, "recency": 100 * math.Exp(-3.0/7), "consensus": 75},
+			reasons:    []ReasonCode{ReasonRatingUpgrade, ReasonTargetUnavailable, ReasonRecentUpdate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultScoringConfig()
+			config.Weights = Weights{Rating: 0.3, Price: 0.3, Recency: 0.2, Consensus: 0.2}
+
+			results := newStockRecommender(config).Recommend(NewInput([]models.Stock{tt.event}, daysBefore(30), asOf), 0)
+			if len(results) != 1 {
+				t.Fatalf("Recommend() returned %d results, want 1", len(results))
+			}
+			result := results[0]
+
+			// Los componentes explican el score completo
+			sum := 0.0
+			for _, c := range result.Breakdown.Components {
+				want, ok := tt.components[c.Name]
+				if !ok || math.Abs(c.Score-want) > 0.01 {
+					t.Errorf("component %s = %.2f, want %.2f", c.Name, c.Score, want)
+				}
+				if c.Contribution != c.Score*c.Weight {
+					t.Errorf("component %s contribution = %v, want score * weight", c.Name, c.Contribution)
+				}
+				sum += c.Contribution
+			}
+			if len(result.Breakdown.Components) != len(tt.components) || math.Abs(sum-result.Score) > 1e-9 {
+				t.Errorf("components %+v add up to %v, want %d components adding up to %v", result.Breakdown.Components, sum, len(tt.components), result.Score)
+			}
+
+			if got := reasonCodes(result.Reasons); !reflect.DeepEqual(got, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", got, tt.reasons)
+			}
+
+			got := result.Breakdown.TargetChangePct
+			if (got == nil) != (tt.targetPct == nil) || (got != nil && math.Abs(*got-*tt.targetPct) > 1e-9) {
+				t.Errorf("target_change_pct = %v, want %v", got, tt.targetPct)
+			}
+		})
+	}
+}
+
+func TestRecommendSkipsUnrankableEvents(t *testing.T) {
+	events := []models.Stock{
+		// Sin mejora de rating ni alza del precio objetivo
+		testEvent("FLAT", "Barclays", "Buy", "Buy", "$100", "$100", asOf),
+		testEvent("CUT", "Barclays", "Buy", "Buy", "$100", "$90", asOf),
+		// Calificación sin valor en la escala ni categoría
+		testEvent("ODD", "Barclays", "Hold", "Speculative", "$100", "$120", asOf),
+		// Solo se evalúa el evento más reciente del ticker
+		testEvent("LATEST", "Barclays", "Hold", "Buy", "$100", "$120", daysBefore(5)),
+		testEvent("LATEST", "JPMorgan", "Buy", "Sell", "$120", "$80", daysBefore(1)),
+	}
+
+	if results := NewStockRecommender().Recommend(NewInput(events, daysBefore(30), asOf), 0); len(results) != 0 {
+		t.Errorf("Recommend() = %+v, want no results", results)
+	}
+}
+
+func TestStrategyReasons(t *testing.T) {
+	events := []models.Stock{
+		testEvent("AAA", "Barclays", "Hold", "Buy", "$100", "$110", daysBefore(1)),
+		testEvent("AAA", "JPMorgan", "Buy", "Strong Buy", "$100", "$120", daysBefore(2)),
+		testEvent("AAA", "Citigroup", "Buy", "Buy", "$130", "$120", daysBefore(3)),
+	}
+	input := NewInput(events, daysBefore(30), asOf)
+	config := DefaultScoringConfig()
+
+	consensusResult := newConsensusStrategy(config).Recommend(input, 0)[0]
+	if got := reasonCodes(consensusResult.Reasons); !reflect.DeepEqual(got, []ReasonCode{ReasonBrokerageConsensus, ReasonTargetRaised}) {
+		t.Errorf("consensus reasons = %v", got)
+	}
+	params := consensusResult.Reasons[0].Params
+	if params["brokerages"] != 3 || params["buy"] != 3 || math.Abs(params["mean_rating"].(float64)-13.0/3) > 1e-9 {
+		t.Errorf("consensus reason params = %v", params)
+	}
+
+	momentumResult := newMomentumStrategy(config).Recommend(input, 0)[0]
+	if got := reasonCodes(momentumResult.Reasons); !reflect.DeepEqual(got, []ReasonCode{ReasonTargetMomentum}) {
+		t.Errorf("momentum reasons = %v", got)
+	}
+	params = momentumResult.Reasons[0].Params
+	if params["raises"] != 2 || params["cuts"] != 1 {
+		t.Errorf("momentum reason params = %v, want 2 raises and 1 cut", params)
+	}
+	if len(momentumResult.Breakdown.Components) != 1 || momentumResult.Breakdown.Components[0].Contribution != momentumResult.Score {
+		t.Errorf("momentum breakdown = %+v, want a single component with the whole score", momentumResult.Breakdown.Components)
+	}
+}
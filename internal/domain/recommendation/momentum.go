@@ -0,0 +1,97 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// momentumStrategy recomienda tickers cuyas revisiones recientes del precio objetivo son al alza
+type momentumStrategy struct {
+	config MomentumConfig
+}
+
+func newMomentumStrategy(config ScoringConfig) *momentumStrategy {
+	return &momentumStrategy{config: config.TargetMomentum}
+}
+
+func (s *momentumStrategy) Name() string {
+	return TargetMomentumStrategyName
+}
+
+func (s *momentumStrategy) Description() string {
+	return "Suma las revisiones del precio objetivo de la ventana ponderadas por antigüedad"
+}
+
+func (s *momentumStrategy) Parameters() []Parameter {
+	return []Parameter{
+		{Name: "decay_days", Description: "Constante del decaimiento de cada revisión por antigüedad, en días", Value: s.config.DecayDays},
+		{Name: "band_pct", Description: "Momentum (%) que equivale a un score de 100", Value: s.config.BandPct},
+		{Name: "min_revisions", Description: "Mínimo de revisiones del precio objetivo en la ventana", Value: s.config.MinRevisions},
+	}
+}
+
+func (s *momentumStrategy) Recommend(input Input, limit int) []RecommendationResult {
+	type tickerMomentum struct {
+		latest       models.Stock
+		momentum     float64
+		raises, cuts int
+		revisions    int
+	}
+
+	byTicker := make(map[string]*tickerMomentum)
+
+	for _, event := range input.Events {
+		m, exists := byTicker[event.Ticker]
+		if !exists {
+			m = &tickerMomentum{}
+			byTicker[event.Ticker] = m
+		}
+		if event.Time.After(m.latest.Time) {
+			m.latest = event
+		}
+
+		pct, ok := targetChangePct(event)
+		if !ok || pct == 0 {
+			continue
+		}
+
+		daysAgo := input.daysSince(event.Time)
+		m.momentum += pct * math.Exp(-daysAgo/s.config.DecayDays)
+		m.revisions++
+		if pct > 0 {
+			m.raises++
+		} else {
+			m.cuts++
+		}
+	}
+
+	var results []RecommendationResult
+
+	for _, m := range byTicker {
+		if m.revisions < s.config.MinRevisions || m.momentum <= 0 {
+			continue
+		}
+
+		score := math.Min(100, 100*m.momentum/s.config.BandPct)
+
+		results = append(results, RecommendationResult{
+			Stock:     m.latest,
+			Score:     score,
+			Breakdown: newBreakdown(m.latest, component("momentum", score, 1)),
+			Reasons: []Reason{
+				{Code: ReasonTargetMomentum, Params: map[string]interface{}{
+					"momentum_pct": m.momentum,
+					"raises":       m.raises,
+					"cuts":         m.cuts,
+				}},
+			},
+			Rationale: fmt.Sprintf("La acción %s (%s) acumula %d revisiones del precio objetivo (%d alzas, %d recortes) con un momentum de %.1f%%.",
+				m.latest.Company, m.latest.Ticker, m.revisions, m.raises, m.cuts, m.momentum),
+			PotentialReturn: potentialReturnLabel(m.momentum),
+		})
+	}
+
+	return sortAndLimit(results, limit)
+}
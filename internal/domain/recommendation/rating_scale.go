@@ -0,0 +1,48 @@
+package recommendation
+
+import (
+	"math"
+	"strings"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ratingScale convierte calificaciones en valores numéricos usando primero los
+// valores por calificación y luego los de su categoría canónica
+type ratingScale struct {
+	ratings map[string]float64
+	buckets map[models.RatingBucket]float64
+}
+
+func newRatingScale(config ScoringConfig) ratingScale {
+	ratings := make(map[string]float64, len(config.RatingValues))
+	for rating, value := range config.RatingValues {
+		ratings[strings.ToLower(strings.TrimSpace(rating))] = value
+	}
+
+	return ratingScale{ratings: ratings, buckets: config.BucketValues}
+}
+
+// value retorna el valor de una calificación y si pudo evaluarse
+func (s ratingScale) value(rating string, bucket models.RatingBucket) (float64, bool) {
+	if value, ok := s.ratings[strings.ToLower(strings.TrimSpace(rating))]; ok {
+		return value, true
+	}
+	if bucket != "" {
+		value, ok := s.buckets[bucket]
+		return value, ok
+	}
+	return 0, false
+}
+
+// max retorna el mayor valor de la escala
+func (s ratingScale) max() float64 {
+	max := 0.0
+	for _, value := range s.ratings {
+		max = math.Max(max, value)
+	}
+	for _, value := range s.buckets {
+		max = math.Max(max, value)
+	}
+	return max
+}
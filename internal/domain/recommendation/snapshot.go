@@ -0,0 +1,118 @@
+package recommendation
+
+import (
+	"sort"
+	"time"
+)
+
+// SnapshotDateLayout es el formato de la fecha de un snapshot, un día UTC
+const SnapshotDateLayout = "2006-01-02"
+
+// Snapshot es el ranking de recomendaciones de una estrategia mostrado en un día.
+// Cada día conserva el último snapshot tomado
+type Snapshot struct {
+	Date            string                 `json:"date"`
+	Strategy        string                 `json:"strategy"`
+	TakenAt         time.Time              `json:"taken_at"`
+	AsOf            time.Time              `json:"as_of"`
+	Recommendations []RecommendationResult `json:"recommendations"`
+}
+
+// RankChange describe la posición de un ticker en los dos snapshots comparados.
+// Los rankings empiezan en 1; FromRank o ToRank son nil si el ticker no estaba
+type RankChange struct {
+	Ticker    string   `json:"ticker"`
+	Company   string   `json:"company"`
+	FromRank  *int     `json:"from_rank,omitempty"`
+	ToRank    *int     `json:"to_rank,omitempty"`
+	FromScore *float64 `json:"from_score,omitempty"`
+	ToScore   *float64 `json:"to_score,omitempty"`
+	// Move es la cantidad de posiciones ganadas, negativa si el ticker bajó
+	Move int `json:"move"`
+}
+
+// SnapshotDiff compara dos snapshots de la misma estrategia
+type SnapshotDiff struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Strategy string `json:"strategy"`
+
+	Entries   []RankChange `json:"entries"`
+	Exits     []RankChange `json:"exits"`
+	Moves     []RankChange `json:"moves"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// DiffSnapshots calcula las entradas, salidas y cambios de posición entre dos snapshots.
+// Las entradas se ordenan por su nuevo ranking, las salidas por el anterior y los
+// movimientos por la magnitud del cambio
+func DiffSnapshots(from, to Snapshot) SnapshotDiff {
+	diff := SnapshotDiff{
+		From:     from.Date,
+		To:       to.Date,
+		Strategy: to.Strategy,
+		Entries:  []RankChange{},
+		Exits:    []RankChange{},
+		Moves:    []RankChange{},
+	}
+
+	previous := make(map[string]int, len(from.Recommendations))
+	for i, rec := range from.Recommendations {
+		previous[rec.Stock.Ticker] = i
+	}
+	current := make(map[string]bool, len(to.Recommendations))
+
+	for i, rec := range to.Recommendations {
+		current[rec.Stock.Ticker] = true
+		change := RankChange{
+			Ticker:  rec.Stock.Ticker,
+			Company: rec.Stock.Company,
+			ToRank:  intPtr(i + 1),
+			ToScore: floatPtr(rec.Score),
+		}
+
+		j, existed := previous[rec.Stock.Ticker]
+		if !existed {
+			diff.Entries = append(diff.Entries, change)
+			continue
+		}
+
+		change.FromRank = intPtr(j + 1)
+		change.FromScore = floatPtr(from.Recommendations[j].Score)
+		change.Move = j - i
+		if change.Move == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Moves = append(diff.Moves, change)
+	}
+
+	for i, rec := range from.Recommendations {
+		if current[rec.Stock.Ticker] {
+			continue
+		}
+		diff.Exits = append(diff.Exits, RankChange{
+			Ticker:    rec.Stock.Ticker,
+			Company:   rec.Stock.Company,
+			FromRank:  intPtr(i + 1),
+			FromScore: floatPtr(rec.Score),
+		})
+	}
+
+	sort.SliceStable(diff.Moves, func(i, j int) bool {
+		return abs(diff.Moves[i].Move) > abs(diff.Moves[j].Move)
+	})
+
+	return diff
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
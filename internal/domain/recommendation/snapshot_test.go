@@ -0,0 +1,124 @@
+package recommendation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// testSnapshot construye un snapshot con los tickers dados, en orden de ranking.
+// El score de cada ticker baja diez puntos por posición
+func testSnapshot(date string, tickers ...string) Snapshot {
+	snapshot := Snapshot{Date: date, Strategy: DefaultStrategyName, Recommendations: []RecommendationResult{}}
+	for i, ticker := range tickers {
+		snapshot.Recommendations = append(snapshot.Recommendations, RecommendationResult{
+			Stock: models.Stock{Ticker: ticker, Company: ticker + " Inc."},
+			Score: float64(100 - 10*i),
+		})
+	}
+	return snapshot
+}
+
+// rankChange resume un cambio como ticker, ranking previo, ranking nuevo y movimiento;
+// un ranking ausente es 0
+type rankChange struct {
+	ticker   string
+	from, to int
+	move     int
+}
+
+func summarize(changes []RankChange) []rankChange {
+	summary := []rankChange{}
+	for _, c := range changes {
+		s := rankChange{ticker: c.Ticker, move: c.Move}
+		if c.FromRank != nil {
+			s.from = *c.FromRank
+		}
+		if c.ToRank != nil {
+			s.to = *c.ToRank
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  []string
+		entries   []rankChange
+		exits     []rankChange
+		moves     []rankChange
+		unchanged int
+	}{
+		{
+			name: "same ranking",
+			from: []string{"AAA", "BBB"}, to: []string{"AAA", "BBB"},
+			entries: []rankChange{}, exits: []rankChange{}, moves: []rankChange{},
+			unchanged: 2,
+		},
+		{
+			name: "entries and exits",
+			from: []string{"AAA", "BBB", "CCC"}, to: []string{"DDD", "AAA", "EEE"},
+			entries: []rankChange{{"DDD", 0, 1, 0}, {"EEE", 0, 3, 0}},
+			exits:   []rankChange{{"BBB", 2, 0, 0}, {"CCC", 3, 0, 0}},
+			moves:   []rankChange{{"AAA", 1, 2, -1}},
+		},
+		{
+			// Los movimientos se ordenan por magnitud; los empates conservan el nuevo ranking
+			name: "moves by magnitude",
+			from: []string{"AAA", "BBB", "CCC", "DDD"}, to: []string{"DDD", "BBB", "AAA", "CCC"},
+			entries: []rankChange{}, exits: []rankChange{},
+			moves:     []rankChange{{"DDD", 4, 1, 3}, {"AAA", 1, 3, -2}, {"CCC", 3, 4, -1}},
+			unchanged: 1,
+		},
+		{
+			name: "from an empty snapshot",
+			from: nil, to: []string{"AAA"},
+			entries: []rankChange{{"AAA", 0, 1, 0}}, exits: []rankChange{}, moves: []rankChange{},
+		},
+		{
+			name: "to an empty snapshot",
+			from: []string{"AAA"}, to: nil,
+			entries: []rankChange{}, exits: []rankChange{{"AAA", 1, 0, 0}}, moves: []rankChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffSnapshots(testSnapshot("2025-03-09", tt.from...), testSnapshot("2025-03-10", tt.to...))
+
+			if diff.From != "2025-03-09" || diff.To != "2025-03-10" || diff.Strategy != DefaultStrategyName {
+				t.Errorf("diff of %s to %s (%s), want 2025-03-09 to 2025-03-10 (%s)", diff.From, diff.To, diff.Strategy, DefaultStrategyName)
+			}
+			if got := summarize(diff.Entries); !reflect.DeepEqual(got, tt.entries) {
+				t.Errorf("entries = %+v, want %+v", got, tt.entries)
+			}
+			if got := summarize(diff.Exits); !reflect.DeepEqual(got, tt.exits) {
+				t.Errorf("exits = %+v, want %+v", got, tt.exits)
+			}
+			if got := summarize(diff.Moves); !reflect.DeepEqual(got, tt.moves) {
+				t.Errorf("moves = %+v, want %+v", got, tt.moves)
+			}
+			if diff.Unchanged != tt.unchanged {
+				t.Errorf("unchanged = %d, want %d", diff.Unchanged, tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsScores(t *testing.T) {
+	diff := DiffSnapshots(testSnapshot("2025-03-09", "AAA", "BBB"), testSnapshot("2025-03-10", "BBB", "CCC"))
+
+	move := diff.Moves[0]
+	if move.Company != "BBB Inc." || move.FromScore == nil || *move.FromScore != 90 || move.ToScore == nil || *move.ToScore != 100 {
+		t.Errorf("move = %+v, want BBB Inc. from 90 to 100", move)
+	}
+	if entry := diff.Entries[0]; entry.FromScore != nil || entry.ToScore == nil || *entry.ToScore != 90 {
+		t.Errorf("entry = %+v, want only the new score 90", entry)
+	}
+	if exit := diff.Exits[0]; exit.ToScore != nil || exit.FromScore == nil || *exit.FromScore != 100 {
+		t.Errorf("exit = %+v, want only the previous score 100", exit)
+	}
+}
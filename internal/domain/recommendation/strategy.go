@@ -0,0 +1,189 @@
+package recommendation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/consensus"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// ErrUnknownStrategy se retorna cuando se solicita una estrategia no registrada
+var ErrUnknownStrategy = models.NewError(models.ErrInvalidArgument, "unknown recommendation strategy")
+
+// Nombres de las estrategias incluidas
+const (
+	DefaultStrategyName        = "default"
+	ConsensusStrategyName      = "consensus"
+	TargetMomentumStrategyName = "target_momentum"
+)
+
+// Input son los datos de una ventana de análisis: los eventos de rating de todas
+// las casas de bolsa y el consenso por ticker calculado con ellos. AsOf es la fecha
+// de referencia para medir la antigüedad, lo que permite evaluar fechas pasadas
+type Input struct {
+	Events    []models.Stock
+	From      time.Time
+	To        time.Time
+	AsOf      time.Time
+	Consensus map[string]consensus.Consensus
+}
+
+// NewInput construye la entrada de las estrategias calculando el consenso de cada
+// ticker, con el final de la ventana como fecha de referencia
+func NewInput(events []models.Stock, from, to time.Time) Input {
+	return Input{
+		Events:    events,
+		From:      from,
+		To:        to,
+		AsOf:      to,
+		Consensus: consensus.BuildAll(events, from, to),
+	}
+}
+
+// daysSince retorna los días transcurridos desde t hasta la fecha de referencia,
+// o hasta la hora del sistema si la entrada no la tiene
+func (in Input) daysSince(t time.Time) float64 {
+	asOf := in.AsOf
+	if asOf.IsZero() {
+		asOf = SystemClock.Now()
+	}
+	return asOf.Sub(t).Hours() / 24
+}
+
+// consensusScore retorna el consenso normalizado (0-100) de un ticker, o 50 si no está disponible
+func (in Input) consensusScore(ticker string) float64 {
+	if c, ok := in.Consensus[ticker]; ok {
+		if normalized, ok := c.NormalizedScore(); ok {
+			return normalized
+		}
+	}
+	return 50
+}
+
+// Strategy genera recomendaciones a partir de los datos de una ventana.
+// Los resultados se retornan ordenados por score descendente
+type Strategy interface {
+	Name() string
+	Description() string
+	Parameters() []Parameter
+	Recommend(input Input, limit int) []RecommendationResult
+}
+
+// Parameter describe un parámetro de una estrategia y su valor vigente
+type Parameter struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Value       interface{} `json:"value"`
+}
+
+// StrategyInfo describe una estrategia registrada
+type StrategyInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Default     bool        `json:"default"`
+	Parameters  []Parameter `json:"parameters"`
+}
+
+// strategyFactories construye las estrategias incluidas a partir de la configuración
+var strategyFactories = map[string]func(ScoringConfig) Strategy{
+	DefaultStrategyName:        func(c ScoringConfig) Strategy { return newStockRecommender(c) },
+	ConsensusStrategyName:      func(c ScoringConfig) Strategy { return newConsensusStrategy(c) },
+	TargetMomentumStrategyName: func(c ScoringConfig) Strategy { return newMomentumStrategy(c) },
+}
+
+// Registry mantiene las estrategias disponibles por nombre
+type Registry struct {
+	mu          sync.RWMutex
+	strategies  map[string]Strategy
+	defaultName string
+}
+
+// NewRegistry crea un registro con las estrategias incluidas configuradas con config
+func NewRegistry(config ScoringConfig) (*Registry, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	registry := &Registry{
+		strategies:  make(map[string]Strategy, len(strategyFactories)),
+		defaultName: config.DefaultStrategy,
+	}
+	for _, factory := range strategyFactories {
+		registry.Register(factory(config))
+	}
+
+	return registry, nil
+}
+
+// Register agrega o reemplaza una estrategia
+func (r *Registry) Register(strategy Strategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.strategies[strategy.Name()] = strategy
+}
+
+// Get obtiene una estrategia por nombre; un nombre vacío retorna la estrategia por defecto
+func (r *Registry) Get(name string) (Strategy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.defaultName
+	}
+
+	strategy, ok := r.strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+	}
+	return strategy, nil
+}
+
+// List describe las estrategias registradas ordenadas por nombre
+func (r *Registry) List() []StrategyInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]StrategyInfo, 0, len(r.strategies))
+	for name, strategy := range r.strategies {
+		infos = append(infos, StrategyInfo{
+			Name:        name,
+			Description: strategy.Description(),
+			Default:     name == r.defaultName,
+			Parameters:  strategy.Parameters(),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// latestPerTicker conserva el evento más reciente de cada ticker
+func latestPerTicker(events []models.Stock) map[string]models.Stock {
+	latest := make(map[string]models.Stock)
+	for _, event := range events {
+		existing, exists := latest[event.Ticker]
+		if !exists || event.Time.After(existing.Time) {
+			latest[event.Ticker] = event
+		}
+	}
+	return latest
+}
+
+// sortAndLimit ordena por score descendente (ticker como desempate) y aplica el límite
+func sortAndLimit(results []RecommendationResult, limit int) []RecommendationResult {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Stock.Ticker < results[j].Stock.Ticker
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,190 @@
+package recommendation
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+)
+
+// asOf es la fecha de referencia de las pruebas de estrategias
+var asOf = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// testEvent construye un evento de rating con los precios objetivo interpretados
+// y las calificaciones clasificadas con la taxonomía por defecto
+func testEvent(ticker, brokerage, from, to, targetFrom, targetTo string, at time.Time) models.Stock {
+	stock := models.Stock{
+		Ticker: ticker, Company: ticker + " Inc.", Brokerage: brokerage,
+		RatingFrom: from, RatingTo: to, TargetFrom: targetFrom, TargetTo: targetTo, Time: at,
+	}
+	stock.ParseTargets()
+	stock.ClassifyRatings(models.DefaultRatingTaxonomy())
+	return stock
+}
+
+// daysBefore retorna la fecha days días antes de asOf
+func daysBefore(days float64) time.Time {
+	return asOf.Add(-time.Duration(days * 24 * float64(time.Hour)))
+}
+
+// assertRanking verifica el orden de los tickers y sus scores
+func assertRanking(t *testing.T, results []RecommendationResult, tickers []string, scores []float64) {
+	t.Helper()
+
+	if len(results) != len(tickers) {
+		got := make([]string, len(results))
+		for i, result := range results {
+			got[i] = result.Stock.Ticker
+		}
+		t.Fatalf("ranking = %v, want %v", got, tickers)
+	}
+	for i, result := range results {
+		if result.Stock.Ticker != tickers[i] || math.Abs(result.Score-scores[i]) > 0.01 {
+			t.Errorf("ranking[%d] = %s (%.2f), want %s (%.2f)", i, result.Stock.Ticker, result.Score, tickers[i], scores[i])
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	invalid := DefaultScoringConfig()
+	invalid.DefaultStrategy = "magic"
+	if _, err := NewRegistry(invalid); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewRegistry() error = %v, want ErrInvalidConfig", err)
+	}
+
+	config := DefaultScoringConfig()
+	config.DefaultStrategy = ConsensusStrategyName
+	registry, err := NewRegistry(config)
+	if err != nil {
+		t.Fatalf("NewRegistry() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		{"", ConsensusStrategyName, nil},
+		{DefaultStrategyName, DefaultStrategyName, nil},
+		{ConsensusStrategyName, ConsensusStrategyName, nil},
+		{TargetMomentumStrategyName, TargetMomentumStrategyName, nil},
+		{"Default", "", ErrUnknownStrategy},
+		{"magic", "", ErrUnknownStrategy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := registry.Get(tt.name)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !errors.Is(err, models.ErrInvalidArgument) {
+					t.Fatalf("Get(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get(%q) error: %v", tt.name, err)
+			}
+			if strategy.Name() != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.name, strategy.Name(), tt.want)
+			}
+		})
+	}
+
+	infos := registry.List()
+	want := []string{ConsensusStrategyName, DefaultStrategyName, TargetMomentumStrategyName}
+	if len(infos) != len(want) {
+		t.Fatalf("List() returned %d strategies, want %d", len(infos), len(want))
+	}
+	for i, info := range infos {
+		if info.Name != want[i] || info.Default != (info.Name == ConsensusStrategyName) || len(info.Parameters) == 0 {
+			t.Errorf("List()[%d] = %+v, want %s with parameters", i, info, want[i])
+		}
+	}
+
+	// Register reemplaza una estrategia con el mismo nombre
+	replacement := newStockRecommender(config).WithClock(FixedClock(asOf))
+	registry.Register(replacement)
+	if strategy, _ := registry.Get(DefaultStrategyName); strategy != replacement {
+		t.Error("Register() did not replace the default strategy")
+	}
+}
+
+func TestMomentumStrategy(t *testing.T) {
+	events := []models.Stock{
+		testEvent("RAISE", "Barclays", "Buy", "Buy", "$100", "$110", asOf),
+		// Una constante de decaimiento (14 días) reduce la revisión al 37%
+		testEvent("OLD", "Barclays", "Buy", "Buy", "$100", "$110", daysBefore(14)),
+		testEvent("CUT", "Barclays", "Buy", "Buy", "$100", "$90", asOf),
+		testEvent("FLAT", "Barclays", "Hold", "Buy", "$100", "$100", asOf),
+		testEvent("NOTARGET", "Barclays", "Hold", "Buy", "", "", asOf),
+		// Las revisiones se suman: +30% y -10% dan un momentum de 20%
+		testEvent("MIXED", "Barclays", "Buy", "Buy", "$100", "$130", asOf),
+		testEvent("MIXED", "Goldman Sachs", "Buy", "Buy", "$100", "$90", daysBefore(0)),
+	}
+
+	tests := []struct {
+		name         string
+		minRevisions int
+		limit        int
+		tickers      []string
+		scores       []float64
+	}{
+		{"all", 1, 0, []string{"MIXED", "RAISE", "OLD"}, []float64{100, 50, 50 * math.Exp(-1)}},
+		{"limit", 1, 2, []string{"MIXED", "RAISE"}, []float64{100, 50}},
+		{"min revisions", 2, 0, []string{"MIXED"}, []float64{100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultScoringConfig()
+			config.TargetMomentum.MinRevisions = tt.minRevisions
+
+			results := newMomentumStrategy(config).Recommend(NewInput(events, daysBefore(30), asOf), tt.limit)
+			assertRanking(t, results, tt.tickers, tt.scores)
+		})
+	}
+}
+
+func TestConsensusStrategy(t *testing.T) {
+	events := []models.Stock{
+		// Solo cuenta la opinión vigente de cada casa de bolsa
+		testEvent("AAA", "Barclays", "Hold", "Sell", "", "", daysBefore(10)),
+		testEvent("AAA", "Barclays", "Sell", "Buy", "$100", "$120", daysBefore(2)),
+		testEvent("AAA", "Goldman Sachs", "Buy", "Strong Buy", "$100", "$110", daysBefore(1)),
+		// Una sola casa de bolsa no alcanza min_brokerages
+		testEvent("ONE", "Barclays", "Buy", "Strong Buy", "", "", daysBefore(1)),
+		// El promedio (3.5) alcanza justo min_mean_rating
+		testEvent("EDGE", "Barclays", "Buy", "Hold", "", "", daysBefore(1)),
+		testEvent("EDGE", "JPMorgan", "Hold", "Buy", "", "", daysBefore(1)),
+		testEvent("LOW", "Barclays", "Buy", "Sell", "", "", daysBefore(1)),
+		testEvent("LOW", "JPMorgan", "Buy", "Hold", "", "", daysBefore(1)),
+		// Fuera de la ventana
+		testEvent("OUT", "Barclays", "Buy", "Strong Buy", "", "", daysBefore(40)),
+		testEvent("OUT", "JPMorgan", "Buy", "Strong Buy", "", "", daysBefore(40)),
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *ConsensusConfig)
+		tickers []string
+		scores  []float64
+	}{
+		// AAA: rating 4.5/5 = 90, cobertura 2/5 = 40 -> 0.7*90 + 0.3*40
+		{"default", func(c *ConsensusConfig) {}, []string{"AAA", "EDGE"}, []float64{75, 61}},
+		{"coverage target", func(c *ConsensusConfig) { c.CoverageTarget = 2 }, []string{"AAA", "EDGE"}, []float64{93, 79}},
+		{"min mean rating", func(c *ConsensusConfig) { c.MinMeanRating = 4 }, []string{"AAA"}, []float64{75}},
+		{"min brokerages", func(c *ConsensusConfig) { c.MinBrokerages = 1 }, []string{"ONE", "AAA", "EDGE"}, []float64{76, 75, 61}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultScoringConfig()
+			tt.modify(&config.Consensus)
+
+			results := newConsensusStrategy(config).Recommend(NewInput(events, daysBefore(30), asOf), 0)
+			assertRanking(t, results, tt.tickers, tt.scores)
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +20,63 @@ type Config struct {
 	DBSSLMode       string
 	StockAPIBaseURL string
 	StockAPIToken   string
+
+	// Reintentos y límite de tasa hacia la API externa
+	StockAPIMaxRetries     int
+	StockAPIRetryBaseDelay time.Duration
+	StockAPIRetryMaxDelay  time.Duration
+	StockAPIRetryDeadline  time.Duration
+	StockAPIRateLimit      float64
+	StockAPIRateBurst      int
+
+	// StorageDriver selecciona el almacenamiento: "cockroachdb" o "memory" (modo demo)
+	StorageDriver string
+
+	// Archivo JSON o YAML con la configuración de scoring de recomendaciones (opcional)
+	// y cada cuánto se revisa para recargarlo
+	ScoringConfigPath           string
+	ScoringConfigReloadInterval time.Duration
+
+	// Archivo CSV o Parquet con los precios de cierre diarios usados por los backtests (opcional)
+	BacktestPricesPath string
+
+	// Cada cuánto se verifica que exista el snapshot diario de recomendaciones (0 lo desactiva)
+	RecommendationSnapshotInterval time.Duration
+
+	// Autenticación con API keys en /api/v1. APIAdminKey registra al iniciar una clave
+	// admin conocida, para crear las demás con los endpoints de administración, y es
+	// obligatoria con la autenticación activa
+	AuthEnabled bool
+	APIAdminKey string
+
+	// Cache en memoria de recomendaciones y stocks por ticker: cantidad máxima de
+	// respuestas (0 lo desactiva), vigencia de cada una y cada cuánto se revisa si
+	// otra réplica sincronizó datos nuevos (0 lo desactiva)
+	CacheSize                 int
+	CacheTTL                  time.Duration
+	CacheInvalidationInterval time.Duration
+
+	// Orígenes permitidos por CORS
+	CORSAllowedOrigins []string
+
+	// Límite de tasa por cliente en /api/v1 (solicitudes por segundo y ráfaga), con
+	// límites propios para stocks y recomendaciones, y cuota diaria por API key (0 sin cuota)
+	RateLimitEnabled              bool
+	RateLimitRPS                  float64
+	RateLimitBurst                int
+	RateLimitStocksRPS            float64
+	RateLimitStocksBurst          int
+	RateLimitRecommendationsRPS   float64
+	RateLimitRecommendationsBurst int
+	RateLimitIPRPS                float64
+	RateLimitIPBurst              int
+	APIDailyQuota                 int
+
+	// Proxies de confianza delante del servidor, por cantidad de saltos o por red
+	// (CIDR o IP). Solo las direcciones de X-Forwarded-For agregadas por ellos
+	// identifican al cliente en los límites por IP
+	TrustedProxyHops int
+	TrustedProxies   []string
 }
 
 func NewConfig() *Config {
@@ -31,7 +93,55 @@ func NewConfig() *Config {
 		// Stock API
 		StockAPIBaseURL: getEnv("STOCK_API_BASE_URL", "https://api.stockapi.com/v1/stocks"),
 		StockAPIToken:   getEnv("STOCK_API_AUTH_TOKEN", ""),
+
+		StockAPIMaxRetries:     getEnvInt("STOCK_API_MAX_RETRIES", 5),
+		StockAPIRetryBaseDelay: getEnvDuration("STOCK_API_RETRY_BASE_DELAY", 500*time.Millisecond),
+		StockAPIRetryMaxDelay:  getEnvDuration("STOCK_API_RETRY_MAX_DELAY", 30*time.Second),
+		StockAPIRetryDeadline:  getEnvDuration("STOCK_API_RETRY_DEADLINE", 10*time.Minute),
+		StockAPIRateLimit:      getEnvFloat("STOCK_API_RATE_LIMIT", 5),
+		StockAPIRateBurst:      getEnvInt("STOCK_API_RATE_BURST", 5),
+
+		StorageDriver: getEnv("STORAGE_DRIVER", "cockroachdb"),
+
+		ScoringConfigPath:           getEnv("SCORING_CONFIG_PATH", ""),
+		ScoringConfigReloadInterval: getEnvDuration("SCORING_CONFIG_RELOAD_INTERVAL", 30*time.Second),
+
+		BacktestPricesPath: getEnv("BACKTEST_PRICES_PATH", ""),
+
+		RecommendationSnapshotInterval: getEnvDuration("RECOMMENDATION_SNAPSHOT_INTERVAL", time.Hour),
+
+		AuthEnabled: getEnvBool("AUTH_ENABLED", true),
+		APIAdminKey: getEnv("API_ADMIN_KEY", ""),
+
+		CacheSize:                 getEnvInt("CACHE_SIZE", 1000),
+		CacheTTL:                  getEnvDuration("CACHE_TTL", 5*time.Minute),
+		CacheInvalidationInterval: getEnvDuration("CACHE_INVALIDATION_INTERVAL", 10*time.Second),
+
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+
+		RateLimitEnabled:              getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitRPS:                  getEnvFloat("RATE_LIMIT_RPS", 10),
+		RateLimitBurst:                getEnvInt("RATE_LIMIT_BURST", 20),
+		RateLimitStocksRPS:            getEnvFloat("RATE_LIMIT_STOCKS_RPS", 5),
+		RateLimitStocksBurst:          getEnvInt("RATE_LIMIT_STOCKS_BURST", 10),
+		RateLimitRecommendationsRPS:   getEnvFloat("RATE_LIMIT_RECOMMENDATIONS_RPS", 1),
+		RateLimitRecommendationsBurst: getEnvInt("RATE_LIMIT_RECOMMENDATIONS_BURST", 5),
+		RateLimitIPRPS:                getEnvFloat("RATE_LIMIT_IP_RPS", 20),
+		RateLimitIPBurst:              getEnvInt("RATE_LIMIT_IP_BURST", 40),
+		APIDailyQuota:                 getEnvInt("API_DAILY_QUOTA", 0),
+
+		TrustedProxyHops: getEnvInt("TRUSTED_PROXY_HOPS", 0),
+		TrustedProxies:   getEnvList("TRUSTED_PROXIES", nil),
+	}
+}
+
+// Validate revisa las combinaciones de variables con las que el servidor no puede
+// operar. Con AUTH_ENABLED sin API_ADMIN_KEY nadie podría crear la primera API key
+func (c *Config) Validate() error {
+	if c.AuthEnabled && strings.TrimSpace(c.APIAdminKey) == "" {
+		return errors.New("API_ADMIN_KEY is required when AUTH_ENABLED is true (set AUTH_ENABLED=false only for local development)")
 	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -41,6 +151,66 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvInt lee una variable entera, usando el valor por defecto si falta o es inválida
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Valor inválido para %s: %q, usando %d", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvFloat lee una variable decimal, usando el valor por defecto si falta o es inválida
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+		log.Printf("Valor inválido para %s: %q, usando %g", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvDuration lee una duración como "500ms" o "2m", usando el valor por defecto si falta o es inválida
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+		log.Printf("Valor inválido para %s: %q, usando %s", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvBool lee una variable booleana ("true", "false", "1", "0"), usando el valor por
+// defecto si falta o es inválida
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		log.Printf("Valor inválido para %s: %q, usando %t", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvList lee una lista separada por comas, usando el valor por defecto si falta o está vacía
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
 func (c *Config) GetDBConnectionString() string {
 	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=%s",
 		c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName, c.DBSSLMode)
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		authEnabled bool
+		wantErr     bool
+	}{
+		{"auth is enabled by default", map[string]string{"API_ADMIN_KEY": strings.Repeat("k", 32)}, true, false},
+		{"auth without admin key", nil, true, true},
+		{"auth with a blank admin key", map[string]string{"API_ADMIN_KEY": "   "}, true, true},
+		{"auth disabled", map[string]string{"AUTH_ENABLED": "false"}, false, false},
+		// Un valor inválido no desactiva la autenticación
+		{"invalid auth flag", map[string]string{"AUTH_ENABLED": "nope"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"AUTH_ENABLED", "API_ADMIN_KEY"} {
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg := NewConfig()
+			if cfg.AuthEnabled != tt.authEnabled {
+				t.Errorf("AuthEnabled = %t, want %t", cfg.AuthEnabled, tt.authEnabled)
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "API_ADMIN_KEY") {
+				t.Errorf("Validate() error = %q, want it to mention API_ADMIN_KEY", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+// LoadScoringConfig lee la configuración de scoring desde un archivo JSON o YAML.
+// Los campos ausentes conservan su valor por defecto y el resultado se valida
+func LoadScoringConfig(path string) (recommendation.ScoringConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return recommendation.ScoringConfig{}, fmt.Errorf("error reading scoring config: %w", err)
+	}
+
+	config := recommendation.DefaultScoringConfig()
+	defaultRatings, defaultBuckets := config.RatingValues, config.BucketValues
+
+	// rating_values y bucket_values reemplazan la escala completa en lugar de mezclarse con la de por defecto
+	config.RatingValues, config.BucketValues = nil, nil
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&config)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&config)
+	default:
+		return recommendation.ScoringConfig{}, fmt.Errorf("unsupported scoring config format: %s (use .json, .yaml or .yml)", path)
+	}
+	if err != nil {
+		return recommendation.ScoringConfig{}, fmt.Errorf("error parsing scoring config %s: %w", path, err)
+	}
+
+	if config.RatingValues == nil {
+		config.RatingValues = defaultRatings
+	}
+	if config.BucketValues == nil {
+		config.BucketValues = defaultBuckets
+	}
+
+	if err := config.Validate(); err != nil {
+		return recommendation.ScoringConfig{}, err
+	}
+
+	return config, nil
+}
+
+// WatchScoringConfig revisa periódicamente el archivo y aplica la configuración cuando
+// cambia. Una configuración inválida se registra y se conserva la anterior
+func WatchScoringConfig(ctx context.Context, path string, interval time.Duration, apply func(recommendation.ScoringConfig) error) {
+	lastModified := modTime(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modified := modTime(path)
+		if modified.IsZero() || modified.Equal(lastModified) {
+			continue
+		}
+		lastModified = modified
+
+		config, err := LoadScoringConfig(path)
+		if err != nil {
+			log.Printf("Configuración de scoring inválida, se conserva la anterior: %v", err)
+			continue
+		}
+		if err := apply(config); err != nil {
+			log.Printf("Error al aplicar la configuración de scoring: %v", err)
+			continue
+		}
+
+		log.Printf("Configuración de scoring recargada desde %s", path)
+	}
+}
+
+// modTime retorna la fecha de modificación del archivo, o cero si no puede leerse
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RobertCastro/stock-insights-api/internal/domain/models"
+	"github.com/RobertCastro/stock-insights-api/internal/domain/recommendation"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+}
+
+func TestLoadScoringConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		check   func(t *testing.T, c recommendation.ScoringConfig)
+		wantErr string
+	}{
+		{
+			name:    "yaml keeps defaults for missing fields",
+			file:    "scoring.yaml",
+			content: "weights:\n  rating: 0.5\n  price: 0.3\n  recency: 0.2\nmax_limit: 50\n",
+			check: func(t *testing.T, c recommendation.ScoringConfig) {
+				if c.Weights.Rating != 0.5 || c.MaxLimit != 50 {
+					t.Errorf("weights %+v, max_limit %d; want the file values", c.Weights, c.MaxLimit)
+				}
+				if c.DefaultLimit != 10 || c.RatingValues["Buy"] != 4 || c.BucketValues[models.RatingHold] != 3 {
+					t.Error("missing fields did not keep their default value")
+				}
+			},
+		},
+		{
+			name:    "json replaces the whole rating scale",
+			file:    "scoring.json",
+			content: `{"rating_values": {"Top Pick": 5}}`,
+			check: func(t *testing.T, c recommendation.ScoringConfig) {
+				if len(c.RatingValues) != 1 || c.RatingValues["Top Pick"] != 5 {
+					t.Errorf("rating_values = %v, want only Top Pick", c.RatingValues)
+				}
+				if len(c.BucketValues) != len(models.RatingBuckets) {
+					t.Errorf("bucket_values = %v, want the default buckets", c.BucketValues)
+				}
+			},
+		},
+		{name: "unknown field", file: "scoring.yaml", content: "weigths:\n  rating: 1\n", wantErr: "weigths"},
+		{name: "invalid config", file: "scoring.json", content: `{"weights": {"rating": 1, "price": 1}}`, wantErr: "sum to 1"},
+		{name: "unsupported format", file: "scoring.toml", content: "", wantErr: "unsupported scoring config format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeFile(t, path, tt.content)
+
+			config, err := LoadScoringConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadScoringConfig() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadScoringConfig() error: %v", err)
+			}
+			tt.check(t, config)
+		})
+	}
+
+	if _, err := LoadScoringConfig(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadScoringConfig() for a missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestWatchScoringConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scoring.yaml")
+	writeFile(t, path, "max_limit: 50\n")
+
+	var mu sync.Mutex
+	var applied []int
+	apply := func(c recommendation.ScoringConfig) error {
+		mu.Lock()
+		defer mu.Unlock()
+		applied = append(applied, c.MaxLimit)
+		return nil
+	}
+	appliedLimits := func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), applied...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchScoringConfig(ctx, path, 5*time.Millisecond, apply)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// touch reemplaza el archivo de forma atómica con una fecha de modificación
+	// distinta, aunque el sistema de archivos tenga poca resolución
+	modified := time.Now()
+	touch := func(content string) {
+		modified = modified.Add(time.Second)
+		tmp := path + ".tmp"
+		writeFile(t, tmp, content)
+		if err := os.Chtimes(tmp, modified, modified); err != nil {
+			t.Fatalf("Chtimes() error: %v", err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatalf("Rename() error: %v", err)
+		}
+	}
+	waitFor := func(want []int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			got := appliedLimits()
+			if len(got) == len(want) {
+				for i := range want {
+					if got[i] != want[i] {
+						t.Fatalf("applied max_limit values = %v, want %v", got, want)
+					}
+				}
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("applied max_limit values = %v, want %v", got, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Sin cambios no se aplica nada; una configuración inválida se ignora y la
+	// siguiente válida se aplica
+	time.Sleep(50 * time.Millisecond)
+	if got := appliedLimits(); len(got) != 0 {
+		t.Fatalf("applied %v without changes to the file", got)
+	}
+	touch("max_limit: 0\n")
+	touch("max_limit: 75\n")
+	waitFor([]int{75})
+
+	touch("max_limit: 80\n")
+	waitFor([]int{75, 80})
+}